@@ -0,0 +1,975 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Netflix/go-env"
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/alert"
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/demeyerthom/feeds-aggregator/internal/release"
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+	"github.com/demeyerthom/feeds-aggregator/internal/workflow"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.temporal.io/sdk/client"
+)
+
+const usage = `Usage: admin <command> [arguments]
+
+Commands:
+  alerts list                                          List alert rules
+  alerts add -name N [-expr E] [-regex R] [-categories A,B] [-feeds A,B] [-notify T,T] [-format F] [-secret S]
+                                                       Add an alert rule notifying log, email:ADDRESS or webhook:URL targets
+  alerts remove ID                                     Remove an alert rule
+  alerts test [-n N] [-expr E] [-regex R] [-categories A,B] [-feeds A,B]
+                                                       List the recent processed items matching a rule
+  categories list                                      List categories with aliases and item counts
+  categories add [-description D] [-parent P] [-alias A,B] NAME
+                                                       Add a category to the taxonomy
+  categories merge -into NAME FROM [FROM...]           Merge categories into NAME and rewrite feed items
+  feeds list [-user U]                                 List the subscriptions of a user, or the polled feeds with their subscribers
+  feeds subscribe -user U -title T [-type release] URL Subscribe a user to a feed
+  feeds unsubscribe -user U ID                         Remove a subscription of a user
+  feeds import -user U FILE                            Subscribe a user to the feeds of a feeds.json list
+  items search [-k N] QUESTION                         List the items semantically nearest to a question
+  items reprocess ID [ID...]                           Process items again with the LLM, bypassing the response cache
+  keys list USER                                       List the API keys of a user
+  keys create [-name N] USER                           Create an API key for a user; the key is only shown once
+  keys revoke ID                                       Revoke an API key
+  releases list [-since V] [-breaking] [-prereleases] PRODUCT
+                                                       List the releases of a product, e.g. breaking changes since 1.8
+  subscribers list                                     List digest subscribers
+  subscribers add [-name N] [-frequency daily|weekly] [-categories A,B] [-disabled] EMAIL
+                                                       Add or update a digest subscriber
+  subscribers remove EMAIL                             Remove a digest subscriber
+  users list                                           List users
+  users add [-name N] [-email E] [-disabled] ID        Add or update a user
+  users remove ID                                      Remove a user with their API keys, subscriptions and item states
+  webhooks list                                        List webhook subscriptions
+  webhooks add [-name N] [-format json|slack|discord] [-secret S] [-categories A,B] [-feeds A,B] [-keywords A,B] URL
+                                                       Add a webhook subscription; json webhooks get a generated secret
+  webhooks remove ID                                   Remove a webhook subscription
+  webhooks deliveries [-n N] ID                        List the last delivery attempts of a webhook subscription
+`
+
+// errUsage is returned when the command line cannot be parsed
+var errUsage = errors.New("invalid arguments")
+
+var (
+	cfg Configuration
+
+	mongoClient *mongo.Client
+)
+
+type Configuration struct {
+	MongoDB struct {
+		URI string `env:"MONGODB_URI,default=mongodb://localhost:27017"`
+	}
+	Logging struct {
+		Level string `env:"LOG_LEVEL,default=info"`
+	}
+	Temporal struct {
+		Host string `env:"TEMPORAL_HOST,default=localhost:7233"`
+	}
+	Embedding struct {
+		Host    string        `env:"EMBEDDING_HOST,default=http://localhost:11434/v1/"`
+		Model   string        `env:"EMBEDDING_MODEL,default=nomic-embed-text"`
+		APIKey  string        `env:"EMBEDDING_API_KEY"`
+		Timeout time.Duration `env:"EMBEDDING_TIMEOUT,default=1m"`
+	}
+}
+
+func init() {
+	_, err := env.UnmarshalFromEnviron(&cfg)
+	if err != nil {
+		slog.Error("Failed to unmarshal environment", "err", err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	slog.SetLogLoggerLevel(internal.ParseLogLevel(cfg.Logging.Level))
+
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Initialize MongoDB client
+	mongoCtx, mongoCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer mongoCancel()
+
+	var err error
+	mongoClient, err = mongo.Connect(mongoCtx, options.Client().ApplyURI(cfg.MongoDB.URI))
+	if err != nil {
+		slog.Error("Failed to connect to MongoDB", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			slog.Error("Failed to disconnect from MongoDB", "err", err)
+		}
+	}()
+
+	if err := run(ctx, os.Args[1], os.Args[2], os.Args[3:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		slog.Error("Command failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a command and subcommand
+func run(ctx context.Context, command, subcommand string, args []string) error {
+	db := mongoClient.Database(internal.MongoDBName)
+
+	switch command {
+	case "alerts":
+		store := alert.NewStore(db.Collection(internal.MongoAlertRuleCollection))
+		switch subcommand {
+		case "list":
+			return listAlertRules(ctx, store)
+		case "add":
+			return addAlertRule(ctx, store, args)
+		case "remove":
+			if len(args) != 1 {
+				return errUsage
+			}
+			id, err := primitive.ObjectIDFromHex(args[0])
+			if err != nil {
+				return fmt.Errorf("%w: %w", errUsage, err)
+			}
+			if err := store.Remove(ctx, id); err != nil {
+				return err
+			}
+			slog.Info("Removed alert rule", "id", args[0])
+			return nil
+		case "test":
+			return testAlertRule(ctx, db.Collection(internal.MongoFeedItemCollection), args)
+		}
+	case "categories":
+		store := taxonomy.NewStore(db.Collection(internal.MongoCategoryCollection), db.Collection(internal.MongoFeedItemCollection),
+			db.Collection(internal.MongoItemStateCollection), db.Collection(internal.MongoReadMarkerCollection))
+		switch subcommand {
+		case "list":
+			return listCategories(ctx, store)
+		case "add":
+			return addCategory(ctx, store, args)
+		case "merge":
+			return mergeCategories(ctx, store, args)
+		}
+	case "feeds":
+		store := newAccountStore(db)
+		switch subcommand {
+		case "list":
+			return listFeeds(ctx, store, args)
+		case "subscribe":
+			return subscribeFeed(ctx, store, args)
+		case "unsubscribe":
+			return unsubscribeFeed(ctx, store, args)
+		case "import":
+			return importFeeds(ctx, store, args)
+		}
+	case "items":
+		switch subcommand {
+		case "search":
+			return searchItems(ctx, db, args)
+		case "reprocess":
+			return reprocessItems(ctx, db, args)
+		}
+	case "keys":
+		store := newAccountStore(db)
+		switch subcommand {
+		case "list":
+			return listKeys(ctx, store, args)
+		case "create":
+			return createKey(ctx, store, args)
+		case "revoke":
+			if len(args) != 1 {
+				return errUsage
+			}
+			id, err := primitive.ObjectIDFromHex(args[0])
+			if err != nil {
+				return fmt.Errorf("%w: %w", errUsage, err)
+			}
+			if err := store.RevokeKey(ctx, id); err != nil {
+				return err
+			}
+			slog.Info("Revoked API key", "id", args[0])
+			return nil
+		}
+	case "releases":
+		switch subcommand {
+		case "list":
+			return listReleases(ctx, release.NewStore(db.Collection(internal.MongoFeedItemCollection)), args)
+		}
+	case "users":
+		store := newAccountStore(db)
+		switch subcommand {
+		case "list":
+			return listUsers(ctx, store)
+		case "add":
+			return addUser(ctx, store, args)
+		case "remove":
+			if len(args) != 1 {
+				return errUsage
+			}
+			if err := store.RemoveUser(ctx, args[0]); err != nil {
+				return err
+			}
+			states := itemstate.NewStore(db.Collection(internal.MongoItemStateCollection), db.Collection(internal.MongoReadMarkerCollection), db.Collection(internal.MongoFeedItemCollection))
+			if err := states.RemoveUser(ctx, args[0]); err != nil {
+				return err
+			}
+			slog.Info("Removed user", "id", args[0])
+			return nil
+		}
+	case "webhooks":
+		store := webhook.NewStore(db.Collection(internal.MongoWebhookCollection), db.Collection(internal.MongoWebhookDeliveryCollection))
+		switch subcommand {
+		case "list":
+			return listWebhooks(ctx, store)
+		case "add":
+			return addWebhook(ctx, store, args)
+		case "remove":
+			if len(args) != 1 {
+				return errUsage
+			}
+			id, err := primitive.ObjectIDFromHex(args[0])
+			if err != nil {
+				return fmt.Errorf("%w: %w", errUsage, err)
+			}
+			if err := store.Remove(ctx, id); err != nil {
+				return err
+			}
+			slog.Info("Removed webhook subscription", "id", args[0])
+			return nil
+		case "deliveries":
+			return listWebhookDeliveries(ctx, store, args)
+		}
+	case "subscribers":
+		store := digest.NewStore(db.Collection(internal.MongoDigestSubscriberCollection), db.Collection(internal.MongoFeedItemCollection))
+		switch subcommand {
+		case "list":
+			return listSubscribers(ctx, store)
+		case "add":
+			return addSubscriber(ctx, store, args)
+		case "remove":
+			if len(args) != 1 {
+				return errUsage
+			}
+			if err := store.Remove(ctx, args[0]); err != nil {
+				return err
+			}
+			slog.Info("Removed subscriber", "email", args[0])
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown command %s %s", errUsage, command, subcommand)
+}
+
+func listAlertRules(ctx context.Context, store *alert.Store) error {
+	rules, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tEXPRESSION\tREGEX\tCATEGORIES\tFEEDS\tTARGETS\tDISABLED")
+	for _, r := range rules {
+		targets := make([]string, 0, len(r.Targets))
+		for _, t := range r.Targets {
+			targets = append(targets, t.String())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\n", r.ID.Hex(), r.Name, r.Expression, r.Regex,
+			strings.Join(r.Categories, ", "), strings.Join(r.Feeds, ", "), strings.Join(targets, ", "), r.Disabled)
+	}
+	return w.Flush()
+}
+
+// ruleFlags defines the flags of the conditions of an alert rule on fs and returns a function
+// building the rule once fs is parsed.
+func ruleFlags(fs *flag.FlagSet) func() alert.Rule {
+	expr := fs.String("expr", "", `boolean expression, e.g. 'cve:* AND (product:openssl OR "heartbleed")'`)
+	regex := fs.String("regex", "", "regular expression matched against the title, summary and article text")
+	categories := fs.String("categories", "", "comma-separated categories, any of which must match")
+	feeds := fs.String("feeds", "", "comma-separated feed titles, any of which must match")
+	return func() alert.Rule {
+		r := alert.Rule{Expression: *expr, Regex: *regex}
+		if *categories != "" {
+			r.Categories = strings.Split(*categories, ",")
+		}
+		if *feeds != "" {
+			r.Feeds = strings.Split(*feeds, ",")
+		}
+		return r
+	}
+}
+
+func addAlertRule(ctx context.Context, store *alert.Store, args []string) error {
+	fs := flag.NewFlagSet("alerts add", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the rule")
+	notify := fs.String("notify", alert.TargetLog, "comma-separated targets: log, email:ADDRESS or webhook:URL")
+	format := fs.String("format", string(webhook.FormatJSON), "message format of webhook targets: json, slack or discord")
+	secret := fs.String("secret", "", "secret signing the payloads of webhook targets")
+	rule := ruleFlags(fs)
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *name == "" {
+		return errUsage
+	}
+	f, err := webhook.ParseFormat(*format)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	r := rule()
+	r.Name = *name
+	for _, s := range strings.Split(*notify, ",") {
+		t, err := alert.ParseTarget(s)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+		if t.Type == alert.TargetWebhook {
+			t.Format = f
+			t.Secret = *secret
+		}
+		r.Targets = append(r.Targets, t)
+	}
+
+	r, err = store.Add(ctx, r)
+	if err != nil {
+		return err
+	}
+	slog.Info("Added alert rule", "id", r.ID.Hex(), "name", r.Name, "targets", len(r.Targets))
+	return nil
+}
+
+// testAlertRule evaluates a rule against the most recent processed items, to try a rule before adding it.
+func testAlertRule(ctx context.Context, items *mongo.Collection, args []string) error {
+	fs := flag.NewFlagSet("alerts test", flag.ContinueOnError)
+	n := fs.Int("n", 500, "number of recent processed items to evaluate")
+	rule := ruleFlags(fs)
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	compiled, err := alert.Compile(rule())
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(*n))
+	cursor, err := items.Find(ctx, bson.M{"status": internal.StatusProcessed}, opts)
+	if err != nil {
+		return err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CREATED\tFEED\tTITLE\tLINK")
+	matched := 0
+	for _, doc := range docs {
+		if compiled.Match(alert.NewItem(doc)) {
+			matched++
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", doc.CreatedAt.Format(time.DateOnly), doc.Feed, doc.Title, doc.Link)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d of %d items matched\n", matched, len(docs))
+	return nil
+}
+
+func listCategories(ctx context.Context, store *taxonomy.Store) error {
+	categories, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+	counts, err := store.Counts(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPARENT\tITEMS\tALIASES\tDESCRIPTION")
+	known := map[string]bool{}
+	for _, c := range categories {
+		known[c.Name] = true
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", c.Name, c.Parent, counts[c.Name], strings.Join(c.Aliases, ", "), c.Description)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Categories created by the LLM outside the taxonomy are candidates for merging
+	var unmanaged []string
+	for name, count := range counts {
+		if !known[name] {
+			unmanaged = append(unmanaged, fmt.Sprintf("%s (%d)", name, count))
+		}
+	}
+	if len(unmanaged) > 0 {
+		fmt.Printf("\nCategories on feed items outside the taxonomy: %s\n", strings.Join(unmanaged, ", "))
+	}
+	return nil
+}
+
+func addCategory(ctx context.Context, store *taxonomy.Store, args []string) error {
+	fs := flag.NewFlagSet("categories add", flag.ContinueOnError)
+	description := fs.String("description", "", "description of the category shown to the LLM")
+	parent := fs.String("parent", "", "name of the parent category")
+	aliases := fs.String("alias", "", "comma-separated aliases")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	c := taxonomy.Category{Name: fs.Arg(0), Description: *description, Parent: *parent}
+	if *aliases != "" {
+		c.Aliases = strings.Split(*aliases, ",")
+	}
+	if err := store.Add(ctx, c); err != nil {
+		return err
+	}
+	slog.Info("Added category", "name", c.Name)
+	return nil
+}
+
+func mergeCategories(ctx context.Context, store *taxonomy.Store, args []string) error {
+	fs := flag.NewFlagSet("categories merge", flag.ContinueOnError)
+	into := fs.String("into", "", "name of the category to merge into")
+	if err := fs.Parse(args); err != nil || *into == "" || fs.NArg() == 0 {
+		return errUsage
+	}
+
+	result, err := store.Merge(ctx, fs.Args(), *into)
+	if err != nil {
+		return err
+	}
+	slog.Info("Merged categories", "into", *into, "from", fs.Args(), "mergedCategories", result.Categories,
+		"rewrittenItems", result.Items, "rewrittenStates", result.States, "movedMarkers", result.Markers)
+	return nil
+}
+
+func searchItems(ctx context.Context, db *mongo.Database, args []string) error {
+	fs := flag.NewFlagSet("items search", flag.ContinueOnError)
+	k := fs.Int("k", 10, "number of items to return")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	question := strings.Join(fs.Args(), " ")
+
+	embedder := llm.NewOpenAIEmbedder(cfg.Embedding.Host, cfg.Embedding.Model, cfg.Embedding.APIKey, cfg.Embedding.Timeout)
+	vectors, err := embedder.Embed(ctx, []string{question})
+	if err != nil {
+		return err
+	}
+
+	index, err := vector.NewStore(db.Collection(internal.MongoEmbeddingCollection)).LoadIndex(ctx, embedder.Model())
+	if err != nil {
+		return err
+	}
+	slog.Debug("Loaded vector index", "model", embedder.Model(), "vectors", index.Len())
+
+	results := index.Search(vectors[0], *k)
+	if len(results) == 0 {
+		fmt.Println("No items found")
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(results))
+	for _, r := range results {
+		id, err := primitive.ObjectIDFromHex(r.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	cursor, err := db.Collection(internal.MongoFeedItemCollection).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	byID := make(map[string]internal.FeedItemDocument, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID.Hex()] = doc
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SCORE\tTITLE\tCATEGORIES\tLINK")
+	for _, r := range results {
+		doc, ok := byID[r.ID]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(w, "%.3f\t%s\t%s\t%s\n", r.Score, doc.Title, strings.Join(doc.Categories, ", "), doc.Link)
+	}
+	return w.Flush()
+}
+
+func reprocessItems(ctx context.Context, db *mongo.Database, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	ids := make([]primitive.ObjectID, 0, len(args))
+	for _, arg := range args {
+		id, err := primitive.ObjectIDFromHex(arg)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errUsage, err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Activities load what they need from the stored document, so the article text is left out of
+	// the workflow input
+	cursor, err := db.Collection(internal.MongoFeedItemCollection).Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"text": 0}))
+	if err != nil {
+		return err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return err
+	}
+	if len(docs) < len(ids) {
+		return fmt.Errorf("found %d of %d items", len(docs), len(ids))
+	}
+
+	temporalClient, err := client.Dial(client.Options{
+		HostPort: cfg.Temporal.Host,
+		Logger:   slog.Default(),
+	})
+	if err != nil {
+		return err
+	}
+	defer temporalClient.Close()
+
+	for _, doc := range docs {
+		workflowOptions := client.StartWorkflowOptions{
+			ID:        fmt.Sprintf("reprocess-feed-item-%s", doc.ID.Hex()),
+			TaskQueue: internal.TaskQueueName,
+		}
+		we, err := temporalClient.ExecuteWorkflow(ctx, workflowOptions, internal.GetFunctionName(workflow.ReprocessFeedItem), doc)
+		if err != nil {
+			return err
+		}
+		slog.Info("Started reprocessing feed item", "id", doc.ID.Hex(), "title", doc.Title, "workflowID", we.GetID(), "runID", we.GetRunID())
+	}
+	return nil
+}
+
+func listReleases(ctx context.Context, store *release.Store, args []string) error {
+	fs := flag.NewFlagSet("releases list", flag.ContinueOnError)
+	since := fs.String("since", "0", "lowest version to list, inclusive")
+	breaking := fs.Bool("breaking", false, "only list releases with breaking changes")
+	prereleases := fs.Bool("prereleases", false, "also list prereleases")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	version, err := semver.Parse(*since)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	docs, err := store.Find(ctx, release.Query{
+		Product:            strings.Join(fs.Args(), " "),
+		Since:              version,
+		BreakingOnly:       *breaking,
+		IncludePrereleases: *prereleases,
+	})
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		fmt.Println("No releases found")
+		return nil
+	}
+
+	for _, doc := range docs {
+		r := doc.Release
+		date := "unknown date"
+		if r.ReleaseDate != nil {
+			date = r.ReleaseDate.Format(time.DateOnly)
+		}
+		fmt.Printf("%s %s (%s) %s\n", r.Product, r.Version, date, doc.Link)
+		for _, section := range []struct {
+			name  string
+			items []string
+		}{
+			{"Breaking changes", r.BreakingChanges},
+			{"Security fixes", r.SecurityFixes},
+			{"Deprecations", r.Deprecations},
+		} {
+			if len(section.items) == 0 {
+				continue
+			}
+			fmt.Printf("  %s:\n", section.name)
+			for _, item := range section.items {
+				fmt.Printf("    - %s\n", item)
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func listSubscribers(ctx context.Context, store *digest.Store) error {
+	subscribers, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EMAIL\tNAME\tFREQUENCY\tCATEGORIES\tLAST SENT\tDISABLED")
+	for _, s := range subscribers {
+		lastSent := "never"
+		if s.LastSentAt != nil {
+			lastSent = s.LastSentAt.Format(time.RFC3339)
+		}
+		categories := strings.Join(s.Categories, ", ")
+		if categories == "" {
+			categories = "all"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", s.Email, s.Name, s.Frequency, categories, lastSent, s.Disabled)
+	}
+	return w.Flush()
+}
+
+func addSubscriber(ctx context.Context, store *digest.Store, args []string) error {
+	fs := flag.NewFlagSet("subscribers add", flag.ContinueOnError)
+	name := fs.String("name", "", "name used to greet the subscriber")
+	frequency := fs.String("frequency", string(digest.Weekly), "digest frequency, daily or weekly")
+	categories := fs.String("categories", "", "comma-separated categories to include, all when empty")
+	disabled := fs.Bool("disabled", false, "stop sending digests to the subscriber")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	f, err := digest.ParseFrequency(*frequency)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	s := digest.Subscriber{Email: fs.Arg(0), Name: *name, Frequency: f, Disabled: *disabled}
+	if *categories != "" {
+		s.Categories = strings.Split(*categories, ",")
+	}
+	if err := store.Save(ctx, s); err != nil {
+		return err
+	}
+	slog.Info("Saved subscriber", "email", s.Email, "frequency", s.Frequency, "categories", s.Categories)
+	return nil
+}
+
+func listWebhooks(ctx context.Context, store *webhook.Store) error {
+	subscriptions, err := store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tFORMAT\tURL\tCATEGORIES\tFEEDS\tKEYWORDS\tSIGNED\tDISABLED")
+	for _, s := range subscriptions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\t%t\n", s.ID.Hex(), s.Name, s.Format, s.URL,
+			strings.Join(s.Filter.Categories, ", "), strings.Join(s.Filter.Feeds, ", "), strings.Join(s.Filter.Keywords, ", "),
+			s.Secret != "", s.Disabled)
+	}
+	return w.Flush()
+}
+
+func addWebhook(ctx context.Context, store *webhook.Store, args []string) error {
+	fs := flag.NewFlagSet("webhooks add", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the subscription")
+	format := fs.String("format", string(webhook.FormatJSON), "message format: json, slack or discord")
+	secret := fs.String("secret", "", "secret signing the payloads, generated for json webhooks when empty")
+	categories := fs.String("categories", "", "comma-separated categories to deliver, all when empty")
+	feeds := fs.String("feeds", "", "comma-separated feed titles to deliver, all when empty")
+	keywords := fs.String("keywords", "", "comma-separated keywords to deliver, all when empty")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	f, err := webhook.ParseFormat(*format)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	sub := webhook.Subscription{Name: *name, URL: fs.Arg(0), Format: f, Secret: *secret}
+	if sub.Secret == "" && f == webhook.FormatJSON {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		sub.Secret = hex.EncodeToString(b)
+	}
+	for _, field := range []struct {
+		value  string
+		target *[]string
+	}{
+		{*categories, &sub.Filter.Categories},
+		{*feeds, &sub.Filter.Feeds},
+		{*keywords, &sub.Filter.Keywords},
+	} {
+		if field.value != "" {
+			*field.target = strings.Split(field.value, ",")
+		}
+	}
+
+	sub, err = store.Add(ctx, sub)
+	if err != nil {
+		return err
+	}
+	slog.Info("Added webhook subscription", "id", sub.ID.Hex(), "url", sub.URL, "format", sub.Format)
+	if sub.Secret != "" && *secret == "" {
+		fmt.Printf("Signing secret: %s\n", sub.Secret)
+	}
+	return nil
+}
+
+func listWebhookDeliveries(ctx context.Context, store *webhook.Store, args []string) error {
+	fs := flag.NewFlagSet("webhooks deliveries", flag.ContinueOnError)
+	n := fs.Int("n", 20, "number of delivery attempts to list")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	id, err := primitive.ObjectIDFromHex(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	deliveries, err := store.Deliveries(ctx, id, *n)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		fmt.Println("No deliveries found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tATTEMPT\tSTATUS\tDURATION\tLINK\tERROR")
+	for _, d := range deliveries {
+		status := "ok"
+		if !d.Success {
+			status = "failed"
+		}
+		if d.StatusCode != 0 {
+			status += fmt.Sprintf(" (%d)", d.StatusCode)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", d.CreatedAt.Format(time.RFC3339), d.Attempt, status, d.Duration.Round(time.Millisecond), d.Link, d.Error)
+	}
+	return w.Flush()
+}
+
+// newAccountStore creates the store of users, API keys and subscriptions.
+func newAccountStore(db *mongo.Database) *account.Store {
+	return account.NewStore(db.Collection(internal.MongoUserCollection), db.Collection(internal.MongoAPIKeyCollection), db.Collection(internal.MongoSubscriptionCollection))
+}
+
+func listUsers(ctx context.Context, store *account.Store) error {
+	users, err := store.Users(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tCREATED\tDISABLED")
+	for _, u := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", u.ID, u.Name, u.Email, u.CreatedAt.Format(time.DateOnly), u.Disabled)
+	}
+	return w.Flush()
+}
+
+func addUser(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("users add", flag.ContinueOnError)
+	name := fs.String("name", "", "display name of the user")
+	email := fs.String("email", "", "email address of the user")
+	disabled := fs.Bool("disabled", false, "reject the API keys of the user")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+
+	u := account.User{ID: fs.Arg(0), Name: *name, Email: *email, Disabled: *disabled}
+	if err := store.SaveUser(ctx, u); err != nil {
+		return err
+	}
+	slog.Info("Saved user", "id", u.ID, "disabled", u.Disabled)
+	return nil
+}
+
+func listKeys(ctx context.Context, store *account.Store, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	keys, err := store.Keys(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tKEY\tCREATED\tLAST USED")
+	for _, k := range keys {
+		lastUsed := "never"
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID.Hex(), k.Name, k.Hint, k.CreatedAt.Format(time.DateOnly), lastUsed)
+	}
+	return w.Flush()
+}
+
+func createKey(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("keys create", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the key, e.g. the client using it")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	if err := store.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	key, k, err := store.CreateKey(ctx, fs.Arg(0), *name)
+	if err != nil {
+		return err
+	}
+	slog.Info("Created API key", "id", k.ID.Hex(), "user", k.User, "name", k.Name)
+	fmt.Printf("API key: %s\n", key)
+	return nil
+}
+
+func listFeeds(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("feeds list", flag.ContinueOnError)
+	user := fs.String("user", "", "user whose subscriptions to list; all polled feeds when empty")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if *user == "" {
+		sources, err := store.Sources(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "TITLE\tTYPE\tURL\tSUBSCRIBERS")
+		for _, s := range sources {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", s.Feed.Title, s.Feed.Type, s.Feed.XMLURL, s.Subscribers)
+		}
+		return w.Flush()
+	}
+
+	subscriptions, err := store.Subscriptions(ctx, *user)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "ID\tTITLE\tTYPE\tURL\tFILTERS")
+	for _, s := range subscriptions {
+		filters := make([]string, 0, len(s.Filters))
+		for _, r := range s.Filters {
+			filters = append(filters, r.String())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID.Hex(), s.Title, s.Type, s.URL, strings.Join(filters, ", "))
+	}
+	return w.Flush()
+}
+
+func subscribeFeed(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("feeds subscribe", flag.ContinueOnError)
+	user := fs.String("user", "", "user to subscribe")
+	title := fs.String("title", "", "title of the feed")
+	feedType := fs.String("type", "", "type of the feed: empty or release")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *user == "" || *title == "" {
+		return errUsage
+	}
+	if err := store.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	sub, err := store.Subscribe(ctx, account.Subscription{User: *user, Title: *title, URL: fs.Arg(0), Type: *feedType})
+	if err != nil {
+		return err
+	}
+	slog.Info("Subscribed to feed", "id", sub.ID.Hex(), "user", sub.User, "url", sub.URL)
+	return nil
+}
+
+func unsubscribeFeed(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("feeds unsubscribe", flag.ContinueOnError)
+	user := fs.String("user", "", "user to unsubscribe")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *user == "" {
+		return errUsage
+	}
+	id, err := primitive.ObjectIDFromHex(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	if err := store.Unsubscribe(ctx, *user, id); err != nil {
+		return err
+	}
+	slog.Info("Removed subscription", "id", fs.Arg(0), "user", *user)
+	return nil
+}
+
+// importFeeds subscribes a user to the feeds of a feeds.json list, the format of the feed list the
+// ingester used to read, skipping the feeds the user already subscribes to.
+func importFeeds(ctx context.Context, store *account.Store, args []string) error {
+	fs := flag.NewFlagSet("feeds import", flag.ContinueOnError)
+	user := fs.String("user", "", "user to subscribe")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *user == "" {
+		return errUsage
+	}
+	b, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var feedList internal.FeedList
+	if err := json.Unmarshal(b, &feedList); err != nil {
+		return err
+	}
+	if err := store.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	imported, skipped := 0, 0
+	for _, f := range feedList {
+		_, err := store.Subscribe(ctx, account.Subscription{User: *user, Title: f.Title, URL: f.XMLURL, Type: f.Type, Filters: f.Filters})
+		if errors.Is(err, account.ErrDuplicateSubscription) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("feed %s: %w", f.Title, err)
+		}
+		imported++
+	}
+	slog.Info("Imported feeds", "user", *user, "imported", imported, "skipped", skipped)
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/Netflix/go-env"
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/api"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+)
+
+const serviceName = "feeds-api"
+
+var cfg Configuration
+
+type Configuration struct {
+	MongoDB struct {
+		URI string `env:"MONGODB_URI,default=mongodb://localhost:27017"`
+	}
+	Otel struct {
+		Host string `env:"OTEL_HOST,default=localhost:4318"`
+	}
+	Logging struct {
+		Level string `env:"LOG_LEVEL,default=info"`
+	}
+	HTTP struct {
+		Addr            string        `env:"API_ADDR,default=:8080"`
+		ShutdownTimeout time.Duration `env:"API_SHUTDOWN_TIMEOUT,default=10s"`
+		// BaseURL is the public URL of the API; empty derives it from the request
+		BaseURL string `env:"API_BASE_URL"`
+		// Anonymous allows requests without an API key, which see all items
+		Anonymous bool `env:"API_ANONYMOUS,default=false"`
+	}
+	Syndication struct {
+		FeedSize int `env:"SYNDICATION_FEED_SIZE,default=50"`
+	}
+	Embedding struct {
+		Enabled bool          `env:"EMBEDDING_ENABLED,default=false"`
+		Host    string        `env:"EMBEDDING_HOST,default=http://localhost:11434/v1/"`
+		Model   string        `env:"EMBEDDING_MODEL,default=nomic-embed-text"`
+		APIKey  string        `env:"EMBEDDING_API_KEY"`
+		Timeout time.Duration `env:"EMBEDDING_TIMEOUT,default=1m"`
+		// RefreshInterval is how often embeddings saved since the last refresh are added to the index
+		RefreshInterval time.Duration `env:"EMBEDDING_REFRESH_INTERVAL,default=1m"`
+	}
+}
+
+func init() {
+	_, err := env.UnmarshalFromEnviron(&cfg)
+	if err != nil {
+		slog.Error("Failed to unmarshal environment", "err", err)
+		os.Exit(1)
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Set up OTel SDK
+	otelShutdown, err := internal.SetupOTelSDK(ctx, serviceName, cfg.Otel.Host)
+	if err != nil {
+		slog.Error("Failed to setup OTel SDK", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := otelShutdown(context.Background()); err != nil {
+			slog.Error("Failed to shutdown OTel SDK", "err", err)
+		}
+	}()
+
+	logLevel := internal.ParseLogLevel(cfg.Logging.Level)
+	slog.SetLogLoggerLevel(logLevel)
+	logger := slog.New(&internal.MultiHandler{
+		Handlers: []slog.Handler{
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+				Level: logLevel,
+			}),
+			otelslog.NewHandler(serviceName),
+		},
+	})
+	slog.SetDefault(logger)
+
+	// Initialize MongoDB client
+	mongoCtx, mongoCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer mongoCancel()
+
+	mongoClient, err := mongo.Connect(mongoCtx, options.Client().ApplyURI(cfg.MongoDB.URI))
+	if err != nil {
+		slog.Error("Failed to connect to MongoDB", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			slog.Error("Failed to disconnect from MongoDB", "err", err)
+		}
+	}()
+
+	db := mongoClient.Database(internal.MongoDBName)
+	feedItemCollection := db.Collection(internal.MongoFeedItemCollection)
+
+	items := feeditem.NewStore(feedItemCollection)
+	if err := items.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create feed item query indexes", "err", err)
+		os.Exit(1)
+	}
+	states := itemstate.NewStore(db.Collection(internal.MongoItemStateCollection), db.Collection(internal.MongoReadMarkerCollection), feedItemCollection)
+	if err := states.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create item state indexes", "err", err)
+		os.Exit(1)
+	}
+	accounts := account.NewStore(db.Collection(internal.MongoUserCollection), db.Collection(internal.MongoAPIKeyCollection), db.Collection(internal.MongoSubscriptionCollection))
+	if err := accounts.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create account indexes", "err", err)
+		os.Exit(1)
+	}
+	taxonomyStore := taxonomy.NewStore(db.Collection(internal.MongoCategoryCollection), feedItemCollection,
+		db.Collection(internal.MongoItemStateCollection), db.Collection(internal.MongoReadMarkerCollection))
+
+	// Load the vector index for semantic search once, then keep adding the embeddings saved since
+	var embedder llm.Embedder
+	var vectors *vector.LiveIndex
+	if cfg.Embedding.Enabled {
+		embedder = llm.NewOpenAIEmbedder(cfg.Embedding.Host, cfg.Embedding.Model, cfg.Embedding.APIKey, cfg.Embedding.Timeout)
+		vectors = vector.NewLiveIndex(vector.NewStore(db.Collection(internal.MongoEmbeddingCollection)), cfg.Embedding.Model)
+		if err := vectors.Refresh(ctx); err != nil {
+			slog.Error("Failed to load vector index", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("Loaded vector index", "model", cfg.Embedding.Model, "vectors", vectors.Len())
+		go refreshVectors(ctx, vectors, cfg.Embedding.RefreshInterval)
+	}
+
+	handler := api.New(api.Config{
+		Items:     items,
+		Taxonomy:  taxonomyStore,
+		States:    states,
+		Accounts:  accounts,
+		Anonymous: cfg.HTTP.Anonymous,
+		BaseURL:   cfg.HTTP.BaseURL,
+		FeedSize:  cfg.Syndication.FeedSize,
+		Embedder:  embedder,
+		Vectors:   vectors,
+	}).Handler()
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Failed to shut down HTTP server", "err", err)
+		}
+	}()
+
+	slog.Info("Starting API server", "addr", cfg.HTTP.Addr, "anonymous", cfg.HTTP.Anonymous)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("API server failed", "err", err)
+		os.Exit(1)
+	}
+	slog.Info("API server stopped")
+}
+
+// refreshVectors adds the embeddings saved since the last refresh to the vector index every interval
+// until ctx is done.
+func refreshVectors(ctx context.Context, vectors *vector.LiveIndex, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := vectors.Refresh(ctx); err != nil {
+				slog.Error("Failed to refresh vector index", "err", err)
+				continue
+			}
+			slog.Debug("Refreshed vector index", "vectors", vectors.Len())
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -13,9 +12,14 @@ import (
 
 	"github.com/Netflix/go-env"
 	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/feedfilter"
+	"github.com/demeyerthom/feeds-aggregator/internal/netguard"
 	"github.com/demeyerthom/feeds-aggregator/internal/workflow"
 	"github.com/mmcdole/gofeed"
 	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -33,10 +37,14 @@ var (
 	rdb            *redis.Client
 	temporalClient client.Client
 	linksCounter   metric.Int64Counter
+	skippedCounter metric.Int64Counter
 	tracer         trace.Tracer
 )
 
 type Configuration struct {
+	MongoDB struct {
+		URI string `env:"MONGODB_URI,default=mongodb://localhost:27017"`
+	}
 	Redis struct {
 		Host     string `env:"REDIS_HOST,default=localhost:6379"`
 		Password string `env:"REDIS_PASSWORD"`
@@ -51,8 +59,9 @@ type Configuration struct {
 	Logging struct {
 		Level string `env:"LOG_LEVEL,default=info"`
 	}
-	SourceFile     string        `env:"SOURCE_FILE,default=/feeds.json"`
 	TickerInterval time.Duration `env:"TICKER_INTERVAL,default=1m"`
+	// FetchTimeout bounds fetching a feed
+	FetchTimeout time.Duration `env:"FEED_FETCH_TIMEOUT,default=30s"`
 }
 
 func init() {
@@ -103,6 +112,15 @@ func main() {
 		slog.Error("Failed to create metric counter", "err", err)
 		os.Exit(1)
 	}
+	skippedCounter, err = meter.Int64Counter(
+		"feeds.skipped_items",
+		metric.WithDescription("Number of new feed items skipped by the filter rules of their feed, by kind of rule"),
+		metric.WithUnit("{item}"),
+	)
+	if err != nil {
+		slog.Error("Failed to create metric counter", "err", err)
+		os.Exit(1)
+	}
 
 	// Initialize tracer
 	tracer = otel.Tracer(serviceName)
@@ -151,15 +169,25 @@ func main() {
 	}
 	defer temporalClient.Close()
 
-	// Load feed list from source file
-	feedList, err := loadFeedList(cfg.SourceFile)
+	// Initialize MongoDB client
+	mongoCtx, mongoCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer mongoCancel()
+
+	mongoClient, err := mongo.Connect(mongoCtx, options.Client().ApplyURI(cfg.MongoDB.URI))
 	if err != nil {
-		slog.Error("Failed to load feed list", "err", err)
+		slog.Error("Failed to connect to MongoDB", "err", err)
 		os.Exit(1)
 	}
-	slog.Info("Loaded feed list", "count", len(feedList))
+	defer func() {
+		if err := mongoClient.Disconnect(context.Background()); err != nil {
+			slog.Error("Failed to disconnect from MongoDB", "err", err)
+		}
+	}()
 
-	processAllFeeds(ctx, feedList)
+	db := mongoClient.Database(internal.MongoDBName)
+	accounts := account.NewStore(db.Collection(internal.MongoUserCollection), db.Collection(internal.MongoAPIKeyCollection), db.Collection(internal.MongoSubscriptionCollection))
+
+	processAllFeeds(ctx, accounts)
 
 	// Set up ticker to process feeds every 5 minutes
 	ticker := time.NewTicker(cfg.TickerInterval)
@@ -171,46 +199,50 @@ func main() {
 			slog.Info("Shutting down feed worker")
 			return
 		case <-ticker.C:
-			processAllFeeds(ctx, feedList)
+			processAllFeeds(ctx, accounts)
 		}
 	}
 }
 
-func processAllFeeds(ctx context.Context, feedList internal.FeedList) {
-	slog.Info("Starting feed processing cycle", "count", len(feedList))
-	for _, feed := range feedList {
-		if err := processFeedActivity(ctx, feed); err != nil {
-			slog.Error("Failed to process feed", "feed", feed.Title, "err", err)
+// processAllFeeds polls the union of the subscriptions of all users, reloaded every cycle so
+// subscriptions take effect without a restart. A feed is polled once however many users subscribe to it.
+func processAllFeeds(ctx context.Context, accounts *account.Store) {
+	sources, err := accounts.Sources(ctx)
+	if err != nil {
+		slog.Error("Failed to load subscribed feeds", "err", err)
+		return
+	}
+	slog.Info("Starting feed processing cycle", "count", len(sources))
+	for _, source := range sources {
+		if err := processFeedActivity(ctx, source); err != nil {
+			slog.Error("Failed to process feed", "feed", source.Feed.Title, "err", err)
 		}
 	}
 	slog.Info("Completed feed processing cycle")
 }
 
-func loadFeedList(path string) (internal.FeedList, error) {
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var feedList internal.FeedList
-	if err := json.Unmarshal(b, &feedList); err != nil {
-		return nil, err
-	}
-
-	return feedList, nil
-}
+func processFeedActivity(ctx context.Context, source account.Source) error {
+	f := source.Feed
 
-func processFeedActivity(ctx context.Context, f internal.Feed) error {
 	// Start a span for the entire activity
 	ctx, span := tracer.Start(ctx, "processFeed",
 		trace.WithAttributes(
 			attribute.String("feed.title", f.Title),
 			attribute.String("feed.url", f.XMLURL),
+			attribute.Int("feed.subscribers", source.Subscribers),
 		),
 	)
 	defer span.End()
 
+	// The rules were validated when subscribing
+	filter, err := feedfilter.CompileAny(source.Rules)
+	if err != nil {
+		return err
+	}
+
 	fp := gofeed.NewParser()
+	// Feeds are user-supplied, so they may not point at internal services
+	fp.Client = netguard.NewClient(cfg.FetchTimeout)
 
 	// Trace the feed parsing (HTTP call)
 	parseCtx, parseSpan := tracer.Start(ctx, "parseFeedURL",
@@ -229,13 +261,13 @@ func processFeedActivity(ctx context.Context, f internal.Feed) error {
 
 	// Process each item
 	for _, item := range feed.Items {
-		processItem(parseCtx, feed.Title, item)
+		processItem(parseCtx, f, filter, item)
 	}
 
 	return nil
 }
 
-func processItem(ctx context.Context, feedTitle string, item *gofeed.Item) {
+func processItem(ctx context.Context, f internal.Feed, filter feedfilter.Any, item *gofeed.Item) {
 	ctx, span := tracer.Start(ctx, "processItem",
 		trace.WithAttributes(
 			attribute.String("item.link", item.Link),
@@ -249,13 +281,29 @@ func processItem(ctx context.Context, feedTitle string, item *gofeed.Item) {
 		// New item - store in Redis
 		rdb.Set(ctx, item.Link, "1", 24*7*time.Hour)
 		linksCounter.Add(ctx, 1, metric.WithAttributeSet(
-			attribute.NewSet(attribute.String("feed.title", feedTitle))))
+			attribute.NewSet(attribute.String("feed.title", f.Title))))
 		span.SetAttributes(attribute.Bool("item.new", true))
 
+		// Skip items the rules of every subscription filter out; they stay in Redis so they are only counted once
+		if skip, rule := filter.Skip(item); skip {
+			// Rules are user-supplied, so metrics are labelled by the kind of rule only
+			skippedCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+				attribute.String("feed.title", f.Title),
+				attribute.String("rule.kind", rule.Kind()),
+			)))
+			span.SetAttributes(attribute.String("item.skipped", rule.String()))
+			slog.Info("Skipped feed item", "feed", f.Title, "rule", rule.String(), "link", item.Link)
+			return
+		}
+
 		// Start Temporal workflow for this feed item
 		feedItem := internal.FeedItem{
-			Link:  item.Link,
-			Title: item.Title,
+			Link:      item.Link,
+			Title:     item.Title,
+			Feed:      f.Title,
+			FeedURL:   f.XMLURL,
+			FeedType:  f.Type,
+			Published: item.PublishedParsed,
 		}
 		workflowOptions := client.StartWorkflowOptions{
 			ID:        fmt.Sprintf("ingest-feed-item-%s", item.Link),
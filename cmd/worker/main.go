@@ -2,19 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/Netflix/go-env"
 	"github.com/demeyerthom/feeds-aggregator/internal"
 	internalactivity "github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"github.com/demeyerthom/feeds-aggregator/internal/alert"
+	"github.com/demeyerthom/feeds-aggregator/internal/cache"
+	"github.com/demeyerthom/feeds-aggregator/internal/cluster"
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/language"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/demeyerthom/feeds-aggregator/internal/netguard"
+	"github.com/demeyerthom/feeds-aggregator/internal/prompt"
+	"github.com/demeyerthom/feeds-aggregator/internal/release"
+	"github.com/demeyerthom/feeds-aggregator/internal/relevance"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+	"github.com/demeyerthom/feeds-aggregator/internal/tokens"
+	"github.com/demeyerthom/feeds-aggregator/internal/usage"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
 	internalworkflow "github.com/demeyerthom/feeds-aggregator/internal/workflow"
-	"github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/option"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -24,6 +41,7 @@ import (
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/contrib/opentracing"
 	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 )
@@ -35,7 +53,6 @@ var (
 
 	rdb         *redis.Client
 	mongoClient *mongo.Client
-	zenClient   openai.Client
 )
 
 type Configuration struct {
@@ -58,20 +75,115 @@ type Configuration struct {
 	}
 	Storage struct {
 		HTMLDir string `env:"HTML_STORAGE_DIR,default=./data"`
+		// FetchTimeout bounds fetching the page of an item
+		FetchTimeout time.Duration `env:"HTML_FETCH_TIMEOUT,default=30s"`
 	}
 	TextExtractor struct {
 		Limit int `env:"TEXT_LIMIT,default=400000"`
 	}
+	Search struct {
+		// TextLimit is the maximum number of bytes of article text stored for full-text search
+		TextLimit int `env:"SEARCH_TEXT_LIMIT,default=100000"`
+	}
+	LLM struct {
+		ProvidersFile           string              `env:"LLM_PROVIDERS_FILE"`
+		RepairAttempts          int                 `env:"LLM_REPAIR_ATTEMPTS,default=2"`
+		ContextSize             int                 `env:"LLM_CONTEXT_SIZE,default=8192"`
+		ContextSizes            tokens.ContextSizes `env:"LLM_CONTEXT_SIZES"`
+		BreakerThreshold        int                 `env:"LLM_BREAKER_THRESHOLD,default=5"`
+		BreakerCooldown         time.Duration       `env:"LLM_BREAKER_COOLDOWN,default=1m"`
+		MaxConcurrentActivities int                 `env:"LLM_MAX_CONCURRENT_ACTIVITIES,default=2"`
+		ActivitiesPerSecond     float64             `env:"LLM_ACTIVITIES_PER_SECOND,default=0"`
+		Pricing                 usage.Pricing       `env:"LLM_PRICING"`
+		DailyBudget             float64             `env:"LLM_DAILY_BUDGET,default=0"`
+	}
+	Cache struct {
+		Enabled bool          `env:"LLM_CACHE_ENABLED,default=true"`
+		TTL     time.Duration `env:"LLM_CACHE_TTL,default=720h"`
+		Bypass  bool          `env:"LLM_CACHE_BYPASS,default=false"`
+	}
+	Prompts struct {
+		Dir string `env:"PROMPT_DIR"`
+	}
+	Categories struct {
+		Strict bool `env:"CATEGORIES_STRICT,default=false"`
+	}
+	Summary struct {
+		// Language is the ISO 639-1 code of the summary language; empty keeps the article language
+		Language     string `env:"SUMMARY_LANGUAGE,default=en"`
+		KeepOriginal bool   `env:"SUMMARY_KEEP_ORIGINAL,default=false"`
+	}
+	Embedding struct {
+		Enabled   bool          `env:"EMBEDDING_ENABLED,default=false"`
+		Host      string        `env:"EMBEDDING_HOST,default=http://localhost:11434/v1/"`
+		Model     string        `env:"EMBEDDING_MODEL,default=nomic-embed-text"`
+		APIKey    string        `env:"EMBEDDING_API_KEY"`
+		MaxTokens int           `env:"EMBEDDING_MAX_TOKENS,default=2048"`
+		Timeout   time.Duration `env:"EMBEDDING_TIMEOUT,default=1m"`
+	}
+	Digest struct {
+		Enabled bool `env:"DIGEST_ENABLED,default=false"`
+		// DailySchedule and WeeklySchedule are cron expressions; an empty expression disables that digest
+		DailySchedule       string `env:"DIGEST_DAILY_SCHEDULE,default=0 7 * * *"`
+		WeeklySchedule      string `env:"DIGEST_WEEKLY_SCHEDULE,default=0 7 * * 1"`
+		TimeZone            string `env:"DIGEST_TIME_ZONE,default=UTC"`
+		Overview            bool   `env:"DIGEST_OVERVIEW,default=true"`
+		MaxItems            int    `env:"DIGEST_MAX_ITEMS,default=500"`
+		MaxItemsPerCategory int    `env:"DIGEST_MAX_ITEMS_PER_CATEGORY,default=10"`
+	}
+	Relevance struct {
+		// ProfilesFile is a JSON file with the interest profiles of the teams; items are not scored without it
+		ProfilesFile string  `env:"RELEVANCE_PROFILES_FILE"`
+		LLM          bool    `env:"RELEVANCE_LLM,default=false"`
+		LLMWeight    float64 `env:"RELEVANCE_LLM_WEIGHT,default=0.5"`
+	}
+	Webhook struct {
+		Timeout time.Duration `env:"WEBHOOK_TIMEOUT,default=10s"`
+	}
+	SMTP struct {
+		Host     string `env:"SMTP_HOST,default=localhost"`
+		Port     int    `env:"SMTP_PORT,default=1025"`
+		Username string `env:"SMTP_USERNAME"`
+		Password string `env:"SMTP_PASSWORD"`
+		From     string `env:"SMTP_FROM,default=Feeds <feeds@localhost>"`
+	}
+	Cluster struct {
+		Window        time.Duration `env:"CLUSTER_WINDOW,default=72h"`
+		MaxDistance   int           `env:"CLUSTER_MAX_DISTANCE,default=3"`
+		MinSimilarity float64       `env:"CLUSTER_MIN_SIMILARITY,default=0.92"`
+	}
 	Ollama struct {
-		Enabled bool   `env:"OLLAMA_ENABLED,default=false"`
-		Host    string `env:"OLLAMA_HOST,default=http://localhost:11434"`
-		Model   string `env:"OLLAMA_MODEL,default=gpt-oss:20b"`
+		Enabled     bool          `env:"OLLAMA_ENABLED,default=false"`
+		Host        string        `env:"OLLAMA_HOST,default=http://localhost:11434"`
+		Model       string        `env:"OLLAMA_MODEL,default=gpt-oss:20b"`
+		Timeout     time.Duration `env:"OLLAMA_TIMEOUT,default=3m"`
+		Concurrency int           `env:"OLLAMA_CONCURRENCY,default=0"`
 	}
 	OpenCode struct {
-		Enabled bool   `env:"OPENCODE_ENABLED,default=false"`
-		Host    string `env:"OPENCODE_HOST,default=https://opencode.ai/zen/v1"`
-		Model   string `env:"OPENCODE_MODEL,default=big-pickle"`
-		APIKey  string `env:"OPENCODE_API_KEY"`
+		Enabled     bool          `env:"OPENCODE_ENABLED,default=false"`
+		Host        string        `env:"OPENCODE_HOST,default=https://opencode.ai/zen/v1"`
+		Model       string        `env:"OPENCODE_MODEL,default=big-pickle"`
+		APIKey      string        `env:"OPENCODE_API_KEY"`
+		Timeout     time.Duration `env:"OPENCODE_TIMEOUT,default=2m"`
+		Concurrency int           `env:"OPENCODE_CONCURRENCY,default=0"`
+	}
+	Anthropic struct {
+		Enabled     bool          `env:"ANTHROPIC_ENABLED,default=false"`
+		Host        string        `env:"ANTHROPIC_HOST,default=https://api.anthropic.com"`
+		Model       string        `env:"ANTHROPIC_MODEL,default=claude-haiku-4-5"`
+		APIKey      string        `env:"ANTHROPIC_API_KEY"`
+		MaxTokens   int           `env:"ANTHROPIC_MAX_TOKENS,default=4096"`
+		Timeout     time.Duration `env:"ANTHROPIC_TIMEOUT,default=2m"`
+		Concurrency int           `env:"ANTHROPIC_CONCURRENCY,default=0"`
+	}
+	Gemini struct {
+		Enabled     bool          `env:"GEMINI_ENABLED,default=false"`
+		Host        string        `env:"GEMINI_HOST,default=https://generativelanguage.googleapis.com"`
+		Model       string        `env:"GEMINI_MODEL,default=gemini-2.5-flash"`
+		APIKey      string        `env:"GEMINI_API_KEY"`
+		MaxTokens   int           `env:"GEMINI_MAX_TOKENS,default=4096"`
+		Timeout     time.Duration `env:"GEMINI_TIMEOUT,default=2m"`
+		Concurrency int           `env:"GEMINI_CONCURRENCY,default=0"`
 	}
 }
 
@@ -179,41 +291,224 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Validate provider configuration: exactly one must be enabled
-	ollamaEnabled := cfg.Ollama.Enabled
-	openCodeEnabled := cfg.OpenCode.Enabled
+	// Create indexes for looking up items by entity and keyword
+	_, err = feedItemCollection.Indexes().CreateMany(mongoCtx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "entities.type", Value: 1}, {Key: "entities.key", Value: 1}}},
+		{Keys: bson.D{{Key: "keywords", Value: 1}}},
+	})
+	if err != nil {
+		slog.Error("Failed to create entity and keyword indexes", "err", err)
+		os.Exit(1)
+	}
+
+	// Create the full-text search index, which ProcessContent keeps up to date, and the query indexes
+	if err := feeditem.NewStore(feedItemCollection).EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create feed item search indexes", "err", err)
+		os.Exit(1)
+	}
+
+	// Create the index for querying releases by product and version
+	if err := release.NewStore(feedItemCollection).EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create release indexes", "err", err)
+		os.Exit(1)
+	}
+
+	// Create the category taxonomy, seeded with the default categories when empty
+	taxonomyStore := taxonomy.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoCategoryCollection), feedItemCollection,
+		mongoClient.Database(internal.MongoDBName).Collection(internal.MongoItemStateCollection),
+		mongoClient.Database(internal.MongoDBName).Collection(internal.MongoReadMarkerCollection))
+	if err := taxonomyStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create category indexes", "err", err)
+		os.Exit(1)
+	}
+	seeded, err := taxonomyStore.Seed(mongoCtx, taxonomy.Defaults)
+	if err != nil {
+		slog.Error("Failed to seed category taxonomy", "err", err)
+		os.Exit(1)
+	}
+	if seeded {
+		slog.Info("Seeded category taxonomy with default categories", "count", len(taxonomy.Defaults))
+	}
+
+	// Create the item state store, whose copies of the categories are refreshed when items are processed again
+	itemStateStore := itemstate.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoItemStateCollection),
+		mongoClient.Database(internal.MongoDBName).Collection(internal.MongoReadMarkerCollection), feedItemCollection)
+
+	// Create the embedding store and, when enabled, the embeddings client
+	embeddingStore := vector.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoEmbeddingCollection))
+	if err := embeddingStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create embedding indexes", "err", err)
+		os.Exit(1)
+	}
+	var embedder llm.Embedder
+	if cfg.Embedding.Enabled {
+		embedder = llm.NewOpenAIEmbedder(cfg.Embedding.Host, cfg.Embedding.Model, cfg.Embedding.APIKey, cfg.Embedding.Timeout)
+		slog.Info("Initialized embeddings client", "host", cfg.Embedding.Host, "model", cfg.Embedding.Model)
+	}
+
+	// Create the story cluster store
+	clusterStore := cluster.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoClusterCollection), feedItemCollection)
+	if err := clusterStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create cluster indexes", "err", err)
+		os.Exit(1)
+	}
+	var clusterEmbeddings *vector.Store
+	if cfg.Embedding.Enabled {
+		clusterEmbeddings = embeddingStore
+	}
+
+	// Create the digest store for the subscribers and the items of a digest period
+	digestStore := digest.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoDigestSubscriberCollection), feedItemCollection)
+	if err := digestStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create digest indexes", "err", err)
+		os.Exit(1)
+	}
 
-	if ollamaEnabled && openCodeEnabled {
-		slog.Error("Both Ollama and OpenCode providers are enabled. Exactly one provider must be enabled.")
+	// Create the webhook store for the subscriptions and their delivery log
+	webhookStore := webhook.NewStore(
+		mongoClient.Database(internal.MongoDBName).Collection(internal.MongoWebhookCollection),
+		mongoClient.Database(internal.MongoDBName).Collection(internal.MongoWebhookDeliveryCollection),
+	)
+	if err := webhookStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create webhook indexes", "err", err)
 		os.Exit(1)
 	}
-	if !ollamaEnabled && !openCodeEnabled {
-		slog.Error("No provider is enabled. Enable either Ollama (OLLAMA_ENABLED=true) or OpenCode (OPENCODE_ENABLED=true).")
+
+	// Create the alert store for the alert rules
+	alertStore := alert.NewStore(mongoClient.Database(internal.MongoDBName).Collection(internal.MongoAlertRuleCollection))
+	if err := alertStore.EnsureIndexes(mongoCtx); err != nil {
+		slog.Error("Failed to create alert indexes", "err", err)
 		os.Exit(1)
 	}
 
-	// Initialize the configured provider and determine the model to use
-	var model string
-	if openCodeEnabled {
-		if cfg.OpenCode.APIKey == "" {
-			slog.Error("OPENCODE_API_KEY is required when OpenCode provider is enabled")
+	// Build the ordered provider chain, either from the providers file or from the
+	// provider config blocks in the order Ollama, OpenCode, Anthropic, Gemini
+	var providerConfigs []llm.ProviderConfig
+	if cfg.LLM.ProvidersFile != "" {
+		providerConfigs, err = llm.LoadProviders(cfg.LLM.ProvidersFile)
+		if err != nil {
+			slog.Error("Failed to load LLM providers file", "err", err, "file", cfg.LLM.ProvidersFile)
 			os.Exit(1)
 		}
-		zenClient = openai.NewClient(
-			option.WithAPIKey(cfg.OpenCode.APIKey),
-			option.WithBaseURL(cfg.OpenCode.Host),
-		)
-		model = cfg.OpenCode.Model
-		slog.Info("Initialized OpenCode client", "model", model, "host", cfg.OpenCode.Host)
 	} else {
-		// Ollama OpenAI compatibility: baseURL = OLLAMA_HOST + "/v1/", apiKey = "ollama" (required but ignored)
-		ollamaBaseURL := cfg.Ollama.Host + "/v1/"
-		zenClient = openai.NewClient(
-			option.WithAPIKey("ollama"),
-			option.WithBaseURL(ollamaBaseURL),
-		)
-		model = cfg.Ollama.Model
-		slog.Info("Initialized Ollama client", "model", model, "host", cfg.Ollama.Host, "baseURL", ollamaBaseURL)
+		if cfg.Ollama.Enabled {
+			// Ollama OpenAI compatibility: baseURL = OLLAMA_HOST + "/v1/", API key is required but ignored
+			providerConfigs = append(providerConfigs, llm.ProviderConfig{
+				Name:        "ollama",
+				Host:        cfg.Ollama.Host + "/v1/",
+				Model:       cfg.Ollama.Model,
+				Timeout:     llm.Duration(cfg.Ollama.Timeout),
+				Concurrency: cfg.Ollama.Concurrency,
+			})
+		}
+		if cfg.OpenCode.Enabled {
+			if cfg.OpenCode.APIKey == "" {
+				slog.Error("OPENCODE_API_KEY is required when OpenCode provider is enabled")
+				os.Exit(1)
+			}
+			providerConfigs = append(providerConfigs, llm.ProviderConfig{
+				Name:        "opencode",
+				Host:        cfg.OpenCode.Host,
+				Model:       cfg.OpenCode.Model,
+				APIKey:      cfg.OpenCode.APIKey,
+				Timeout:     llm.Duration(cfg.OpenCode.Timeout),
+				Concurrency: cfg.OpenCode.Concurrency,
+			})
+		}
+		if cfg.Anthropic.Enabled {
+			if cfg.Anthropic.APIKey == "" {
+				slog.Error("ANTHROPIC_API_KEY is required when Anthropic provider is enabled")
+				os.Exit(1)
+			}
+			providerConfigs = append(providerConfigs, llm.ProviderConfig{
+				Name:        "anthropic",
+				Type:        llm.TypeAnthropic,
+				Host:        cfg.Anthropic.Host,
+				Model:       cfg.Anthropic.Model,
+				APIKey:      cfg.Anthropic.APIKey,
+				MaxTokens:   cfg.Anthropic.MaxTokens,
+				Timeout:     llm.Duration(cfg.Anthropic.Timeout),
+				Concurrency: cfg.Anthropic.Concurrency,
+			})
+		}
+		if cfg.Gemini.Enabled {
+			if cfg.Gemini.APIKey == "" {
+				slog.Error("GEMINI_API_KEY is required when Gemini provider is enabled")
+				os.Exit(1)
+			}
+			providerConfigs = append(providerConfigs, llm.ProviderConfig{
+				Name:        "gemini",
+				Type:        llm.TypeGemini,
+				Host:        cfg.Gemini.Host,
+				Model:       cfg.Gemini.Model,
+				APIKey:      cfg.Gemini.APIKey,
+				MaxTokens:   cfg.Gemini.MaxTokens,
+				Timeout:     llm.Duration(cfg.Gemini.Timeout),
+				Concurrency: cfg.Gemini.Concurrency,
+			})
+		}
+	}
+	if len(providerConfigs) == 0 {
+		slog.Error("No provider is enabled. Enable at least one of Ollama, OpenCode, Anthropic or Gemini (e.g. OLLAMA_ENABLED=true), or set LLM_PROVIDERS_FILE.")
+		os.Exit(1)
+	}
+
+	providers := make([]*llm.Provider, 0, len(providerConfigs))
+	for _, pc := range providerConfigs {
+		if pc.ContextSize == 0 {
+			pc.ContextSize = cfg.LLM.ContextSizes.For(pc.Model, 0)
+		}
+		providers = append(providers, llm.NewProvider(pc, cfg.LLM.BreakerThreshold, cfg.LLM.BreakerCooldown))
+		slog.Info("Initialized LLM provider", "provider", pc.Name, "type", pc.Type, "model", pc.Model, "host", pc.Host, "contextSize", pc.ContextSize)
+	}
+	chain := llm.NewChain(providers...)
+
+	contextSize := chain.ContextSize(cfg.LLM.ContextSize)
+	slog.Info("Using model context size", "contextSize", contextSize)
+
+	usageTracker := usage.NewTracker(rdb, cfg.LLM.Pricing, cfg.LLM.DailyBudget)
+	slog.Info("Initialized LLM usage tracker", "pricedModels", len(cfg.LLM.Pricing), "dailyBudget", cfg.LLM.DailyBudget)
+
+	var responseCache *cache.ResponseCache
+	if cfg.Cache.Enabled {
+		responseCache = cache.NewResponseCache(rdb, cfg.Cache.TTL)
+		slog.Info("Initialized LLM response cache", "ttl", cfg.Cache.TTL, "bypass", cfg.Cache.Bypass)
+	}
+
+	prompts, err := prompt.Load(cfg.Prompts.Dir)
+	if err != nil {
+		slog.Error("Failed to load prompt templates", "err", err, "dir", cfg.Prompts.Dir)
+		os.Exit(1)
+	}
+	if err := prompts.Validate(); err != nil {
+		slog.Error("Prompt templates failed to render", "err", err, "dir", cfg.Prompts.Dir)
+		os.Exit(1)
+	}
+	if cfg.Summary.Language != "" && language.Name(cfg.Summary.Language) == "" {
+		slog.Error("Unsupported summary language", "language", cfg.Summary.Language)
+		os.Exit(1)
+	}
+	slog.Info("Loaded prompt templates", "dir", cfg.Prompts.Dir,
+		"processContentVersion", prompts.CombinedVersion(prompt.ProcessContentSystemTemplate, prompt.ProcessContentTemplate),
+		"repairVersion", prompts.Version(prompt.RepairTemplate),
+		"chunkSummaryVersion", prompts.CombinedVersion(prompt.ChunkSummarySystemTemplate, prompt.ChunkSummaryTemplate),
+		"releaseNotesVersion", prompts.CombinedVersion(prompt.ReleaseNotesSystemTemplate, prompt.ReleaseNotesTemplate),
+		"digestOverviewVersion", prompts.CombinedVersion(prompt.DigestOverviewSystemTemplate, prompt.DigestOverviewTemplate),
+		"relevanceVersion", prompts.CombinedVersion(prompt.RelevanceSystemTemplate, prompt.RelevanceTemplate))
+
+	// Load the team interest profiles used to score the relevance of items
+	var profiles []relevance.Profile
+	if cfg.Relevance.ProfilesFile != "" {
+		profiles, err = relevance.LoadProfiles(cfg.Relevance.ProfilesFile)
+		if err != nil {
+			slog.Error("Failed to load relevance profiles file", "err", err, "file", cfg.Relevance.ProfilesFile)
+			os.Exit(1)
+		}
+		if cfg.Relevance.LLMWeight < 0 || cfg.Relevance.LLMWeight > 1 {
+			slog.Error("Relevance LLM weight must be between 0 and 1", "weight", cfg.Relevance.LLMWeight)
+			os.Exit(1)
+		}
+		slog.Info("Loaded relevance profiles", "count", len(profiles), "llm", cfg.Relevance.LLM, "llmWeight", cfg.Relevance.LLMWeight)
 	}
 
 	// Create interceptor
@@ -237,25 +532,211 @@ func main() {
 
 	w := worker.New(temporalClient, internal.TaskQueueName, worker.Options{})
 
+	// LLM-bound activities run on their own task queue so they can be throttled without slowing down fetching
+	llmWorker := worker.New(temporalClient, internal.LLMTaskQueueName, worker.Options{
+		MaxConcurrentActivityExecutionSize: cfg.LLM.MaxConcurrentActivities,
+		TaskQueueActivitiesPerSecond:       cfg.LLM.ActivitiesPerSecond,
+		DisableWorkflowWorker:              true,
+	})
+
 	// Register workflow
 	w.RegisterWorkflowWithOptions(internalworkflow.IngestFeedItem(), workflow.RegisterOptions{
 		Name: internal.GetFunctionName(internalworkflow.IngestFeedItem),
 	})
 
+	w.RegisterWorkflowWithOptions(internalworkflow.Digest(), workflow.RegisterOptions{
+		Name: internal.GetFunctionName(internalworkflow.Digest),
+	})
+
+	w.RegisterWorkflowWithOptions(internalworkflow.ReprocessFeedItem(), workflow.RegisterOptions{
+		Name: internal.GetFunctionName(internalworkflow.ReprocessFeedItem),
+	})
+
 	// Register activities with the Activities struct methods
 	w.RegisterActivityWithOptions(internalactivity.AddNewFeedItem(feedItemCollection), activity.RegisterOptions{
 		Name: internal.GetFunctionName(internalactivity.AddNewFeedItem),
 	})
-	w.RegisterActivityWithOptions(internalactivity.FetchHTML(http.DefaultClient, cfg.Storage.HTMLDir), activity.RegisterOptions{
+	w.RegisterActivityWithOptions(internalactivity.MarkFeedItemFailed(feedItemCollection), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.MarkFeedItemFailed),
+	})
+	w.RegisterActivityWithOptions(internalactivity.FetchHTML(netguard.NewClient(cfg.Storage.FetchTimeout), cfg.Storage.HTMLDir), activity.RegisterOptions{
 		Name: internal.GetFunctionName(internalactivity.FetchHTML),
 	})
 	w.RegisterActivityWithOptions(
-		internalactivity.ProcessContent(feedItemCollection, zenClient, model, cfg.Storage.HTMLDir, cfg.TextExtractor.Limit),
+		internalactivity.ClusterFeedItem(internalactivity.ClusterFeedItemConfig{
+			Store:          clusterStore,
+			Embeddings:     clusterEmbeddings,
+			EmbeddingModel: cfg.Embedding.Model,
+			DataDir:        cfg.Storage.HTMLDir,
+			TextLimit:      cfg.TextExtractor.Limit,
+			Window:         cfg.Cluster.Window,
+			Thresholds: cluster.Thresholds{
+				MaxDistance:   cfg.Cluster.MaxDistance,
+				MinSimilarity: cfg.Cluster.MinSimilarity,
+			},
+		}),
+		activity.RegisterOptions{
+			Name: internal.GetFunctionName(internalactivity.ClusterFeedItem),
+		},
+	)
+	// The SMTP sender and webhook client are shared by the digests, webhooks and alerts
+	smtpSender := digest.NewSMTPSender(digest.SMTPConfig{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		From:     cfg.SMTP.From,
+	})
+	webhookClient := webhook.NewClient(&http.Client{Timeout: cfg.Webhook.Timeout})
+	webhookConfig := internalactivity.WebhookConfig{
+		Items:  feeditem.NewStore(feedItemCollection),
+		Store:  webhookStore,
+		Client: webhookClient,
+	}
+	w.RegisterActivityWithOptions(internalactivity.NotifyWebhooks(webhookConfig), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.NotifyWebhooks),
+	})
+	w.RegisterActivityWithOptions(internalactivity.DeliverWebhook(webhookConfig), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.DeliverWebhook),
+	})
+	alertConfig := internalactivity.AlertConfig{
+		Collection: feedItemCollection,
+		Items:      feeditem.NewStore(feedItemCollection),
+		Store:      alertStore,
+		Notifiers: alert.Notifiers{
+			alert.TargetLog:     alert.LogNotifier{Logger: slog.Default()},
+			alert.TargetEmail:   alert.EmailNotifier{Sender: smtpSender},
+			alert.TargetWebhook: alert.WebhookNotifier{Client: webhookClient},
+		},
+	}
+	w.RegisterActivityWithOptions(internalactivity.EvaluateAlerts(alertConfig), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.EvaluateAlerts),
+	})
+	w.RegisterActivityWithOptions(internalactivity.NotifyAlert(alertConfig), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.NotifyAlert),
+	})
+	collectDigestConfig := internalactivity.CollectDigestConfig{
+		Store:               digestStore,
+		MaxItems:            cfg.Digest.MaxItems,
+		MaxItemsPerCategory: cfg.Digest.MaxItemsPerCategory,
+	}
+	w.RegisterActivityWithOptions(internalactivity.ListDigestSubscribers(digestStore), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.ListDigestSubscribers),
+	})
+	w.RegisterActivityWithOptions(internalactivity.CollectDigest(collectDigestConfig), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.CollectDigest),
+	})
+	w.RegisterActivityWithOptions(internalactivity.MarkDigestSent(digestStore), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.MarkDigestSent),
+	})
+	w.RegisterActivityWithOptions(internalactivity.SendDigest(smtpSender), activity.RegisterOptions{
+		Name: internal.GetFunctionName(internalactivity.SendDigest),
+	})
+	llmWorker.RegisterActivityWithOptions(
+		internalactivity.ProcessContent(internalactivity.ProcessContentConfig{
+			Collection:          feedItemCollection,
+			LLM:                 chain,
+			Tracker:             usageTracker,
+			Prompts:             prompts,
+			Taxonomy:            taxonomyStore,
+			States:              itemStateStore,
+			StrictCategories:    cfg.Categories.Strict,
+			SummaryLanguage:     strings.ToLower(cfg.Summary.Language),
+			KeepOriginalSummary: cfg.Summary.KeepOriginal,
+			Cache:               responseCache,
+			BypassCache:         cfg.Cache.Bypass,
+			DataDir:             cfg.Storage.HTMLDir,
+			TextLimit:           cfg.TextExtractor.Limit,
+			SearchTextLimit:     cfg.Search.TextLimit,
+			MaxRepairs:          cfg.LLM.RepairAttempts,
+			ContextSize:         contextSize,
+		}),
 		activity.RegisterOptions{
 			Name: internal.GetFunctionName(internalactivity.ProcessContent),
 		},
 	)
 
+	llmWorker.RegisterActivityWithOptions(
+		internalactivity.ExtractRelease(internalactivity.ExtractReleaseConfig{
+			Collection:  feedItemCollection,
+			LLM:         chain,
+			Tracker:     usageTracker,
+			Prompts:     prompts,
+			DataDir:     cfg.Storage.HTMLDir,
+			TextLimit:   cfg.TextExtractor.Limit,
+			MaxRepairs:  cfg.LLM.RepairAttempts,
+			ContextSize: contextSize,
+		}),
+		activity.RegisterOptions{
+			Name: internal.GetFunctionName(internalactivity.ExtractRelease),
+		},
+	)
+
+	llmWorker.RegisterActivityWithOptions(
+		internalactivity.EmbedFeedItem(internalactivity.EmbedFeedItemConfig{
+			Collection: feedItemCollection,
+			Store:      embeddingStore,
+			Embedder:   embedder,
+			DataDir:    cfg.Storage.HTMLDir,
+			TextLimit:  cfg.TextExtractor.Limit,
+			MaxTokens:  cfg.Embedding.MaxTokens,
+		}),
+		activity.RegisterOptions{
+			Name: internal.GetFunctionName(internalactivity.EmbedFeedItem),
+		},
+	)
+
+	llmWorker.RegisterActivityWithOptions(
+		internalactivity.ScoreRelevance(internalactivity.ScoreRelevanceConfig{
+			Collection: feedItemCollection,
+			Profiles:   profiles,
+			Judge:      cfg.Relevance.LLM,
+			LLMWeight:  cfg.Relevance.LLMWeight,
+			LLM:        chain,
+			Tracker:    usageTracker,
+			Prompts:    prompts,
+		}),
+		activity.RegisterOptions{
+			Name: internal.GetFunctionName(internalactivity.ScoreRelevance),
+		},
+	)
+
+	llmWorker.RegisterActivityWithOptions(
+		internalactivity.SummarizeDigest(internalactivity.SummarizeDigestConfig{
+			LLM:         chain,
+			Tracker:     usageTracker,
+			Prompts:     prompts,
+			Language:    strings.ToLower(cfg.Summary.Language),
+			ContextSize: contextSize,
+		}),
+		activity.RegisterOptions{
+			Name: internal.GetFunctionName(internalactivity.SummarizeDigest),
+		},
+	)
+
+	// Schedule the digests, updating existing schedules to the configured cron expressions
+	if cfg.Digest.Enabled {
+		for frequency, cron := range map[digest.Frequency]string{digest.Daily: cfg.Digest.DailySchedule, digest.Weekly: cfg.Digest.WeeklySchedule} {
+			if cron == "" {
+				continue
+			}
+			req := internalworkflow.DigestRequest{Frequency: frequency, Overview: cfg.Digest.Overview}
+			if err := ensureDigestSchedule(ctx, temporalClient, cron, cfg.Digest.TimeZone, req); err != nil {
+				slog.Error("Failed to schedule digest", "err", err, "frequency", frequency, "schedule", cron)
+				os.Exit(1)
+			}
+			slog.Info("Scheduled digest", "frequency", frequency, "schedule", cron, "timeZone", cfg.Digest.TimeZone, "overview", cfg.Digest.Overview)
+		}
+	}
+
+	slog.Info("Starting LLM worker", "taskQueue", internal.LLMTaskQueueName,
+		"maxConcurrentActivities", cfg.LLM.MaxConcurrentActivities, "activitiesPerSecond", cfg.LLM.ActivitiesPerSecond)
+	if err := llmWorker.Start(); err != nil {
+		slog.Error("Unable to start LLM worker", "err", err)
+		os.Exit(1)
+	}
+	defer llmWorker.Stop()
+
 	slog.Info("Starting worker", "taskQueue", internal.TaskQueueName)
 
 	err = w.Run(worker.InterruptCh())
@@ -264,3 +745,29 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// ensureDigestSchedule creates the Temporal schedule starting the Digest workflow for req on the
+// cron expression, or updates the schedule when it already exists.
+func ensureDigestSchedule(ctx context.Context, c client.Client, cron, timeZone string, req internalworkflow.DigestRequest) error {
+	id := "digest-" + string(req.Frequency)
+	spec := client.ScheduleSpec{CronExpressions: []string{cron}, TimeZoneName: timeZone}
+	action := &client.ScheduleWorkflowAction{
+		ID:        id,
+		Workflow:  internal.GetFunctionName(internalworkflow.Digest),
+		Args:      []any{req},
+		TaskQueue: internal.TaskQueueName,
+	}
+
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{ID: id, Spec: spec, Action: action})
+	if !errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return err
+	}
+	return c.ScheduleClient().GetHandle(ctx, id).Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			schedule := input.Description.Schedule
+			schedule.Spec = &spec
+			schedule.Action = action
+			return &client.ScheduleUpdate{Schedule: &schedule}, nil
+		},
+	})
+}
@@ -0,0 +1,157 @@
+// Package account manages the users of the API, their API keys and their feed subscriptions. The
+// ingester polls the union of all subscriptions, each feed once however many users subscribe to
+// it, and the API shows users the items of the feeds they subscribe to.
+package account
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feedfilter"
+	"github.com/demeyerthom/feeds-aggregator/internal/netguard"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// keyPrefix starts every API key, so leaked keys are easy to recognize
+const keyPrefix = "fa_"
+
+var (
+	// ErrInvalidUser is returned for users that cannot be stored
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUnauthorized is returned for unknown API keys and the keys of disabled users
+	ErrUnauthorized = errors.New("invalid API key")
+	// ErrKeyNotFound is returned when an API key does not exist
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrInvalidSubscription is returned for subscriptions that cannot be polled
+	ErrInvalidSubscription = errors.New("invalid subscription")
+	// ErrDuplicateSubscription is returned when a user already subscribes to a feed
+	ErrDuplicateSubscription = errors.New("already subscribed to this feed")
+	// ErrSubscriptionNotFound is returned when a subscription does not exist
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+)
+
+// User is a user of the API
+type User struct {
+	// ID is the user name, which identifies the user in item states and logs
+	ID        string    `bson:"_id"`
+	Name      string    `bson:"name,omitempty"`
+	Email     string    `bson:"email,omitempty"`
+	Disabled  bool      `bson:"disabled,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Validate reports whether u has an ID without whitespace.
+func (u User) Validate() error {
+	if u.ID == "" || strings.ContainsFunc(u.ID, func(r rune) bool { return r <= ' ' }) {
+		return fmt.Errorf("%w: ID must be set and not contain whitespace, got %q", ErrInvalidUser, u.ID)
+	}
+	return nil
+}
+
+// APIKey is an API key of a user. Only its hash is stored; the key itself is shown once, when it is
+// created.
+type APIKey struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	User string             `bson:"user"`
+	Name string             `bson:"name,omitempty"`
+	// Hash is the hex-encoded SHA-256 hash of the key
+	Hash string `bson:"hash"`
+	// Hint is the start of the key, to tell keys apart in listings
+	Hint       string     `bson:"hint"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty"`
+}
+
+// NewKey generates a random API key.
+func NewKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashKey returns the hash under which key is stored. API keys are random, so a fast hash is as safe
+// as a password hash and keeps authenticating every request cheap.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// keyHint returns the start of key shown in listings.
+func keyHint(key string) string {
+	return key[:min(len(key), len(keyPrefix)+6)] + "…"
+}
+
+// Subscription is the subscription of a user to a feed
+type Subscription struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	User  string             `bson:"user"`
+	Title string             `bson:"title"`
+	URL   string             `bson:"url"`
+	// Type is empty for regular feeds or internal.FeedTypeRelease
+	Type string `bson:"type,omitempty"`
+	// Filters decide which items of the feed the user wants ingested
+	Filters   []feedfilter.Rule `bson:"filters,omitempty"`
+	CreatedAt time.Time         `bson:"created_at"`
+}
+
+// Validate reports whether s has a title, an HTTP(S) URL outside the internal network, a known type
+// and valid filter rules.
+func (s Subscription) Validate() error {
+	if strings.TrimSpace(s.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidSubscription)
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: URL must be an http or https URL, got %q", ErrInvalidSubscription, s.URL)
+	}
+	if err := netguard.CheckURL(s.URL); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	if s.Type != "" && s.Type != internal.FeedTypeRelease {
+		return fmt.Errorf("%w: type must be empty or %s, got %q", ErrInvalidSubscription, internal.FeedTypeRelease, s.Type)
+	}
+	if _, err := feedfilter.Compile(s.Filters); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSubscription, err)
+	}
+	return nil
+}
+
+// Source is a feed polled once for all its subscriptions
+type Source struct {
+	// Feed is the feed polled, without filters
+	Feed internal.Feed
+	// Rules holds the filter rules of every subscription, see feedfilter.Any
+	Rules [][]feedfilter.Rule
+	// Subscribers is the number of users subscribing to the feed
+	Subscribers int
+}
+
+// Sources groups subscriptions, ordered by creation time, into the feeds to poll. A feed is
+// polled under the title and type of its first subscription.
+func Sources(subscriptions []Subscription) []Source {
+	var sources []Source
+	index := map[string]int{}
+	for _, sub := range subscriptions {
+		i, ok := index[sub.URL]
+		if !ok {
+			i = len(sources)
+			index[sub.URL] = i
+			sources = append(sources, Source{Feed: internal.Feed{Title: sub.Title, XMLURL: sub.URL, Type: sub.Type}})
+		}
+		sources[i].Rules = append(sources[i].Rules, sub.Filters)
+		sources[i].Subscribers++
+	}
+	return sources
+}
@@ -0,0 +1,81 @@
+package account
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feedfilter"
+)
+
+func TestNewKey(t *testing.T) {
+	a, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+	b, _ := NewKey()
+	if a == b || !strings.HasPrefix(a, keyPrefix) || len(a) != len(keyPrefix)+43 {
+		t.Errorf("unexpected keys %q and %q", a, b)
+	}
+	if HashKey(a) != HashKey(a) || HashKey(a) == HashKey(b) || strings.Contains(HashKey(a), a) {
+		t.Error("expected a stable hash per key that does not contain the key")
+	}
+	if hint := keyHint(a); !strings.HasPrefix(a, strings.TrimSuffix(hint, "…")) || len(hint) >= len(a) {
+		t.Errorf("unexpected hint %q", hint)
+	}
+}
+
+func TestUser_Validate(t *testing.T) {
+	if err := (User{ID: "alice"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, id := range []string{"", "alice smith", "alice\t"} {
+		if err := (User{ID: id}).Validate(); !errors.Is(err, ErrInvalidUser) {
+			t.Errorf("Validate(%q) expected ErrInvalidUser, got %v", id, err)
+		}
+	}
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	valid := Subscription{Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Filters: []feedfilter.Rule{{Prerelease: true}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	for name, sub := range map[string]Subscription{
+		"title":  {URL: "https://go.dev/blog/feed.atom"},
+		"scheme": {Title: "Go Blog", URL: "ftp://go.dev/blog/feed.atom"},
+		"url":    {Title: "Go Blog", URL: "go.dev/blog/feed.atom"},
+		"local":  {Title: "Metadata", URL: "http://169.254.169.254/latest/meta-data/"},
+		"type":   {Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Type: "podcast"},
+		"filter": {Title: "Go Blog", URL: "https://go.dev/blog/feed.atom", Filters: []feedfilter.Rule{{Name: "empty"}}},
+	} {
+		if err := sub.Validate(); !errors.Is(err, ErrInvalidSubscription) {
+			t.Errorf("%s: expected ErrInvalidSubscription, got %v", name, err)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	prereleases := []feedfilter.Rule{{Prerelease: true}}
+	sources := Sources([]Subscription{
+		{User: "alice", Title: "Terraform", URL: "https://github.com/hashicorp/terraform/releases.atom", Type: internal.FeedTypeRelease, Filters: prereleases},
+		{User: "alice", Title: "Go Blog", URL: "https://go.dev/blog/feed.atom"},
+		{User: "bob", Title: "Terraform releases", URL: "https://github.com/hashicorp/terraform/releases.atom"},
+	})
+
+	if len(sources) != 2 {
+		t.Fatalf("expected one source per feed, got %+v", sources)
+	}
+	terraform := sources[0]
+	if terraform.Feed.Title != "Terraform" || terraform.Feed.Type != internal.FeedTypeRelease || terraform.Subscribers != 2 {
+		t.Errorf("expected the feed to be polled as first subscribed, got %+v", terraform)
+	}
+	if len(terraform.Rules) != 2 || len(terraform.Rules[0]) != 1 || terraform.Rules[1] != nil {
+		t.Errorf("expected the rules of every subscription, got %+v", terraform.Rules)
+	}
+	if sources[1].Feed.XMLURL != "https://go.dev/blog/feed.atom" || sources[1].Subscribers != 1 {
+		t.Errorf("unexpected source %+v", sources[1])
+	}
+}
@@ -0,0 +1,249 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// lastUsedInterval is how often the last use of an API key is saved, so busy keys do not cause a
+// write on every request
+const lastUsedInterval = time.Minute
+
+// Store persists users, their API keys and their subscriptions in MongoDB
+type Store struct {
+	users         *mongo.Collection
+	keys          *mongo.Collection
+	subscriptions *mongo.Collection
+}
+
+// NewStore creates a store backed by the user, API key and subscription collections.
+func NewStore(users, keys, subscriptions *mongo.Collection) *Store {
+	return &Store{users: users, keys: keys, subscriptions: subscriptions}
+}
+
+// EnsureIndexes creates the unique index used to authenticate API keys, the unique index allowing
+// one subscription per user and feed, and the index listing the subscriptions in creation order.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.keys.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.subscriptions.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "url", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+// SaveUser adds or updates a user.
+func (s *Store) SaveUser(ctx context.Context, u User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	_, err := s.users.UpdateOne(ctx,
+		bson.M{"_id": u.ID},
+		bson.M{
+			"$set": bson.M{
+				"name":     u.Name,
+				"email":    u.Email,
+				"disabled": u.Disabled,
+			},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// User returns the user with the given ID.
+func (s *Store) User(ctx context.Context, id string) (User, error) {
+	var u User
+	err := s.users.FindOne(ctx, bson.M{"_id": id}).Decode(&u)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return u, ErrUserNotFound
+	}
+	return u, err
+}
+
+// Users returns all users, ordered by ID.
+func (s *Store) Users(ctx context.Context) ([]User, error) {
+	cursor, err := s.users.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var users []User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// RemoveUser deletes a user with their API keys and subscriptions.
+func (s *Store) RemoveUser(ctx context.Context, id string) error {
+	res, err := s.users.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+	if _, err := s.keys.DeleteMany(ctx, bson.M{"user": id}); err != nil {
+		return err
+	}
+	_, err = s.subscriptions.DeleteMany(ctx, bson.M{"user": id})
+	return err
+}
+
+// CreateKey creates an API key for user and returns the key, which cannot be read back later.
+func (s *Store) CreateKey(ctx context.Context, user, name string) (string, APIKey, error) {
+	if _, err := s.User(ctx, user); err != nil {
+		return "", APIKey{}, err
+	}
+	key, err := NewKey()
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	k := APIKey{ID: primitive.NewObjectID(), User: user, Name: name, Hash: HashKey(key), Hint: keyHint(key), CreatedAt: time.Now()}
+	if _, err := s.keys.InsertOne(ctx, k); err != nil {
+		return "", APIKey{}, err
+	}
+	return key, k, nil
+}
+
+// Keys returns the API keys of user, ordered by creation time.
+func (s *Store) Keys(ctx context.Context, user string) ([]APIKey, error) {
+	cursor, err := s.keys.Find(ctx, bson.M{"user": user}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeKey deletes the API key with the given ID.
+func (s *Store) RevokeKey(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.keys.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the user of an API key, or ErrUnauthorized when the key is unknown or its
+// user is disabled.
+func (s *Store) Authenticate(ctx context.Context, key string) (User, error) {
+	var k APIKey
+	err := s.keys.FindOne(ctx, bson.M{"hash": HashKey(key)}).Decode(&k)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return User{}, ErrUnauthorized
+	}
+	if err != nil {
+		return User{}, err
+	}
+	u, err := s.User(ctx, k.User)
+	if errors.Is(err, ErrUserNotFound) || u.Disabled {
+		return User{}, ErrUnauthorized
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedInterval {
+		if _, err := s.keys.UpdateOne(ctx, bson.M{"_id": k.ID}, bson.M{"$set": bson.M{"last_used_at": now}}); err != nil {
+			return User{}, err
+		}
+	}
+	return u, nil
+}
+
+// Subscribe stores a new subscription and returns it with its ID.
+func (s *Store) Subscribe(ctx context.Context, sub Subscription) (Subscription, error) {
+	if err := sub.Validate(); err != nil {
+		return Subscription{}, err
+	}
+	if _, err := s.User(ctx, sub.User); err != nil {
+		return Subscription{}, err
+	}
+	sub.ID = primitive.NewObjectID()
+	sub.CreatedAt = time.Now()
+	if _, err := s.subscriptions.InsertOne(ctx, sub); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return Subscription{}, ErrDuplicateSubscription
+		}
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe deletes the subscription of user with the given ID.
+func (s *Store) Unsubscribe(ctx context.Context, user string, id primitive.ObjectID) error {
+	res, err := s.subscriptions.DeleteOne(ctx, bson.M{"_id": id, "user": user})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// Subscriptions returns the subscriptions of user, or of all users when user is empty, ordered by
+// creation time.
+func (s *Store) Subscriptions(ctx context.Context, user string) ([]Subscription, error) {
+	filter := bson.M{}
+	if user != "" {
+		filter["user"] = user
+	}
+	cursor, err := s.subscriptions.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// FeedURLs returns the URLs of the feeds user subscribes to. The result is empty but not nil for
+// users without subscriptions, who see no items.
+func (s *Store) FeedURLs(ctx context.Context, user string) ([]string, error) {
+	cursor, err := s.subscriptions.Find(ctx, bson.M{"user": user}, options.Find().SetProjection(bson.M{"url": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	urls := make([]string, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		urls = append(urls, sub.URL)
+	}
+	return urls, nil
+}
+
+// Sources returns the feeds to poll: the union of the subscriptions of all users.
+func (s *Store) Sources(ctx context.Context) ([]Source, error) {
+	subscriptions, err := s.Subscriptions(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return Sources(subscriptions), nil
+}
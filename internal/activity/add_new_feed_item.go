@@ -25,9 +25,14 @@ func AddNewFeedItem(c *mongo.Collection) func(ctx context.Context, feedItem inte
 		logger := activity.GetLogger(ctx)
 
 		doc := internal.FeedItemDocument{
-			Link:      feedItem.Link,
-			Title:     feedItem.Title,
-			CreatedAt: time.Now(),
+			Link:        feedItem.Link,
+			Title:       feedItem.Title,
+			Feed:        feedItem.Feed,
+			FeedURL:     feedItem.FeedURL,
+			FeedType:    feedItem.FeedType,
+			PublishedAt: feedItem.Published,
+			Status:      internal.StatusPending,
+			CreatedAt:   time.Now(),
 		}
 
 		result, err := c.InsertOne(ctx, doc)
@@ -0,0 +1,33 @@
+package activity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	textextractor "github.com/demeyerthom/feeds-aggregator/internal/html"
+	"go.temporal.io/sdk/activity"
+)
+
+// readArticleText reads the fetched HTML of a feed item and extracts the article text, falling back
+// to the plain text of the whole page when no article can be found.
+func readArticleText(ctx context.Context, dataDir string, textLimit int, feedItemDoc internal.FeedItemDocument) (string, error) {
+	logger := activity.GetLogger(ctx)
+
+	filename := filepath.Join(dataDir, feedItemDoc.ID.Hex()+".html")
+	htmlContent, err := os.ReadFile(filename)
+	if err != nil {
+		logger.Error("Failed to read HTML file", "err", err, "filename", filename)
+		return "", err
+	}
+
+	logger.Info("Read HTML file", "id", feedItemDoc.ID.Hex(), "size", len(htmlContent))
+
+	extractText := textextractor.ExtractArticleText(textLimit)
+	articleText, ok := extractText(ctx, string(htmlContent))
+	if !ok || len(articleText) == 0 {
+		articleText = textextractor.StripHTMLToPlainText(string(htmlContent))
+	}
+	return articleText, nil
+}
@@ -0,0 +1,126 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/cluster"
+	"github.com/demeyerthom/feeds-aggregator/internal/simhash"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+)
+
+var clusterJoinCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	clusterJoinCounter, _ = meter.Int64Counter(
+		"feeds.clusters.joins",
+		metric.WithDescription("Number of feed items added to a story cluster by match method (simhash, embedding)"),
+		metric.WithUnit("{item}"),
+	)
+}
+
+// ClusterFeedItemConfig holds the dependencies and settings of the ClusterFeedItem activity
+type ClusterFeedItemConfig struct {
+	// Store persists clusters and fingerprints
+	Store *cluster.Store
+	// Embeddings holds the feed item embeddings; nil disables embedding similarity
+	Embeddings *vector.Store
+	// EmbeddingModel is the model whose embeddings are compared
+	EmbeddingModel string
+	// DataDir is the directory where HTML files are stored
+	DataDir string
+	// TextLimit is the maximum number of characters to extract from HTML content
+	TextLimit int
+	// Window is how far back near-duplicates are searched
+	Window time.Duration
+	// Thresholds decide when two items are near-duplicates
+	Thresholds cluster.Thresholds
+}
+
+// ClusterFeedItem fingerprints the article text of a feed item with SimHash and, when it is a
+// near-duplicate of a recent item from the window, adds it to that item's story cluster. Items that
+// are worded differently can still be clustered by embedding similarity when embeddings are available.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that clusters a feed item document
+// @author Thomas De Meyer
+func ClusterFeedItem(cfg ClusterFeedItemConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+
+		articleText, err := readArticleText(ctx, cfg.DataDir, cfg.TextLimit, feedItemDoc)
+		if err != nil {
+			return err
+		}
+
+		hash := simhash.Compute(articleText)
+		if err := cfg.Store.SetSimHash(ctx, feedItemDoc.ID, hash); err != nil {
+			logger.Error("Failed to save SimHash", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		candidates, err := cfg.Store.Candidates(ctx, feedItemDoc.ID, time.Now().Add(-cfg.Window))
+		if err != nil {
+			logger.Error("Failed to find cluster candidates", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		if cfg.Embeddings != nil && cfg.Thresholds.MinSimilarity > 0 && len(candidates) > 0 {
+			if err := addSimilarities(ctx, cfg, feedItemDoc.ID, candidates); err != nil {
+				// Embedding similarity is optional, SimHash matching still works without it
+				logger.Warn("Failed to compare embeddings", "err", err, "id", feedItemDoc.ID.Hex())
+			}
+		}
+
+		match, ok := cluster.BestMatch(hash, candidates, cfg.Thresholds)
+		if !ok {
+			logger.Info("No near-duplicate found", "id", feedItemDoc.ID.Hex(), "candidates", len(candidates))
+			return nil
+		}
+
+		clusterID, err := cfg.Store.Join(ctx, feedItemDoc, match)
+		if err != nil {
+			logger.Error("Failed to add feed item to story cluster", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		clusterJoinCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+			attribute.String("method", match.Method),
+		)))
+		logger.Info("Added feed item to story cluster", "id", feedItemDoc.ID.Hex(), "cluster", clusterID.Hex(),
+			"duplicateOf", match.ItemID.Hex(), "method", match.Method, "distance", match.Distance, "similarity", match.Similarity)
+		return nil
+	}
+}
+
+// addSimilarities sets the embedding similarity of each candidate to the feed item.
+func addSimilarities(ctx context.Context, cfg ClusterFeedItemConfig, id primitive.ObjectID, candidates []cluster.Candidate) error {
+	ids := make([]primitive.ObjectID, 0, len(candidates)+1)
+	ids = append(ids, id)
+	for _, c := range candidates {
+		ids = append(ids, c.ItemID)
+	}
+
+	embeddings, err := cfg.Embeddings.Find(ctx, cfg.EmbeddingModel, ids)
+	if err != nil {
+		return err
+	}
+	own, ok := embeddings[id]
+	if !ok {
+		return nil
+	}
+	for i, c := range candidates {
+		if other, ok := embeddings[c.ItemID]; ok {
+			candidates[i].Similarity = vector.Similarity(own, other)
+		}
+	}
+	return nil
+}
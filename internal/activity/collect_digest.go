@@ -0,0 +1,83 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"go.temporal.io/sdk/activity"
+)
+
+// CollectDigestConfig holds the dependencies and settings of the digest activities
+type CollectDigestConfig struct {
+	// Store reads subscribers and the processed items of a period
+	Store *digest.Store
+	// MaxItems is the maximum number of items read for one digest
+	MaxItems int
+	// MaxItemsPerCategory is the maximum number of items listed per category, 0 for no limit
+	MaxItemsPerCategory int
+}
+
+// ListDigestSubscribers returns the enabled subscribers receiving a digest at the given frequency.
+//
+// @param store - The digest store
+// @return A function that lists the subscribers of a digest frequency
+// @author Thomas De Meyer
+func ListDigestSubscribers(store *digest.Store) func(ctx context.Context, frequency digest.Frequency) ([]digest.Subscriber, error) {
+	return func(ctx context.Context, frequency digest.Frequency) ([]digest.Subscriber, error) {
+		logger := activity.GetLogger(ctx)
+
+		subscribers, err := store.Subscribers(ctx, frequency)
+		if err != nil {
+			logger.Error("Failed to list digest subscribers", "err", err, "frequency", frequency)
+			return nil, err
+		}
+
+		logger.Info("Listed digest subscribers", "frequency", frequency, "subscribers", len(subscribers))
+		return subscribers, nil
+	}
+}
+
+// CollectDigest reads the items processed since the last digest of the subscriber up to until,
+// limited to the categories of the subscriber, and groups them by category.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that collects the digest of a subscriber
+// @author Thomas De Meyer
+func CollectDigest(cfg CollectDigestConfig) func(ctx context.Context, subscriber digest.Subscriber, until time.Time) (digest.Digest, error) {
+	return func(ctx context.Context, subscriber digest.Subscriber, until time.Time) (digest.Digest, error) {
+		logger := activity.GetLogger(ctx)
+
+		d := digest.Digest{Frequency: subscriber.Frequency, Since: subscriber.Since(until), Until: until}
+		docs, err := cfg.Store.Items(ctx, d.Since, d.Until, subscriber.Categories, cfg.MaxItems)
+		if err != nil {
+			logger.Error("Failed to read digest items", "err", err, "email", subscriber.Email)
+			return digest.Digest{}, err
+		}
+		if len(docs) == cfg.MaxItems {
+			logger.Warn("Digest item limit reached, leaving out older items", "email", subscriber.Email, "maxItems", cfg.MaxItems)
+		}
+		d.Sections = digest.Group(docs, subscriber.Categories, cfg.MaxItemsPerCategory)
+
+		logger.Info("Collected digest", "email", subscriber.Email, "since", d.Since, "until", d.Until, "items", d.Items(), "categories", len(d.Sections))
+		return d, nil
+	}
+}
+
+// MarkDigestSent records that the subscriber received the digest of the period ending at until,
+// so the next digest starts where this one ended.
+//
+// @param store - The digest store
+// @return A function that marks the digest of a subscriber as sent
+// @author Thomas De Meyer
+func MarkDigestSent(store *digest.Store) func(ctx context.Context, subscriber digest.Subscriber, until time.Time) error {
+	return func(ctx context.Context, subscriber digest.Subscriber, until time.Time) error {
+		logger := activity.GetLogger(ctx)
+
+		if err := store.MarkSent(ctx, subscriber.ID, until); err != nil {
+			logger.Error("Failed to mark digest as sent", "err", err, "email", subscriber.Email)
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package activity
+
+import (
+	"context"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/demeyerthom/feeds-aggregator/internal/tokens"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.temporal.io/sdk/activity"
+)
+
+// EmbedFeedItemConfig holds the dependencies and settings of the EmbedFeedItem activity
+type EmbedFeedItemConfig struct {
+	// Collection is the MongoDB collection of feed item documents
+	Collection *mongo.Collection
+	// Store persists the embeddings
+	Store *vector.Store
+	// Embedder creates the embeddings; nil disables the activity
+	Embedder llm.Embedder
+	// DataDir is the directory where HTML files are stored
+	DataDir string
+	// TextLimit is the maximum number of characters to extract from HTML content
+	TextLimit int
+	// MaxTokens is the maximum number of tokens of article text sent to the embedding model
+	MaxTokens int
+}
+
+// EmbedFeedItem creates embeddings of the summary and the extracted article text of a processed
+// feed item and stores them for semantic search. It runs after ProcessContent, so the summary is
+// read from the stored document rather than the workflow input.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that embeds a feed item document
+// @author Thomas De Meyer
+func EmbedFeedItem(cfg EmbedFeedItemConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+
+		if cfg.Embedder == nil {
+			logger.Debug("Embeddings are disabled, skipping", "id", feedItemDoc.ID.Hex())
+			return nil
+		}
+
+		var stored internal.FeedItemDocument
+		err := cfg.Collection.FindOne(ctx, bson.M{"_id": feedItemDoc.ID}, options.FindOne().SetProjection(bson.M{"summary": 1})).Decode(&stored)
+		if err != nil {
+			logger.Error("Failed to read feed item for embedding", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		articleText, err := readArticleText(ctx, cfg.DataDir, cfg.TextLimit, feedItemDoc)
+		if err != nil {
+			return err
+		}
+		articleText = tokens.Truncate(articleText, cfg.MaxTokens)
+
+		// Embedding endpoints reject empty inputs, so only send what is available
+		var inputs []string
+		var targets []*[]float32
+		embedding := vector.Embedding{ItemID: feedItemDoc.ID, Model: cfg.Embedder.Model(), CreatedAt: time.Now()}
+		if stored.Summary != "" {
+			inputs = append(inputs, stored.Summary)
+			targets = append(targets, &embedding.Summary)
+		}
+		if articleText != "" {
+			inputs = append(inputs, articleText)
+			targets = append(targets, &embedding.Content)
+		}
+		if len(inputs) == 0 {
+			logger.Warn("Feed item has no text to embed", "id", feedItemDoc.ID.Hex())
+			return nil
+		}
+
+		vectors, err := cfg.Embedder.Embed(ctx, inputs)
+		if err != nil {
+			logger.Error("Failed to create embeddings", "err", err, "id", feedItemDoc.ID.Hex(), "model", cfg.Embedder.Model())
+			return err
+		}
+		for i, target := range targets {
+			*target = vectors[i]
+		}
+
+		if err := cfg.Store.Save(ctx, embedding); err != nil {
+			logger.Error("Failed to save embeddings", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		logger.Info("Saved feed item embeddings", "id", feedItemDoc.ID.Hex(), "model", embedding.Model, "dimensions", len(vectors[0]))
+		return nil
+	}
+}
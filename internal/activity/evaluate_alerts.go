@@ -0,0 +1,186 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/alert"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeAlertRejected is the application error type of alert notifications that cannot succeed
+// when retried, such as webhooks rejecting the request with a client error.
+const ErrTypeAlertRejected = "AlertRejected"
+
+var (
+	alertMatchCounter        metric.Int64Counter
+	alertNotificationCounter metric.Int64Counter
+)
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	alertMatchCounter, _ = meter.Int64Counter(
+		"feeds.alerts.matches",
+		metric.WithDescription("Number of alert rules matched by feed items, by rule"),
+		metric.WithUnit("{match}"),
+	)
+	alertNotificationCounter, _ = meter.Int64Counter(
+		"feeds.alert.notifications",
+		metric.WithDescription("Number of alert notifications by target type and outcome (delivered, failed, rejected)"),
+		metric.WithUnit("{notification}"),
+	)
+}
+
+// AlertConfig holds the dependencies of the alert activities
+type AlertConfig struct {
+	// Collection is the MongoDB collection for reading and updating feed item documents
+	Collection *mongo.Collection
+	// Items reads the processed feed item when notifying
+	Items *feeditem.Store
+	// Store holds the alert rules
+	Store *alert.Store
+	// Notifiers deliver the alerts by target type
+	Notifiers alert.Notifiers
+}
+
+// EvaluateAlerts evaluates the enabled alert rules against the processed feed item, including its
+// article text, entities and release information, and records the matched rules on the item. It
+// returns a notification for every target of every matched rule, so each is delivered and retried
+// on its own. Rules that no longer compile are skipped with a warning.
+//
+// @param cfg - Dependencies of the activity
+// @return A function that returns the alert notifications of a feed item
+// @author Thomas De Meyer
+func EvaluateAlerts(cfg AlertConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) ([]alert.Notification, error) {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) ([]alert.Notification, error) {
+		logger := activity.GetLogger(ctx)
+
+		rules, err := cfg.Store.Enabled(ctx)
+		if err != nil {
+			logger.Error("Failed to list alert rules", "err", err)
+			return nil, err
+		}
+		if len(rules) == 0 {
+			return nil, nil
+		}
+
+		compiled := make([]*alert.Compiled, 0, len(rules))
+		for _, r := range rules {
+			c, err := alert.Compile(r)
+			if err != nil {
+				logger.Warn("Skipping invalid alert rule", "err", err, "rule", r.Name)
+				continue
+			}
+			compiled = append(compiled, c)
+		}
+
+		var doc internal.FeedItemDocument
+		if err := cfg.Collection.FindOne(ctx, bson.M{"_id": feedItemDoc.ID}).Decode(&doc); err != nil {
+			logger.Error("Failed to read feed item", "err", err, "id", feedItemDoc.ID.Hex())
+			return nil, err
+		}
+
+		matched := alert.Evaluate(compiled, alert.NewItem(doc))
+		if len(matched) == 0 {
+			return nil, nil
+		}
+
+		now := time.Now()
+		matches := make([]internal.AlertMatch, 0, len(matched))
+		var notifications []alert.Notification
+		for _, r := range matched {
+			matches = append(matches, internal.AlertMatch{RuleID: r.ID, Rule: r.Name, MatchedAt: now})
+			for i := range r.Targets {
+				notifications = append(notifications, alert.Notification{RuleID: r.ID.Hex(), Target: i})
+			}
+			alertMatchCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("rule", r.Name)))
+		}
+
+		if _, err := cfg.Collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"alerts": matches}}); err != nil {
+			logger.Error("Failed to save alert matches", "err", err, "id", doc.ID.Hex())
+			return nil, err
+		}
+
+		logger.Info("Matched alert rules", "id", doc.ID.Hex(), "rules", len(compiled), "matched", len(matched), "notifications", len(notifications))
+		return notifications, nil
+	}
+}
+
+// NotifyAlert delivers the alert of a matched rule for the processed feed item to one target of
+// the rule. Failed notifications are retried with backoff by Temporal, except when the target
+// can never accept it, which fails with ErrTypeAlertRejected. Rules removed or disabled since
+// matching are skipped.
+//
+// @param cfg - Dependencies of the activity
+// @return A function that notifies a target of an alert rule about a feed item
+// @author Thomas De Meyer
+func NotifyAlert(cfg AlertConfig) func(ctx context.Context, n alert.Notification, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, n alert.Notification, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+
+		id, err := primitive.ObjectIDFromHex(n.RuleID)
+		if err != nil {
+			return temporal.NewNonRetryableApplicationError("invalid alert rule ID", ErrTypeAlertRejected, err)
+		}
+		rule, err := cfg.Store.Get(ctx, id)
+		if errors.Is(err, alert.ErrRuleNotFound) || err == nil && (rule.Disabled || n.Target >= len(rule.Targets)) {
+			logger.Info("Alert rule or target removed or disabled, skipping notification", "rule", n.RuleID, "target", n.Target, "id", feedItemDoc.ID.Hex())
+			return nil
+		}
+		if err != nil {
+			logger.Error("Failed to read alert rule", "err", err, "rule", n.RuleID)
+			return err
+		}
+
+		doc, err := cfg.Items.Get(ctx, feedItemDoc.ID)
+		if err != nil {
+			logger.Error("Failed to read feed item", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		target := rule.Targets[n.Target]
+		matchedAt := time.Now()
+		for _, m := range doc.Alerts {
+			if m.RuleID == rule.ID {
+				matchedAt = m.MatchedAt
+			}
+		}
+
+		notifyErr := cfg.Notifiers.Notify(ctx, target, alert.Alert{Rule: rule, Item: doc, MatchedAt: matchedAt})
+		if notifyErr == nil {
+			recordAlertNotification(ctx, target.Type, outcomeDelivered)
+			logger.Info("Sent alert", "rule", rule.Name, "target", target.String(), "id", doc.ID.Hex())
+			return nil
+		}
+
+		var statusErr *webhook.StatusError
+		if errors.Is(notifyErr, alert.ErrInvalidTarget) || errors.Is(notifyErr, webhook.ErrInvalidFormat) ||
+			errors.As(notifyErr, &statusErr) && !statusErr.Retryable() {
+			recordAlertNotification(ctx, target.Type, outcomeRejected)
+			logger.Error("Alert target rejected notification", "err", notifyErr, "rule", rule.Name, "target", target.String(), "id", doc.ID.Hex())
+			return temporal.NewNonRetryableApplicationError(notifyErr.Error(), ErrTypeAlertRejected, notifyErr)
+		}
+		recordAlertNotification(ctx, target.Type, outcomeFailed)
+		logger.Warn("Failed to send alert", "err", notifyErr, "rule", rule.Name, "target", target.String(), "id", doc.ID.Hex(), "attempt", activity.GetInfo(ctx).Attempt)
+		return notifyErr
+	}
+}
+
+func recordAlertNotification(ctx context.Context, targetType, outcome string) {
+	alertNotificationCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("target", targetType),
+		attribute.String("outcome", outcome),
+	)))
+}
@@ -0,0 +1,185 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
+	"github.com/demeyerthom/feeds-aggregator/internal/release"
+	"github.com/demeyerthom/feeds-aggregator/internal/tokens"
+	"github.com/demeyerthom/feeds-aggregator/internal/usage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+)
+
+var extractReleaseOutcomeCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	extractReleaseOutcomeCounter, _ = meter.Int64Counter(
+		"feeds.extract_release.responses",
+		metric.WithDescription("Number of release extraction LLM responses by outcome (first_pass, repaired, failed)"),
+		metric.WithUnit("{response}"),
+	)
+}
+
+// ExtractReleaseConfig holds the dependencies and settings of the ExtractRelease activity
+type ExtractReleaseConfig struct {
+	// Collection is the MongoDB collection for updating feed item documents
+	Collection *mongo.Collection
+	// LLM is the LLM client, typically a failover chain of providers
+	LLM llm.LLM
+	// Tracker records token metrics, estimates cost and enforces the daily budget
+	Tracker *usage.Tracker
+	// Prompts are the prompt templates used to build the LLM requests
+	Prompts *prompt.Templates
+	// DataDir is the directory where HTML files are stored
+	DataDir string
+	// TextLimit is the maximum number of characters to extract from HTML content
+	TextLimit int
+	// MaxRepairs is the maximum number of repair attempts for invalid LLM responses
+	MaxRepairs int
+	// ContextSize is the context window size of the model in tokens
+	ContextSize int
+}
+
+// parseReleaseResponse decodes and validates the raw LLM output into a release.
+// The returned error describes the problem in terms the LLM can act upon during a repair attempt.
+func parseReleaseResponse(content string, feedItemDoc internal.FeedItemDocument) (internal.Release, error) {
+	if content == "" {
+		return internal.Release{}, ErrEmptyResponse
+	}
+	var raw release.Raw
+	if err := json.Unmarshal([]byte(content), &raw); err != nil {
+		return internal.Release{}, fmt.Errorf("response is not a valid JSON object: %w", err)
+	}
+	return release.Normalize(raw, feedItemDoc.PublishedAt)
+}
+
+// ExtractRelease reads the release notes of an item from a release feed and asks the LLM for the
+// product, version, release date, breaking changes, security fixes, deprecations and highlights.
+// The result is validated, with the version parsed as a semantic version so releases can be
+// queried by version range, and saved as the release sub-document. Invalid responses are repaired
+// up to MaxRepairs times like in ProcessContent.
+//
+// Release notes are rarely longer than the context window, so oversized notes are truncated rather
+// than summarized in chunks. Token usage is added to the usage already recorded on the document.
+// When the daily budget is exhausted the activity fails with ErrTypeBudgetExceeded without calling
+// the LLM.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that extracts the release of a feed item document
+// @author Thomas De Meyer
+func ExtractRelease(cfg ExtractReleaseConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+
+		articleText, err := readArticleText(ctx, cfg.DataDir, cfg.TextLimit, feedItemDoc)
+		if err != nil {
+			return err
+		}
+
+		exceeded, resetAt, err := cfg.Tracker.Exceeded(ctx)
+		if err != nil {
+			logger.Warn("Failed to check daily LLM budget", "err", err)
+		} else if exceeded {
+			logger.Warn("Daily LLM budget exceeded, not extracting release", "id", feedItemDoc.ID.Hex(), "resetAt", resetAt)
+			return newBudgetExceededError(resetAt)
+		}
+
+		data := prompt.ReleaseNotesData{Feed: feedItemDoc.Feed, Title: feedItemDoc.Title, URL: feedItemDoc.Link}
+		emptyMessages, err := renderMessages(cfg.Prompts, prompt.ReleaseNotesSystemTemplate, prompt.ReleaseNotesTemplate, data)
+		if err != nil {
+			logger.Error("Failed to render release notes prompt", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+		budget := max(cfg.ContextSize-estimateMessages(emptyMessages)-responseTokenReserve, minChunkTokens)
+		if tokens.Estimate(articleText) > budget {
+			logger.Warn("Release notes exceed context window, truncating", "id", feedItemDoc.ID.Hex(), "estimatedTokens", tokens.Estimate(articleText), "budget", budget)
+			data.Content = tokens.Truncate(articleText, budget)
+		} else {
+			data.Content = articleText
+		}
+		messages, err := renderMessages(cfg.Prompts, prompt.ReleaseNotesSystemTemplate, prompt.ReleaseNotesTemplate, data)
+		if err != nil {
+			logger.Error("Failed to render release notes prompt", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		var totalUsage internal.TokenUsage
+		var result internal.Release
+		for attempt := 0; ; attempt++ {
+			resp, err := cfg.LLM.Complete(ctx, messages)
+			if err != nil {
+				logger.Error("Failed to extract release with LLM", "err", err, "id", feedItemDoc.ID.Hex(), "attempt", attempt)
+				return err
+			}
+			cost, err := cfg.Tracker.Record(ctx, feedItemDoc.Feed, resp)
+			if err != nil {
+				logger.Warn("Failed to record LLM spend", "err", err, "id", feedItemDoc.ID.Hex())
+			}
+			totalUsage.PromptTokens += resp.Usage.PromptTokens
+			totalUsage.CompletionTokens += resp.Usage.CompletionTokens
+			totalUsage.EstimatedCost += cost
+
+			result, err = parseReleaseResponse(resp.Content, feedItemDoc)
+			if err == nil {
+				outcome := outcomeFirstPass
+				if attempt > 0 {
+					outcome = outcomeRepaired
+				}
+				recordExtractReleaseOutcome(ctx, resp.Model, outcome)
+				break
+			}
+
+			if attempt >= cfg.MaxRepairs {
+				logger.Error("Invalid release extraction after repair attempts", "err", err, "id", feedItemDoc.ID.Hex(), "attempts", attempt, "response", resp.Content)
+				recordExtractReleaseOutcome(ctx, resp.Model, outcomeFailed)
+				return err
+			}
+
+			logger.Warn("Invalid release extraction, requesting repair", "err", err, "id", feedItemDoc.ID.Hex(), "attempt", attempt, "response", resp.Content)
+			repairText, renderErr := cfg.Prompts.Render(prompt.ReleaseNotesRepairTemplate, prompt.RepairData{Error: err.Error()})
+			if renderErr != nil {
+				logger.Error("Failed to render repair prompt", "err", renderErr, "id", feedItemDoc.ID.Hex())
+				return renderErr
+			}
+			messages = append(messages,
+				llm.AssistantMessage(resp.Content),
+				llm.UserMessage(repairText),
+			)
+		}
+
+		update := bson.M{
+			"$set": bson.M{"release": result},
+			"$inc": bson.M{
+				"usage.prompt_tokens":     totalUsage.PromptTokens,
+				"usage.completion_tokens": totalUsage.CompletionTokens,
+				"usage.estimated_cost":    totalUsage.EstimatedCost,
+			},
+		}
+		if _, err := cfg.Collection.UpdateOne(ctx, bson.M{"_id": feedItemDoc.ID}, update); err != nil {
+			logger.Error("Failed to save release", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		logger.Info("Saved release", "id", feedItemDoc.ID.Hex(), "product", result.Product, "version", result.Version.String(),
+			"breakingChanges", len(result.BreakingChanges), "securityFixes", len(result.SecurityFixes), "deprecations", len(result.Deprecations))
+		return nil
+	}
+}
+
+func recordExtractReleaseOutcome(ctx context.Context, model, outcome string) {
+	extractReleaseOutcomeCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("llm.model", model),
+		attribute.String("outcome", outcome),
+	)))
+}
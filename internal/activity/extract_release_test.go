@@ -0,0 +1,38 @@
+package activity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+)
+
+func TestParseReleaseResponse(t *testing.T) {
+	published := time.Date(2024, 6, 26, 9, 0, 0, 0, time.UTC)
+	doc := internal.FeedItemDocument{PublishedAt: &published}
+
+	r, err := parseReleaseResponse(`{"product": "Terraform", "version": "v1.9.0", "releaseDate": "", "breakingChanges": ["Removed -lock"], "securityFixes": [], "deprecations": [], "highlights": []}`, doc)
+	if err != nil {
+		t.Fatalf("parseReleaseResponse() error = %v", err)
+	}
+	if r.Version != (semver.Version{Major: 1, Minor: 9}) || len(r.BreakingChanges) != 1 {
+		t.Errorf("unexpected release: %+v", r)
+	}
+	if r.ReleaseDate == nil || !r.ReleaseDate.Equal(published) {
+		t.Errorf("release date = %v, want published date", r.ReleaseDate)
+	}
+}
+
+func TestParseReleaseResponse_Invalid(t *testing.T) {
+	if _, err := parseReleaseResponse("", internal.FeedItemDocument{}); !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("empty response: error = %v, want ErrEmptyResponse", err)
+	}
+	if _, err := parseReleaseResponse("not json", internal.FeedItemDocument{}); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	if _, err := parseReleaseResponse(`{"product": "Go", "version": "latest"}`, internal.FeedItemDocument{}); !errors.Is(err, semver.ErrInvalid) {
+		t.Errorf("invalid version: error = %v, want semver.ErrInvalid", err)
+	}
+}
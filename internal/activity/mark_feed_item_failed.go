@@ -0,0 +1,31 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.temporal.io/sdk/activity"
+)
+
+// MarkFeedItemFailed sets the status of a feed item document to failed, recording why, so items
+// that could not be fetched or processed can be told apart from items still being ingested.
+//
+// @param c - The MongoDB collection of feed item documents
+// @return A function that marks a feed item document as failed with a reason
+// @author Thomas De Meyer
+func MarkFeedItemFailed(c *mongo.Collection) func(ctx context.Context, feedItemDoc internal.FeedItemDocument, reason string) error {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument, reason string) error {
+		logger := activity.GetLogger(ctx)
+
+		update := bson.M{"$set": bson.M{"status": internal.StatusFailed, "error": reason}}
+		if _, err := c.UpdateOne(ctx, bson.M{"_id": feedItemDoc.ID}, update); err != nil {
+			logger.Error("Failed to mark feed item as failed", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		logger.Info("Marked feed item as failed", "id", feedItemDoc.ID.Hex(), "reason", reason)
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// ErrTypeWebhookRejected is the application error type of deliveries rejected by the webhook with
+// a client error; retrying them would be rejected again.
+const ErrTypeWebhookRejected = "WebhookRejected"
+
+// errTypeInvalidWebhook is the application error type of deliveries that cannot be built
+const errTypeInvalidWebhook = "InvalidWebhook"
+
+const (
+	outcomeDelivered = "delivered"
+	outcomeRejected  = "rejected"
+)
+
+var webhookDeliveryCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	webhookDeliveryCounter, _ = meter.Int64Counter(
+		"feeds.webhook.deliveries",
+		metric.WithDescription("Number of webhook delivery attempts by format and outcome (delivered, failed, rejected)"),
+		metric.WithUnit("{delivery}"),
+	)
+}
+
+// WebhookConfig holds the dependencies of the webhook activities
+type WebhookConfig struct {
+	// Items reads the processed feed item
+	Items *feeditem.Store
+	// Store holds the subscriptions and the delivery log
+	Store *webhook.Store
+	// Client posts the payloads
+	Client *webhook.Client
+}
+
+// NotifyWebhooks returns the IDs of the enabled webhook subscriptions whose filter matches the
+// processed feed item. The item is read again as the document passed between activities does not
+// carry the processing results.
+//
+// @param cfg - Dependencies of the activity
+// @return A function that returns the IDs of the subscriptions to deliver a feed item to
+// @author Thomas De Meyer
+func NotifyWebhooks(cfg WebhookConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) ([]string, error) {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) ([]string, error) {
+		logger := activity.GetLogger(ctx)
+
+		subscriptions, err := cfg.Store.Enabled(ctx)
+		if err != nil {
+			logger.Error("Failed to list webhook subscriptions", "err", err)
+			return nil, err
+		}
+		if len(subscriptions) == 0 {
+			return nil, nil
+		}
+
+		doc, err := cfg.Items.Get(ctx, feedItemDoc.ID)
+		if err != nil {
+			logger.Error("Failed to read feed item", "err", err, "id", feedItemDoc.ID.Hex())
+			return nil, err
+		}
+
+		var ids []string
+		for _, sub := range subscriptions {
+			if sub.Filter.Matches(doc) {
+				ids = append(ids, sub.ID.Hex())
+			}
+		}
+
+		logger.Info("Matched webhook subscriptions", "id", feedItemDoc.ID.Hex(), "subscriptions", len(subscriptions), "matched", len(ids))
+		return ids, nil
+	}
+}
+
+// DeliverWebhook posts the processed feed item to a webhook subscription in the format of the
+// subscription and records the attempt in the delivery log. Failed deliveries are retried with
+// backoff by Temporal, except when the webhook rejects the request with a client error, which
+// fails with ErrTypeWebhookRejected. Subscriptions removed or disabled since matching are skipped.
+//
+// @param cfg - Dependencies of the activity
+// @return A function that delivers a feed item to a webhook subscription
+// @author Thomas De Meyer
+func DeliverWebhook(cfg WebhookConfig) func(ctx context.Context, subscriptionID string, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, subscriptionID string, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+
+		id, err := primitive.ObjectIDFromHex(subscriptionID)
+		if err != nil {
+			return temporal.NewNonRetryableApplicationError("invalid subscription ID", errTypeInvalidWebhook, err)
+		}
+		sub, err := cfg.Store.Get(ctx, id)
+		if errors.Is(err, webhook.ErrSubscriptionNotFound) || err == nil && sub.Disabled {
+			logger.Info("Webhook subscription removed or disabled, skipping delivery", "subscription", subscriptionID, "id", feedItemDoc.ID.Hex())
+			return nil
+		}
+		if err != nil {
+			logger.Error("Failed to read webhook subscription", "err", err, "subscription", subscriptionID)
+			return err
+		}
+
+		doc, err := cfg.Items.Get(ctx, feedItemDoc.ID)
+		if err != nil {
+			logger.Error("Failed to read feed item", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		deliveryID := webhook.DeliveryID(sub.ID, doc.ID)
+		now := time.Now()
+		status, sendErr := cfg.Client.Send(ctx, sub, webhook.NewEvent(deliveryID, doc, now), now)
+
+		delivery := webhook.Delivery{
+			DeliveryID:     deliveryID,
+			SubscriptionID: sub.ID,
+			ItemID:         doc.ID,
+			Link:           doc.Link,
+			Attempt:        activity.GetInfo(ctx).Attempt,
+			Success:        sendErr == nil,
+			StatusCode:     status,
+			Duration:       time.Since(now),
+			CreatedAt:      now,
+		}
+		if sendErr != nil {
+			delivery.Error = sendErr.Error()
+		}
+		if err := cfg.Store.LogDelivery(ctx, delivery); err != nil {
+			logger.Warn("Failed to log webhook delivery", "err", err, "subscription", subscriptionID)
+		}
+
+		if sendErr == nil {
+			recordWebhookDelivery(ctx, sub.Format, outcomeDelivered)
+			logger.Info("Delivered webhook", "subscription", subscriptionID, "id", doc.ID.Hex(), "status", status)
+			return nil
+		}
+
+		if errors.Is(sendErr, webhook.ErrInvalidFormat) {
+			logger.Error("Invalid webhook subscription format", "err", sendErr, "subscription", subscriptionID)
+			return temporal.NewNonRetryableApplicationError(sendErr.Error(), errTypeInvalidWebhook, sendErr)
+		}
+		var statusErr *webhook.StatusError
+		if errors.As(sendErr, &statusErr) && !statusErr.Retryable() {
+			recordWebhookDelivery(ctx, sub.Format, outcomeRejected)
+			logger.Error("Webhook rejected delivery", "err", sendErr, "subscription", subscriptionID, "id", doc.ID.Hex())
+			return temporal.NewNonRetryableApplicationError(sendErr.Error(), ErrTypeWebhookRejected, sendErr)
+		}
+		recordWebhookDelivery(ctx, sub.Format, outcomeFailed)
+		logger.Warn("Failed to deliver webhook", "err", sendErr, "subscription", subscriptionID, "id", doc.ID.Hex(), "attempt", delivery.Attempt)
+		return sendErr
+	}
+}
+
+func recordWebhookDelivery(ctx context.Context, format webhook.Format, outcome string) {
+	webhookDeliveryCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("format", string(format)),
+		attribute.String("outcome", outcome),
+	)))
+}
@@ -2,104 +2,641 @@ package activity
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"os"
-	"path/filepath"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/cache"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
 	textextractor "github.com/demeyerthom/feeds-aggregator/internal/html"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/language"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
 	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
-	"github.com/openai/openai-go/v3"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+	"github.com/demeyerthom/feeds-aggregator/internal/tokens"
+	"github.com/demeyerthom/feeds-aggregator/internal/usage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 )
 
 // ErrInvalidCategoryCount is returned when the number of categories is not between 1 and 5
 var ErrInvalidCategoryCount = errors.New("categories must be between 1 and 5")
 
+// ErrEmptyResponse is returned when the LLM returns no choices or an empty message
+var ErrEmptyResponse = errors.New("response was empty")
+
+// ErrUnknownCategories is returned in strict mode when the LLM uses categories outside the taxonomy
+var ErrUnknownCategories = errors.New("categories must be chosen from the list")
+
+// ErrTypeBudgetExceeded is the application error type returned when the daily LLM budget is exhausted
+const ErrTypeBudgetExceeded = "BudgetExceeded"
+
+// Results recorded on the LLM cache counter
+const (
+	cacheResultHit    = "hit"
+	cacheResultMiss   = "miss"
+	cacheResultBypass = "bypass"
+)
+
+// Outcomes recorded on the process content response counter
+const (
+	outcomeFirstPass = "first_pass"
+	outcomeRepaired  = "repaired"
+	outcomeFailed    = "failed"
+)
+
+const (
+	// responseTokenReserve is the number of context tokens kept free for the LLM response
+	responseTokenReserve = 1024
+	// minChunkTokens is the smallest chunk size used when the context window is very small
+	minChunkTokens = 256
+	// maxReduceRounds bounds how many times chunk summaries are summarized again
+	maxReduceRounds = 3
+)
+
+var (
+	processContentOutcomeCounter metric.Int64Counter
+	chunkCountHistogram          metric.Int64Histogram
+	cacheCounter                 metric.Int64Counter
+	suspiciousCounter            metric.Int64Counter
+	languageCounter              metric.Int64Counter
+)
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	processContentOutcomeCounter, _ = meter.Int64Counter(
+		"feeds.process_content.responses",
+		metric.WithDescription("Number of processed LLM responses by outcome (first_pass, repaired, failed)"),
+		metric.WithUnit("{response}"),
+	)
+
+	chunkCountHistogram, _ = meter.Int64Histogram(
+		"feeds.process_content.chunk_count",
+		metric.WithDescription("Number of chunks oversized articles are split into before summarization"),
+		metric.WithUnit("{chunk}"),
+		metric.WithExplicitBucketBoundaries(2, 3, 5, 10, 20, 50, 100),
+	)
+
+	cacheCounter, _ = meter.Int64Counter(
+		"feeds.llm.cache",
+		metric.WithDescription("Number of LLM response cache lookups by result (hit, miss, bypass)"),
+		metric.WithUnit("{lookup}"),
+	)
+
+	suspiciousCounter, _ = meter.Int64Counter(
+		"feeds.process_content.suspicious",
+		metric.WithDescription("Number of articles flagged for instruction-like content by injection signal"),
+		metric.WithUnit("{article}"),
+	)
+
+	languageCounter, _ = meter.Int64Counter(
+		"feeds.process_content.languages",
+		metric.WithDescription("Number of processed articles by detected language, empty when unknown"),
+		metric.WithUnit("{article}"),
+	)
+}
+
 // processContentResponse represents the JSON response from the LLM
 type processContentResponse struct {
-	Summary    string   `json:"summary"`
-	Categories []string `json:"categories"`
+	Summary         string       `json:"summary"`
+	OriginalSummary string       `json:"originalSummary,omitempty"`
+	Categories      []string     `json:"categories"`
+	Entities        []entity.Raw `json:"entities,omitempty"`
+	Keywords        []string     `json:"keywords,omitempty"`
+}
+
+// parseProcessContentResponse decodes and validates the raw LLM output.
+// The returned error describes the problem in terms the LLM can act upon during a repair attempt.
+func parseProcessContentResponse(content string) (processContentResponse, error) {
+	var result processContentResponse
+	if content == "" {
+		return result, ErrEmptyResponse
+	}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return result, fmt.Errorf("response is not a valid JSON object: %w", err)
+	}
+	if len(result.Categories) == 0 || len(result.Categories) > 5 {
+		return result, fmt.Errorf("%w, got %d", ErrInvalidCategoryCount, len(result.Categories))
+	}
+	return result, nil
+}
+
+// normalizeCategories maps the categories of result to their canonical taxonomy names. In strict mode
+// categories outside the taxonomy are rejected with an error the LLM can act upon during a repair attempt.
+func normalizeCategories(tax *taxonomy.Taxonomy, result processContentResponse, strict bool) (processContentResponse, error) {
+	categories, unknown := tax.Normalize(result.Categories, strict)
+	if strict && len(unknown) > 0 {
+		return result, fmt.Errorf("%w, unknown categories: %s", ErrUnknownCategories, strings.Join(unknown, ", "))
+	}
+	if len(categories) == 0 {
+		return result, fmt.Errorf("%w, got 0", ErrInvalidCategoryCount)
+	}
+	result.Categories = categories
+	return result, nil
+}
+
+// normalizeEntities validates and normalizes the entities and keywords of result, adding the CVE IDs
+// found in the article text. Invalid entities are dropped rather than repaired, as they are not worth
+// another LLM call; they are returned so they can be logged.
+func normalizeEntities(result processContentResponse, articleText string) ([]entity.Entity, []string, []entity.Raw) {
+	entities, rejected := entity.Normalize(result.Entities)
+	entities = entity.Merge(entities, entity.FindCVEs(articleText)...)
+	return entities, entity.NormalizeKeywords(result.Keywords), rejected
+}
+
+// newBudgetExceededError creates a non-retryable error carrying the time the budget resets.
+func newBudgetExceededError(resetAt time.Time) error {
+	return temporal.NewNonRetryableApplicationError("daily LLM budget exceeded", ErrTypeBudgetExceeded, nil, resetAt)
+}
+
+// BudgetResetAt reports whether err was caused by an exhausted daily LLM budget, and if so when it resets.
+// The returned time is zero if the reset time could not be decoded.
+func BudgetResetAt(err error) (time.Time, bool) {
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) || appErr.Type() != ErrTypeBudgetExceeded {
+		return time.Time{}, false
+	}
+	var resetAt time.Time
+	if err := appErr.Details(&resetAt); err != nil {
+		return time.Time{}, true
+	}
+	return resetAt, true
+}
+
+// ProcessContentConfig holds the dependencies and settings of the ProcessContent activity
+type ProcessContentConfig struct {
+	// Collection is the MongoDB collection for updating feed item documents
+	Collection *mongo.Collection
+	// LLM is the LLM client, typically a failover chain of providers
+	LLM llm.LLM
+	// Tracker records token metrics, estimates cost and enforces the daily budget
+	Tracker *usage.Tracker
+	// Prompts are the prompt templates used to build the LLM requests
+	Prompts *prompt.Templates
+	// Taxonomy holds the categories offered to the LLM and used to normalize its output
+	Taxonomy *taxonomy.Store
+	// States refreshes the copies of the categories on the item states of users; nil skips it
+	States *itemstate.Store
+	// StrictCategories rejects categories outside the taxonomy instead of keeping them
+	StrictCategories bool
+	// SummaryLanguage is the ISO 639-1 code of the language summaries are written in; empty keeps
+	// the language of the article
+	SummaryLanguage string
+	// KeepOriginalSummary also stores a summary in the article language when it differs from SummaryLanguage
+	KeepOriginalSummary bool
+	// Cache stores results by content hash, model and prompt version; nil disables caching
+	Cache *cache.ResponseCache
+	// BypassCache skips cache lookups for every item, while still refreshing cached entries
+	BypassCache bool
+	// DataDir is the directory where HTML files are stored
+	DataDir string
+	// TextLimit is the maximum number of characters to extract from HTML content
+	TextLimit int
+	// SearchTextLimit is the maximum number of bytes of article text stored for full-text search; 0 disables storing it
+	SearchTextLimit int
+	// MaxRepairs is the maximum number of repair attempts for invalid LLM responses
+	MaxRepairs int
+	// ContextSize is the context window size of the model in tokens
+	ContextSize int
+}
+
+// cachedContent is the cache entry stored for a processed article
+type cachedContent struct {
+	Result        processContentResponse `json:"result"`
+	Provider      string                 `json:"provider"`
+	Model         string                 `json:"model"`
+	PromptVersion string                 `json:"promptVersion"`
 }
 
 // ProcessContent reads the fetched HTML content, sends it to the LLM for combined
 // summarization and categorization, and saves both to the MongoDB document in a single operation.
+// Articles that do not fit the model's context window are split on paragraph boundaries and
+// summarized chunk by chunk; the final summary and categories are produced from the chunk summaries.
+// When the LLM returns an invalid response, the previous answer and the validation error are sent
+// back to the model up to MaxRepairs times before the activity fails.
+//
+// The article is sent as delimited, untrusted data in the user message, separate from the system
+// instructions. Articles containing instruction-like text are flagged as suspicious on the document.
+//
+// Alongside the summary, the LLM extracts typed entities and keywords, which are validated and
+// normalized before they are stored; CVE IDs found in the article text are always included.
+//
+// The language of the article text is detected and stored on the document. Summaries are written
+// in SummaryLanguage, optionally keeping a second summary in the original language.
 //
-// @param c - MongoDB collection for updating feed item documents
-// @param client - OpenAI client for LLM calls
-// @param model - The model to use for LLM calls
-// @param dataDir - Directory where HTML files are stored
-// @param textLimit - Maximum characters to extract from HTML content
+// The article text is stored on the document alongside the summary, keeping the full-text search
+// index over title, summary and text up to date.
+//
+// Categories are offered from the taxonomy and the LLM output is normalized against canonical
+// names and aliases; in strict mode categories outside the taxonomy are repaired like other
+// invalid responses.
+//
+// Results are cached by a hash of the extracted text, the model, the prompt version and the
+// settings and categories rendered into the prompt, so syndicated or reprocessed articles do not
+// cost another LLM call unless the cache is bypassed, for every item or for a reprocessed one.
+// The provider and model that produced the result, the version of the prompt template and the
+// token usage of all calls are recorded on the document. When the daily budget is exhausted the activity fails with ErrTypeBudgetExceeded
+// without calling the LLM.
+//
+// @param cfg - Dependencies and settings of the activity
 // @return A function that processes a feed item document
 // @author Thomas De Meyer
-func ProcessContent(c *mongo.Collection, client openai.Client, model, dataDir string, textLimit int) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+func ProcessContent(cfg ProcessContentConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
 	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
 		logger := activity.GetLogger(ctx)
 
-		filename := filepath.Join(dataDir, feedItemDoc.ID.Hex()+".html")
-		htmlContent, err := os.ReadFile(filename)
+		articleText, err := readArticleText(ctx, cfg.DataDir, cfg.TextLimit, feedItemDoc)
 		if err != nil {
-			logger.Error("Failed to read HTML file for content processing", "err", err, "filename", filename)
 			return err
 		}
 
-		logger.Info("Read HTML file for content processing", "id", feedItemDoc.ID.Hex(), "size", len(htmlContent))
+		tax, err := cfg.Taxonomy.Load(ctx)
+		if err != nil {
+			logger.Error("Failed to load category taxonomy", "err", err)
+			return err
+		}
 
-		// Extract article text
-		extractText := textextractor.ExtractArticleText(textLimit)
-		articleText, ok := extractText(ctx, string(htmlContent))
-		if !ok || len(articleText) == 0 {
-			articleText = textextractor.StripHTMLToPlainText(string(htmlContent))
+		// Flag articles that try to steer the LLM; they are still processed, but marked for review
+		signals := prompt.DetectInjection(feedItemDoc.Title + "\n" + articleText)
+		if len(signals) > 0 {
+			logger.Warn("Article contains instruction-like text", "id", feedItemDoc.ID.Hex(), "link", feedItemDoc.Link, "signals", signals)
+			recordSuspicious(ctx, signals)
 		}
 
-		// Build combined prompt for summarization and categorization
-		promptText := prompt.BuildProcessContentPrompt(feedItemDoc.Title, feedItemDoc.Link, articleText)
+		articleLanguage := language.Detect(articleText)
+		recordLanguage(ctx, articleLanguage)
+		data := newProcessContentData(cfg, tax, feedItemDoc, articleLanguage)
+		summaryLanguage := cfg.SummaryLanguage
+		if summaryLanguage == "" {
+			summaryLanguage = articleLanguage
+		}
+		// The article text is stored with the summary, so the full-text index covers both
+		searchText := textextractor.Truncate(articleText, cfg.SearchTextLimit)
 
-		// Call LLM for combined processing
-		resp, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-			Model: openai.ChatModel(model),
-			Messages: []openai.ChatCompletionMessageParamUnion{
-				openai.UserMessage(promptText),
-			},
-		})
-		if err != nil {
-			logger.Error("Failed to process content with LLM", "err", err, "id", feedItemDoc.ID.Hex())
-			return err
+		// Serve syndicated and reprocessed articles from the cache. The summary language, strict mode
+		// and the offered categories change the response, so they are part of the key alongside the
+		// prompt version.
+		promptVersion := cfg.Prompts.CombinedVersion(prompt.ProcessContentSystemTemplate, prompt.ProcessContentTemplate)
+		cacheKey := cache.Key(articleText, cfg.LLM.Model(), cacheVariant(promptVersion, data))
+		if cfg.Cache != nil {
+			if cfg.BypassCache || feedItemDoc.BypassCache {
+				recordCacheResult(ctx, cacheResultBypass)
+			} else {
+				var cached cachedContent
+				found, err := cfg.Cache.Get(ctx, cacheKey, &cached)
+				if err != nil {
+					logger.Warn("Failed to read LLM response cache", "err", err, "id", feedItemDoc.ID.Hex())
+				}
+				if found {
+					// The taxonomy may have changed since the result was cached, so its categories are
+					// normalized again; a result left without valid categories is processed like a miss
+					result, err := normalizeCategories(tax, cached.Result, cfg.StrictCategories)
+					if err == nil {
+						recordCacheResult(ctx, cacheResultHit)
+						entities, keywords, _ := normalizeEntities(result, articleText)
+						logger.Info("Using cached content processing result", "id", feedItemDoc.ID.Hex(), "provider", cached.Provider, "model", cached.Model)
+						return saveProcessedContent(ctx, cfg.Collection, cfg.States, feedItemDoc, bson.M{
+							"summary":           result.Summary,
+							"original_summary":  result.OriginalSummary,
+							"language":          articleLanguage,
+							"text":              searchText,
+							"text_language":     feeditem.TextLanguage(articleLanguage),
+							"summary_language":  summaryLanguage,
+							"categories":        result.Categories,
+							"entities":          entities,
+							"keywords":          keywords,
+							"provider":          cached.Provider,
+							"model":             cached.Model,
+							"prompt_version":    cached.PromptVersion,
+							"cache_hit":         true,
+							"suspicious":        len(signals) > 0,
+							"injection_signals": signals,
+							"status":            internal.StatusProcessed,
+						})
+					}
+					logger.Info("Cached content processing result has no valid categories, processing again", "err", err, "id", feedItemDoc.ID.Hex())
+				}
+				recordCacheResult(ctx, cacheResultMiss)
+			}
 		}
 
-		llmResponse := resp.Choices[0].Message.Content
+		exceeded, resetAt, err := cfg.Tracker.Exceeded(ctx)
+		if err != nil {
+			logger.Warn("Failed to check daily LLM budget", "err", err)
+		} else if exceeded {
+			logger.Warn("Daily LLM budget exceeded, not processing content", "id", feedItemDoc.ID.Hex(), "resetAt", resetAt)
+			return newBudgetExceededError(resetAt)
+		}
 
-		logger.Info("Received LLM response", "id", feedItemDoc.ID.Hex(), "responseLength", len(llmResponse))
+		// Accumulate token usage over all LLM calls for this item
+		var totalUsage internal.TokenUsage
+		recordUsage := func(resp llm.Response) {
+			cost, err := cfg.Tracker.Record(ctx, feedItemDoc.Feed, resp)
+			if err != nil {
+				logger.Warn("Failed to record LLM spend", "err", err, "id", feedItemDoc.ID.Hex())
+			}
+			totalUsage.PromptTokens += resp.Usage.PromptTokens
+			totalUsage.CompletionTokens += resp.Usage.CompletionTokens
+			totalUsage.EstimatedCost += cost
+		}
 
-		// Parse JSON response to extract summary and categories
-		var result processContentResponse
-		if err := json.Unmarshal([]byte(llmResponse), &result); err != nil {
-			logger.Error("Failed to parse LLM JSON response", "err", err, "id", feedItemDoc.ID.Hex(), "response", llmResponse)
+		result, resp, err := generateContent(ctx, cfg, tax, feedItemDoc, data, articleText, recordUsage)
+		if err != nil {
 			return err
 		}
 
-		// Validate we have 1-5 categories
-		if len(result.Categories) == 0 || len(result.Categories) > 5 {
-			logger.Error("Invalid number of categories", "count", len(result.Categories), "id", feedItemDoc.ID.Hex())
-			return ErrInvalidCategoryCount
+		entities, keywords, rejected := normalizeEntities(result, articleText)
+		if len(rejected) > 0 {
+			logger.Info("Dropped invalid entities from LLM response", "id", feedItemDoc.ID.Hex(), "rejected", rejected)
+		}
+
+		if data.KeepOriginal && result.OriginalSummary == "" {
+			logger.Warn("LLM response has no summary in the original language", "id", feedItemDoc.ID.Hex(), "language", articleLanguage)
 		}
 
-		logger.Info("Parsed content processing result", "id", feedItemDoc.ID.Hex(), "summaryLength", len(result.Summary), "categories", result.Categories)
+		logger.Info("Parsed content processing result", "id", feedItemDoc.ID.Hex(), "summaryLength", len(result.Summary), "categories", result.Categories,
+			"entities", len(entities), "keywords", keywords, "language", articleLanguage, "summaryLanguage", summaryLanguage)
 
-		// Update MongoDB document with both summary and categories in a single operation
-		filter := bson.M{"_id": feedItemDoc.ID}
-		update := bson.M{"$set": bson.M{"summary": result.Summary, "categories": result.Categories}}
+		// Lookups are keyed by the primary model, so results of a failover provider are not cached
+		if cfg.Cache != nil && resp.Model == cfg.LLM.Model() {
+			if err := cfg.Cache.Set(ctx, cacheKey, cachedContent{Result: result, Provider: resp.Provider, Model: resp.Model, PromptVersion: promptVersion}); err != nil {
+				logger.Warn("Failed to write LLM response cache", "err", err, "id", feedItemDoc.ID.Hex())
+			}
+		}
 
-		_, err = c.UpdateOne(ctx, filter, update)
+		err = saveProcessedContent(ctx, cfg.Collection, cfg.States, feedItemDoc, bson.M{
+			"summary":           result.Summary,
+			"original_summary":  result.OriginalSummary,
+			"language":          articleLanguage,
+			"text":              searchText,
+			"text_language":     feeditem.TextLanguage(articleLanguage),
+			"summary_language":  summaryLanguage,
+			"categories":        result.Categories,
+			"entities":          entities,
+			"keywords":          keywords,
+			"provider":          resp.Provider,
+			"model":             resp.Model,
+			"prompt_version":    promptVersion,
+			"usage":             totalUsage,
+			"cache_hit":         false,
+			"suspicious":        len(signals) > 0,
+			"injection_signals": signals,
+			"status":            internal.StatusProcessed,
+		})
 		if err != nil {
-			logger.Error("Failed to update document with summary and categories", "err", err, "id", feedItemDoc.ID.Hex())
 			return err
 		}
 
-		logger.Info("Successfully saved summary and categories to document", "id", feedItemDoc.ID.Hex(), "link", feedItemDoc.Link, "categories", result.Categories)
+		logger.Info("Token usage for content processing", "id", feedItemDoc.ID.Hex(),
+			"promptTokens", totalUsage.PromptTokens, "completionTokens", totalUsage.CompletionTokens, "estimatedCost", totalUsage.EstimatedCost)
 		return nil
 	}
 }
+
+// generateContent asks the LLM for the summary and categories of articleText, reducing oversized
+// articles to chunk summaries first and repairing invalid responses in-activity.
+// It returns the parsed result together with the response that produced it.
+func generateContent(ctx context.Context, cfg ProcessContentConfig, tax *taxonomy.Taxonomy, feedItemDoc internal.FeedItemDocument, data prompt.ProcessContentData, articleText string, recordUsage func(llm.Response)) (processContentResponse, llm.Response, error) {
+	logger := activity.GetLogger(ctx)
+
+	// Reduce oversized articles to chunk summaries until they fit the context window
+	emptyMessages, err := renderMessages(cfg.Prompts, prompt.ProcessContentSystemTemplate, prompt.ProcessContentTemplate, data)
+	if err != nil {
+		logger.Error("Failed to render content processing prompt", "err", err, "id", feedItemDoc.ID.Hex())
+		return processContentResponse{}, llm.Response{}, err
+	}
+	budget := max(cfg.ContextSize-estimateMessages(emptyMessages)-responseTokenReserve, minChunkTokens)
+	for round := 0; tokens.Estimate(articleText) > budget; round++ {
+		if round >= maxReduceRounds {
+			logger.Warn("Article still exceeds context window after chunk summarization, truncating", "id", feedItemDoc.ID.Hex(), "estimatedTokens", tokens.Estimate(articleText), "budget", budget)
+			articleText = tokens.Truncate(articleText, budget)
+			break
+		}
+		logger.Info("Article exceeds context window, summarizing in chunks", "id", feedItemDoc.ID.Hex(), "estimatedTokens", tokens.Estimate(articleText), "budget", budget, "round", round)
+		articleText, err = summarizeChunks(ctx, cfg.LLM, cfg.Prompts, feedItemDoc.Title, articleText, cfg.ContextSize, recordUsage)
+		if err != nil {
+			logger.Error("Failed to summarize article chunks", "err", err, "id", feedItemDoc.ID.Hex())
+			return processContentResponse{}, llm.Response{}, err
+		}
+	}
+
+	// Build combined prompt for summarization and categorization
+	data.Content = articleText
+	messages, err := renderMessages(cfg.Prompts, prompt.ProcessContentSystemTemplate, prompt.ProcessContentTemplate, data)
+	if err != nil {
+		logger.Error("Failed to render content processing prompt", "err", err, "id", feedItemDoc.ID.Hex())
+		return processContentResponse{}, llm.Response{}, err
+	}
+
+	// Call LLM for combined processing, repairing invalid responses in-activity
+	for attempt := 0; ; attempt++ {
+		resp, err := cfg.LLM.Complete(ctx, messages)
+		if err != nil {
+			logger.Error("Failed to process content with LLM", "err", err, "id", feedItemDoc.ID.Hex(), "attempt", attempt)
+			return processContentResponse{}, llm.Response{}, err
+		}
+		recordUsage(resp)
+
+		llmResponse := resp.Content
+
+		logger.Info("Received LLM response", "id", feedItemDoc.ID.Hex(), "provider", resp.Provider, "model", resp.Model, "responseLength", len(llmResponse), "attempt", attempt)
+
+		result, err := parseProcessContentResponse(llmResponse)
+		if err == nil {
+			result, err = normalizeCategories(tax, result, cfg.StrictCategories)
+		}
+		if err == nil {
+			outcome := outcomeFirstPass
+			if attempt > 0 {
+				outcome = outcomeRepaired
+			}
+			recordProcessContentOutcome(ctx, resp.Model, outcome)
+			return result, resp, nil
+		}
+
+		if attempt >= cfg.MaxRepairs {
+			logger.Error("Invalid LLM response after repair attempts", "err", err, "id", feedItemDoc.ID.Hex(), "attempts", attempt, "response", llmResponse)
+			recordProcessContentOutcome(ctx, resp.Model, outcomeFailed)
+			return processContentResponse{}, resp, err
+		}
+
+		logger.Warn("Invalid LLM response, requesting repair", "err", err, "id", feedItemDoc.ID.Hex(), "attempt", attempt, "response", llmResponse)
+		repairText, renderErr := cfg.Prompts.Render(prompt.RepairTemplate, prompt.RepairData{Error: err.Error(), KeepOriginal: data.KeepOriginal})
+		if renderErr != nil {
+			logger.Error("Failed to render repair prompt", "err", renderErr, "id", feedItemDoc.ID.Hex())
+			return processContentResponse{}, resp, renderErr
+		}
+		messages = append(messages,
+			llm.AssistantMessage(llmResponse),
+			llm.UserMessage(repairText),
+		)
+	}
+}
+
+// newProcessContentData creates the prompt data for feedItemDoc without the article content. An
+// original-language summary is only requested when the article language is known and differs from
+// the summary language.
+func newProcessContentData(cfg ProcessContentConfig, tax *taxonomy.Taxonomy, feedItemDoc internal.FeedItemDocument, articleLanguage string) prompt.ProcessContentData {
+	data := prompt.ProcessContentData{
+		Title:            feedItemDoc.Title,
+		URL:              feedItemDoc.Link,
+		StrictCategories: cfg.StrictCategories,
+		SummaryLanguage:  language.Name(cfg.SummaryLanguage),
+		ArticleLanguage:  language.Name(articleLanguage),
+	}
+	data.KeepOriginal = cfg.KeepOriginalSummary && data.SummaryLanguage != "" && data.ArticleLanguage != "" && data.SummaryLanguage != data.ArticleLanguage
+	for _, c := range tax.Categories() {
+		data.Categories = append(data.Categories, prompt.Category{Name: c.Name, Description: c.Description})
+	}
+	return data
+}
+
+// cacheVariant extends the prompt version with the settings of data that change the response for
+// the same article text: the language settings, strict mode and the categories offered to the LLM.
+func cacheVariant(promptVersion string, data prompt.ProcessContentData) string {
+	variant := promptVersion + "/" + data.SummaryLanguage
+	if data.KeepOriginal {
+		variant += "+original"
+	}
+	if data.StrictCategories {
+		variant += "+strict"
+	}
+	return variant + "/" + categoriesVersion(data.Categories)
+}
+
+// categoriesVersion identifies the categories rendered into the prompt by a hash of their names and
+// descriptions, so a taxonomy change does not serve results categorized against the old one.
+func categoriesVersion(categories []prompt.Category) string {
+	h := sha256.New()
+	for _, c := range categories {
+		h.Write([]byte(c.Name))
+		h.Write([]byte{0})
+		h.Write([]byte(c.Description))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)[:6])
+}
+
+// saveProcessedContent updates the MongoDB document with the processing results in a single operation,
+// stamping the time it was processed, and refreshes the item states of an item processed again.
+func saveProcessedContent(ctx context.Context, c *mongo.Collection, states *itemstate.Store, feedItemDoc internal.FeedItemDocument, fields bson.M) error {
+	logger := activity.GetLogger(ctx)
+
+	fields["processed_at"] = time.Now()
+	filter := bson.M{"_id": feedItemDoc.ID}
+	update := bson.M{"$set": fields}
+
+	_, err := c.UpdateOne(ctx, filter, update)
+	if err != nil {
+		logger.Error("Failed to update document with summary and categories", "err", err, "id", feedItemDoc.ID.Hex())
+		return err
+	}
+	if states != nil {
+		if err := states.RefreshItem(ctx, feedItemDoc.ID); err != nil {
+			logger.Error("Failed to refresh item states with categories", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+	}
+
+	logger.Info("Successfully saved summary and categories to document", "id", feedItemDoc.ID.Hex(), "link", feedItemDoc.Link, "categories", fields["categories"])
+	return nil
+}
+
+// summarizeChunks splits text into chunks that fit the context window and summarizes each one,
+// returning the chunk summaries joined on paragraph boundaries.
+func summarizeChunks(ctx context.Context, client llm.LLM, prompts *prompt.Templates, title, text string, contextSize int, recordUsage func(llm.Response)) (string, error) {
+	emptyMessages, err := renderMessages(prompts, prompt.ChunkSummarySystemTemplate, prompt.ChunkSummaryTemplate, prompt.ChunkSummaryData{Title: title})
+	if err != nil {
+		return "", err
+	}
+	chunkBudget := max(contextSize-estimateMessages(emptyMessages)-responseTokenReserve, minChunkTokens)
+	chunks := tokens.Split(text, chunkBudget)
+	chunkCountHistogram.Record(ctx, int64(len(chunks)), metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("llm.model", client.Model()),
+	)))
+
+	summaries := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		messages, err := renderMessages(prompts, prompt.ChunkSummarySystemTemplate, prompt.ChunkSummaryTemplate, prompt.ChunkSummaryData{Title: title, Part: i + 1, Total: len(chunks), Content: chunk})
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Complete(ctx, messages)
+		if err != nil {
+			return "", err
+		}
+		recordUsage(resp)
+		if resp.Content == "" {
+			return "", fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), ErrEmptyResponse)
+		}
+		summaries = append(summaries, resp.Content)
+
+		// Heartbeat so long-running chunked articles are not mistaken for stuck activities
+		activity.RecordHeartbeat(ctx, i+1)
+	}
+
+	return strings.Join(summaries, "\n\n"), nil
+}
+
+// renderMessages renders the system and user templates with the same data into a system message
+// with the instructions and a user message carrying the untrusted article.
+func renderMessages(prompts *prompt.Templates, systemTemplate, userTemplate string, data any) ([]llm.Message, error) {
+	system, err := prompts.Render(systemTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	user, err := prompts.Render(userTemplate, data)
+	if err != nil {
+		return nil, err
+	}
+	return []llm.Message{llm.SystemMessage(system), llm.UserMessage(user)}, nil
+}
+
+// estimateMessages estimates the number of tokens of all messages together.
+func estimateMessages(messages []llm.Message) int {
+	total := 0
+	for _, m := range messages {
+		total += tokens.Estimate(m.Content)
+	}
+	return total
+}
+
+func recordProcessContentOutcome(ctx context.Context, model, outcome string) {
+	processContentOutcomeCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("llm.model", model),
+		attribute.String("outcome", outcome),
+	)))
+}
+
+func recordCacheResult(ctx context.Context, result string) {
+	cacheCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("result", result),
+	)))
+}
+
+func recordLanguage(ctx context.Context, lang string) {
+	languageCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("language", lang),
+	)))
+}
+
+func recordSuspicious(ctx context.Context, signals []string) {
+	for _, signal := range signals {
+		suspiciousCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+			attribute.String("signal", signal),
+		)))
+	}
+}
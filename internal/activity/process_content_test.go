@@ -0,0 +1,169 @@
+package activity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+)
+
+func TestParseProcessContentResponse_Valid(t *testing.T) {
+	result, err := parseProcessContentResponse(`{"summary": "A summary.", "categories": ["Security", "Go"]}`)
+	if err != nil {
+		t.Fatalf("expected valid response, got error: %v", err)
+	}
+	if result.Summary != "A summary." {
+		t.Errorf("unexpected summary: %q", result.Summary)
+	}
+	if len(result.Categories) != 2 {
+		t.Errorf("expected 2 categories, got %d", len(result.Categories))
+	}
+}
+
+func TestParseProcessContentResponse_Empty(t *testing.T) {
+	_, err := parseProcessContentResponse("")
+	if !errors.Is(err, ErrEmptyResponse) {
+		t.Fatalf("expected ErrEmptyResponse, got %v", err)
+	}
+}
+
+func TestParseProcessContentResponse_InvalidJSON(t *testing.T) {
+	_, err := parseProcessContentResponse(`Here is the summary: {"summary": "x"`)
+	if err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+func TestParseProcessContentResponse_CategoryCount(t *testing.T) {
+	tests := []string{
+		`{"summary": "x", "categories": []}`,
+		`{"summary": "x", "categories": ["a", "b", "c", "d", "e", "f"]}`,
+	}
+	for _, tt := range tests {
+		_, err := parseProcessContentResponse(tt)
+		if !errors.Is(err, ErrInvalidCategoryCount) {
+			t.Errorf("expected ErrInvalidCategoryCount for %s, got %v", tt, err)
+		}
+	}
+}
+
+func TestBudgetResetAt(t *testing.T) {
+	resetAt := time.Date(2026, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	got, ok := BudgetResetAt(fmt.Errorf("activity failed: %w", newBudgetExceededError(resetAt)))
+	if !ok {
+		t.Fatal("expected budget exceeded error to be detected")
+	}
+	if !got.Equal(resetAt) {
+		t.Errorf("expected reset at %s, got %s", resetAt, got)
+	}
+
+	if _, ok := BudgetResetAt(errors.New("other")); ok {
+		t.Error("expected other errors not to be detected as budget exceeded")
+	}
+	if _, ok := BudgetResetAt(nil); ok {
+		t.Error("expected nil error not to be detected as budget exceeded")
+	}
+}
+
+func TestNormalizeCategories(t *testing.T) {
+	tax := taxonomy.New(taxonomy.Defaults)
+
+	result, err := normalizeCategories(tax, processContentResponse{Summary: "s", Categories: []string{"Dev Ops", "security", "Rust"}}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"DevOps & Infrastructure", "Security", "Rust"}
+	if !slices.Equal(result.Categories, want) {
+		t.Errorf("expected %v, got %v", want, result.Categories)
+	}
+}
+
+func TestNormalizeCategories_StrictRejectsUnknown(t *testing.T) {
+	tax := taxonomy.New(taxonomy.Defaults)
+
+	_, err := normalizeCategories(tax, processContentResponse{Summary: "s", Categories: []string{"Security", "Rust"}}, true)
+	if !errors.Is(err, ErrUnknownCategories) {
+		t.Fatalf("expected ErrUnknownCategories, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Rust") {
+		t.Errorf("expected error to name the unknown category, got %v", err)
+	}
+}
+
+func TestParseProcessContentResponse_EntitiesAndKeywords(t *testing.T) {
+	result, err := parseProcessContentResponse(`{"summary": "s", "categories": ["Security"], "entities": [{"type": "version", "name": "v1.8", "product": "Terraform"}], "keywords": ["IaC"]}`)
+	if err != nil {
+		t.Fatalf("expected valid response, got error: %v", err)
+	}
+	if len(result.Entities) != 1 || result.Entities[0].Product != "Terraform" || len(result.Keywords) != 1 {
+		t.Errorf("expected entities and keywords to be decoded, got %+v", result)
+	}
+}
+
+func TestNormalizeEntities(t *testing.T) {
+	result := processContentResponse{
+		Entities: []entity.Raw{{Type: "cve", Name: "CVE-2024-3094"}, {Type: "version", Name: "next"}},
+		Keywords: []string{"XZ Utils", "xz utils", "Backdoor"},
+	}
+
+	entities, keywords, rejected := normalizeEntities(result, "The backdoor (CVE-2024-3094) is related to CVE-2024-47176.")
+	if len(entities) != 2 || entities[0].Name != "CVE-2024-3094" || entities[1].Name != "CVE-2024-47176" {
+		t.Errorf("expected LLM and text CVEs without duplicates, got %+v", entities)
+	}
+	if !slices.Equal(keywords, []string{"xz utils", "backdoor"}) {
+		t.Errorf("unexpected keywords %v", keywords)
+	}
+	if len(rejected) != 1 || rejected[0].Name != "next" {
+		t.Errorf("expected the invalid version to be rejected, got %+v", rejected)
+	}
+}
+
+func TestNewProcessContentData_Languages(t *testing.T) {
+	tax := taxonomy.New(taxonomy.Defaults)
+	cfg := ProcessContentConfig{SummaryLanguage: "en", KeepOriginalSummary: true}
+
+	data := newProcessContentData(cfg, tax, internal.FeedItemDocument{}, "nl")
+	if data.SummaryLanguage != "English" || data.ArticleLanguage != "Dutch" || !data.KeepOriginal {
+		t.Errorf("Dutch article: got %q, %q, keep original %v", data.SummaryLanguage, data.ArticleLanguage, data.KeepOriginal)
+	}
+
+	// No second summary when the article is already in the summary language or its language is unknown
+	for _, lang := range []string{"en", ""} {
+		if data := newProcessContentData(cfg, tax, internal.FeedItemDocument{}, lang); data.KeepOriginal {
+			t.Errorf("article language %q should not keep the original summary", lang)
+		}
+	}
+
+	cfg.KeepOriginalSummary = false
+	if data := newProcessContentData(cfg, tax, internal.FeedItemDocument{}, "nl"); data.KeepOriginal {
+		t.Error("original summary should only be kept when enabled")
+	}
+}
+
+func TestCacheVariant(t *testing.T) {
+	tax := taxonomy.New(taxonomy.Defaults)
+	english := newProcessContentData(ProcessContentConfig{SummaryLanguage: "en"}, tax, internal.FeedItemDocument{}, "de")
+	german := newProcessContentData(ProcessContentConfig{SummaryLanguage: "de"}, tax, internal.FeedItemDocument{}, "de")
+	withOriginal := newProcessContentData(ProcessContentConfig{SummaryLanguage: "en", KeepOriginalSummary: true}, tax, internal.FeedItemDocument{}, "de")
+	strict := newProcessContentData(ProcessContentConfig{SummaryLanguage: "en", StrictCategories: true}, tax, internal.FeedItemDocument{}, "de")
+	otherTaxonomy := newProcessContentData(ProcessContentConfig{SummaryLanguage: "en"}, taxonomy.New(taxonomy.Defaults[1:]), internal.FeedItemDocument{}, "de")
+
+	variants := map[string]bool{}
+	for _, data := range []prompt.ProcessContentData{english, german, withOriginal, strict, otherTaxonomy} {
+		variants[cacheVariant("5+2", data)] = true
+	}
+	if len(variants) != 5 {
+		t.Errorf("language settings, strict mode and taxonomy should produce distinct cache variants, got %v", variants)
+	}
+	if again := newProcessContentData(ProcessContentConfig{SummaryLanguage: "en"}, taxonomy.New(taxonomy.Defaults), internal.FeedItemDocument{}, "de"); cacheVariant("5+2", again) != cacheVariant("5+2", english) {
+		t.Error("the same settings and taxonomy should produce the same cache variant")
+	}
+}
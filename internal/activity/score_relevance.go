@@ -0,0 +1,147 @@
+package activity
+
+import (
+	"context"
+	"slices"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
+	"github.com/demeyerthom/feeds-aggregator/internal/relevance"
+	"github.com/demeyerthom/feeds-aggregator/internal/usage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+)
+
+var relevanceScoreHistogram metric.Float64Histogram
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	relevanceScoreHistogram, _ = meter.Float64Histogram(
+		"feeds.relevance.score",
+		metric.WithDescription("Relevance scores of processed feed items by best matching profile"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9),
+	)
+}
+
+// ScoreRelevanceConfig holds the dependencies and settings of the ScoreRelevance activity
+type ScoreRelevanceConfig struct {
+	// Collection is the MongoDB collection for reading and updating feed item documents
+	Collection *mongo.Collection
+	// Profiles are the interest profiles of the teams
+	Profiles []relevance.Profile
+	// Judge enables the LLM judgement of items against the statements of the profiles
+	Judge bool
+	// LLMWeight is the weight of the LLM judgement in the score of a profile, between 0 and 1
+	LLMWeight float64
+	// LLM is the LLM client, typically a failover chain of providers
+	LLM llm.LLM
+	// Tracker records token metrics, estimates cost and enforces the daily budget
+	Tracker *usage.Tracker
+	// Prompts are the prompt templates used to build the LLM requests
+	Prompts *prompt.Templates
+}
+
+// ScoreRelevance scores how relevant the processed feed item is to the interest profiles of the
+// teams and saves the relevance on the document, where it is used to rank items in queries and
+// digests. The score of a profile is computed from its weighted categories, keywords and feeds,
+// blended with an LLM judgement against its interest statement when judging is enabled.
+//
+// Relevance is a ranking aid, so the activity falls back to the rule scores rather than failing
+// when the LLM fails or the daily budget is exhausted. Token usage is added to the usage already
+// recorded on the document.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that scores the relevance of a feed item document
+// @author Thomas De Meyer
+func ScoreRelevance(cfg ScoreRelevanceConfig) func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx context.Context, feedItemDoc internal.FeedItemDocument) error {
+		logger := activity.GetLogger(ctx)
+		if len(cfg.Profiles) == 0 {
+			return nil
+		}
+
+		var doc internal.FeedItemDocument
+		err := cfg.Collection.FindOne(ctx, bson.M{"_id": feedItemDoc.ID}, options.FindOne().SetProjection(bson.M{"text": 0})).Decode(&doc)
+		if err != nil {
+			logger.Error("Failed to read feed item", "err", err, "id", feedItemDoc.ID.Hex())
+			return err
+		}
+
+		var judgements map[string]relevance.Judgement
+		var judgeUsage internal.TokenUsage
+		if cfg.Judge && slices.ContainsFunc(cfg.Profiles, func(p relevance.Profile) bool { return p.Statement != "" }) {
+			judgements, judgeUsage = judgeRelevance(ctx, cfg, doc)
+		}
+
+		result := relevance.Score(cfg.Profiles, doc, judgements, cfg.LLMWeight)
+		update := bson.M{"$set": bson.M{"relevance": result}}
+		if judgeUsage != (internal.TokenUsage{}) {
+			update["$inc"] = bson.M{
+				"usage.prompt_tokens":     judgeUsage.PromptTokens,
+				"usage.completion_tokens": judgeUsage.CompletionTokens,
+				"usage.estimated_cost":    judgeUsage.EstimatedCost,
+			}
+		}
+		if _, err := cfg.Collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+			logger.Error("Failed to save relevance", "err", err, "id", doc.ID.Hex())
+			return err
+		}
+
+		relevanceScoreHistogram.Record(ctx, result.Score, metric.WithAttributes(attribute.String("profile", result.Profile)))
+		logger.Info("Scored relevance", "id", doc.ID.Hex(), "score", result.Score, "profile", result.Profile, "judged", len(judgements))
+		return nil
+	}
+}
+
+// judgeRelevance asks the LLM how relevant doc is to the statements of the profiles. Failures are
+// logged and return no judgements.
+func judgeRelevance(ctx context.Context, cfg ScoreRelevanceConfig, doc internal.FeedItemDocument) (map[string]relevance.Judgement, internal.TokenUsage) {
+	logger := activity.GetLogger(ctx)
+
+	exceeded, resetAt, err := cfg.Tracker.Exceeded(ctx)
+	if err != nil {
+		logger.Warn("Failed to check daily LLM budget", "err", err)
+	} else if exceeded {
+		logger.Warn("Daily LLM budget exceeded, scoring relevance without LLM", "id", doc.ID.Hex(), "resetAt", resetAt)
+		return nil, internal.TokenUsage{}
+	}
+
+	data := prompt.RelevanceData{Feed: doc.Feed, Title: doc.Title, Categories: doc.Categories, Summary: doc.Summary}
+	var judged []relevance.Profile
+	for _, p := range cfg.Profiles {
+		if p.Statement != "" {
+			data.Profiles = append(data.Profiles, prompt.RelevanceProfile{Name: p.Name, Statement: p.Statement})
+			judged = append(judged, p)
+		}
+	}
+	messages, err := renderMessages(cfg.Prompts, prompt.RelevanceSystemTemplate, prompt.RelevanceTemplate, data)
+	if err != nil {
+		logger.Error("Failed to render relevance prompt", "err", err, "id", doc.ID.Hex())
+		return nil, internal.TokenUsage{}
+	}
+
+	resp, err := cfg.LLM.Complete(ctx, messages)
+	if err != nil {
+		logger.Warn("Failed to judge relevance with LLM, scoring relevance without LLM", "err", err, "id", doc.ID.Hex())
+		return nil, internal.TokenUsage{}
+	}
+	cost, err := cfg.Tracker.Record(ctx, doc.Feed, resp)
+	if err != nil {
+		logger.Warn("Failed to record LLM spend", "err", err, "id", doc.ID.Hex())
+	}
+	spent := internal.TokenUsage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens, EstimatedCost: cost}
+
+	judgements, err := relevance.ParseJudgements(resp.Content, judged)
+	if err != nil {
+		logger.Warn("Invalid relevance judgement, scoring relevance without LLM", "err", err, "id", doc.ID.Hex(), "response", resp.Content)
+		return nil, spent
+	}
+	return judgements, spent
+}
@@ -0,0 +1,57 @@
+package activity
+
+import (
+	"context"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.temporal.io/sdk/activity"
+)
+
+var digestEmailCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	digestEmailCounter, _ = meter.Int64Counter(
+		"feeds.digest.emails",
+		metric.WithDescription("Number of digest emails by frequency and outcome (sent, failed)"),
+		metric.WithUnit("{email}"),
+	)
+}
+
+// SendDigest renders the digest as an HTML and plain-text email and sends it to the subscriber.
+//
+// @param sender - The sender delivering the email, typically an SMTP server
+// @return A function that sends a digest to a subscriber
+// @author Thomas De Meyer
+func SendDigest(sender digest.Sender) func(ctx context.Context, subscriber digest.Subscriber, d digest.Digest) error {
+	return func(ctx context.Context, subscriber digest.Subscriber, d digest.Digest) error {
+		logger := activity.GetLogger(ctx)
+
+		email, err := digest.Render(d, subscriber)
+		if err != nil {
+			logger.Error("Failed to render digest", "err", err, "email", subscriber.Email)
+			return err
+		}
+
+		if err := sender.Send(ctx, subscriber.Email, email); err != nil {
+			logger.Error("Failed to send digest", "err", err, "email", subscriber.Email)
+			recordDigestEmail(ctx, d.Frequency, "failed")
+			return err
+		}
+
+		recordDigestEmail(ctx, d.Frequency, "sent")
+		logger.Info("Sent digest", "email", subscriber.Email, "subject", email.Subject)
+		return nil
+	}
+}
+
+func recordDigestEmail(ctx context.Context, frequency digest.Frequency, outcome string) {
+	digestEmailCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("frequency", string(frequency)),
+		attribute.String("outcome", outcome),
+	)))
+}
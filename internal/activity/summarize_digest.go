@@ -0,0 +1,112 @@
+package activity
+
+import (
+	"context"
+	"strings"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"github.com/demeyerthom/feeds-aggregator/internal/language"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
+	"github.com/demeyerthom/feeds-aggregator/internal/usage"
+	"go.temporal.io/sdk/activity"
+)
+
+// digestUsageFeed is the feed LLM spend on digest overviews is recorded under
+const digestUsageFeed = "digest"
+
+// SummarizeDigestConfig holds the dependencies and settings of the SummarizeDigest activity
+type SummarizeDigestConfig struct {
+	// LLM is the LLM client, typically a failover chain of providers
+	LLM llm.LLM
+	// Tracker records token metrics, estimates cost and enforces the daily budget
+	Tracker *usage.Tracker
+	// Prompts are the prompt templates used to build the LLM requests
+	Prompts *prompt.Templates
+	// Language is the ISO 639-1 code of the overview language; empty lets the LLM choose
+	Language string
+	// ContextSize is the context window size of the model in tokens
+	ContextSize int
+}
+
+// newDigestOverviewData creates the prompt data for d with as many items as fit in budget tokens,
+// in the order of the sections.
+func newDigestOverviewData(d digest.Digest, lang string, budget int, estimate func(prompt.DigestOverviewData) int) prompt.DigestOverviewData {
+	data := prompt.DigestOverviewData{Frequency: string(d.Frequency), Period: "week", Language: language.Name(lang)}
+	if d.Frequency == digest.Daily {
+		data.Period = "day"
+	}
+	for _, s := range d.Sections {
+		for _, item := range s.Items {
+			data.Items = append(data.Items, prompt.DigestOverviewItem{Category: s.Category, Title: item.Title, Summary: item.Summary})
+			if estimate(data) > budget {
+				data.Items = data.Items[:len(data.Items)-1]
+				return data
+			}
+		}
+	}
+	return data
+}
+
+// SummarizeDigest asks the LLM for a short overview of what happened in the period of a digest,
+// based on the titles and summaries of its items. Items that do not fit the context window are
+// left out of the prompt. When the daily budget is exhausted the activity fails with
+// ErrTypeBudgetExceeded without calling the LLM.
+//
+// @param cfg - Dependencies and settings of the activity
+// @return A function that returns the overview of a digest
+// @author Thomas De Meyer
+func SummarizeDigest(cfg SummarizeDigestConfig) func(ctx context.Context, d digest.Digest) (string, error) {
+	return func(ctx context.Context, d digest.Digest) (string, error) {
+		logger := activity.GetLogger(ctx)
+
+		exceeded, resetAt, err := cfg.Tracker.Exceeded(ctx)
+		if err != nil {
+			logger.Warn("Failed to check daily LLM budget", "err", err)
+		} else if exceeded {
+			logger.Warn("Daily LLM budget exceeded, not summarizing digest", "resetAt", resetAt)
+			return "", newBudgetExceededError(resetAt)
+		}
+
+		var renderErr error
+		budget := max(cfg.ContextSize-responseTokenReserve, minChunkTokens)
+		data := newDigestOverviewData(d, cfg.Language, budget, func(data prompt.DigestOverviewData) int {
+			messages, err := renderMessages(cfg.Prompts, prompt.DigestOverviewSystemTemplate, prompt.DigestOverviewTemplate, data)
+			if err != nil {
+				renderErr = err
+				return 0
+			}
+			return estimateMessages(messages)
+		})
+		if renderErr != nil {
+			logger.Error("Failed to render digest overview prompt", "err", renderErr)
+			return "", renderErr
+		}
+		if len(data.Items) < d.Items() {
+			logger.Info("Leaving digest items out of the overview prompt", "items", len(data.Items), "digestItems", d.Items())
+		}
+		messages, err := renderMessages(cfg.Prompts, prompt.DigestOverviewSystemTemplate, prompt.DigestOverviewTemplate, data)
+		if err != nil {
+			logger.Error("Failed to render digest overview prompt", "err", err)
+			return "", err
+		}
+
+		resp, err := cfg.LLM.Complete(ctx, messages)
+		if err != nil {
+			logger.Error("Failed to summarize digest with LLM", "err", err)
+			return "", err
+		}
+		if _, err := cfg.Tracker.Record(ctx, digestUsageFeed, resp); err != nil {
+			logger.Warn("Failed to record LLM spend", "err", err)
+		}
+
+		overview := strings.TrimSpace(resp.Content)
+		if overview == "" {
+			return "", ErrEmptyResponse
+		}
+
+		logger.Info("Summarized digest", "frequency", d.Frequency, "items", len(data.Items), "provider", resp.Provider, "model", resp.Model,
+			"promptTokens", resp.Usage.PromptTokens, "completionTokens", resp.Usage.CompletionTokens)
+		return overview, nil
+	}
+}
@@ -0,0 +1,32 @@
+package activity
+
+import (
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	prompt "github.com/demeyerthom/feeds-aggregator/internal/prompt"
+)
+
+func TestNewDigestOverviewData(t *testing.T) {
+	d := digest.Digest{
+		Frequency: digest.Daily,
+		Sections: []digest.Section{
+			{Category: "Security", Items: []digest.Item{{Title: "a", Summary: "sa"}, {Title: "b"}}},
+			{Category: "Cloud", Items: []digest.Item{{Title: "c"}}},
+		},
+	}
+	count := func(data prompt.DigestOverviewData) int { return len(data.Items) }
+
+	data := newDigestOverviewData(d, "nl", 10, count)
+	if data.Period != "day" || data.Language != "Dutch" || len(data.Items) != 3 {
+		t.Fatalf("expected all items of a daily digest in Dutch, got %+v", data)
+	}
+	if data.Items[2].Category != "Cloud" || data.Items[0].Summary != "sa" {
+		t.Errorf("expected items in section order with summaries, got %+v", data.Items)
+	}
+
+	data = newDigestOverviewData(d, "", 2, count)
+	if len(data.Items) != 2 || data.Items[1].Title != "b" {
+		t.Errorf("expected the items that fit the budget, got %+v", data.Items)
+	}
+}
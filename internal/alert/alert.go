@@ -0,0 +1,163 @@
+// Package alert evaluates alert rules against processed feed items and notifies their targets,
+// e.g. to page on-call when a feed mentions a CVE in a dependency or a breaking change in a product.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Target types
+const (
+	TargetLog     = "log"
+	TargetEmail   = "email"
+	TargetWebhook = "webhook"
+)
+
+var (
+	// ErrEmptyRule is returned for rules without any condition
+	ErrEmptyRule = errors.New("alert rule needs an expression, regex, category or feed")
+	// ErrInvalidTarget is returned for targets that cannot be parsed
+	ErrInvalidTarget = errors.New("target must be log, email:ADDRESS or webhook:URL")
+)
+
+// Rule is an alert rule. An item matches when it matches every condition that is set: the
+// expression, the regex, any of the categories and any of the feeds.
+type Rule struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name"`
+	Expression string             `bson:"expression,omitempty"`
+	// Regex is matched against the title, summary and article text
+	Regex      string    `bson:"regex,omitempty"`
+	Categories []string  `bson:"categories,omitempty"`
+	Feeds      []string  `bson:"feeds,omitempty"`
+	Targets    []Target  `bson:"targets"`
+	Disabled   bool      `bson:"disabled,omitempty"`
+	CreatedAt  time.Time `bson:"created_at"`
+}
+
+// Target is where the alerts of a rule are sent
+type Target struct {
+	Type string `bson:"type"`
+	// Address is the email address of email targets and the URL of webhook targets
+	Address string `bson:"address,omitempty"`
+	// Format and Secret configure webhook targets like webhook subscriptions
+	Format webhook.Format `bson:"format,omitempty"`
+	Secret string         `bson:"secret,omitempty"`
+}
+
+// String returns the target in the form accepted by ParseTarget.
+func (t Target) String() string {
+	if t.Address == "" {
+		return t.Type
+	}
+	return t.Type + ":" + t.Address
+}
+
+// ParseTarget parses a target of the form log, email:ADDRESS or webhook:URL.
+func ParseTarget(s string) (Target, error) {
+	typ, address, _ := strings.Cut(strings.TrimSpace(s), ":")
+	t := Target{Type: strings.ToLower(typ), Address: address}
+	switch {
+	case t.Type == TargetLog && address == "":
+		return t, nil
+	case t.Type == TargetEmail && address != "":
+		return t, nil
+	case t.Type == TargetWebhook && address != "":
+		t.Format = webhook.FormatJSON
+		return t, nil
+	}
+	return Target{}, fmt.Errorf("%w: %q", ErrInvalidTarget, s)
+}
+
+// Compiled is a rule with its expression and regex compiled
+type Compiled struct {
+	Rule
+	expr  Expr
+	regex *regexp.Regexp
+}
+
+// Compile validates the conditions of r and compiles them.
+func Compile(r Rule) (*Compiled, error) {
+	if r.Expression == "" && r.Regex == "" && len(r.Categories) == 0 && len(r.Feeds) == 0 {
+		return nil, ErrEmptyRule
+	}
+	c := &Compiled{Rule: r}
+	if r.Expression != "" {
+		expr, err := Parse(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		c.expr = expr
+	}
+	if r.Regex != "" {
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alert regex: %w", err)
+		}
+		c.regex = re
+	}
+	return c, nil
+}
+
+// Match reports whether item matches every condition of the rule.
+func (c *Compiled) Match(item *Item) bool {
+	doc := item.doc
+	if len(c.Categories) > 0 && !slices.ContainsFunc(doc.Categories, func(category string) bool { return slices.Contains(c.Categories, category) }) {
+		return false
+	}
+	if len(c.Feeds) > 0 && !slices.Contains(c.Feeds, doc.Feed) {
+		return false
+	}
+	if c.regex != nil && !c.regex.MatchString(item.raw) {
+		return false
+	}
+	if c.expr != nil && !c.expr.Eval(item) {
+		return false
+	}
+	return true
+}
+
+// Item is a feed item prepared for evaluating rules
+type Item struct {
+	doc internal.FeedItemDocument
+	// raw is the title, summary and article text, and text is raw and the keywords in lowercase
+	raw  string
+	text string
+}
+
+// NewItem prepares doc for evaluating rules. The article text is only searched when doc carries it.
+func NewItem(doc internal.FeedItemDocument) *Item {
+	raw := strings.Join([]string{doc.Title, doc.Summary, doc.Text}, "\n")
+	return &Item{
+		doc:  doc,
+		raw:  raw,
+		text: strings.ToLower(raw + "\n" + strings.Join(doc.Keywords, "\n")),
+	}
+}
+
+// Evaluate returns the rules matched by item.
+func Evaluate(rules []*Compiled, item *Item) []*Compiled {
+	var matched []*Compiled
+	for _, r := range rules {
+		if r.Match(item) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// Notification is the delivery of an alert of a rule to one of its targets, identified by its
+// index in the targets of the rule
+type Notification struct {
+	RuleID string `json:"ruleId"`
+	Target int    `json:"target"`
+}
@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCompile_Match(t *testing.T) {
+	cases := []struct {
+		name string
+		rule Rule
+		want bool
+	}{
+		{"expression", Rule{Expression: "cve:* AND product:openssl"}, true},
+		{"regex", Rule{Regex: `CVE-\d{4}-\d+`}, true},
+		{"regex no match", Rule{Regex: `(?i)kubernetes`}, false},
+		{"category", Rule{Categories: []string{"Security"}}, true},
+		{"feed", Rule{Feeds: []string{"Go Blog"}}, false},
+		{"all conditions", Rule{Expression: "release:breaking", Regex: "state format", Categories: []string{"DevOps"}, Feeds: []string{"Terraform Releases"}}, true},
+		{"one condition fails", Rule{Expression: "release:breaking", Feeds: []string{"Go Blog"}}, false},
+	}
+	item := testItem()
+	for _, c := range cases {
+		compiled, err := Compile(c.rule)
+		if err != nil {
+			t.Errorf("%s: failed to compile: %v", c.name, err)
+			continue
+		}
+		if got := compiled.Match(item); got != c.want {
+			t.Errorf("%s: Match() = %t, want %t", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	if _, err := Compile(Rule{Name: "empty"}); !errors.Is(err, ErrEmptyRule) {
+		t.Errorf("expected ErrEmptyRule, got %v", err)
+	}
+	if _, err := Compile(Rule{Expression: "(terraform"}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("expected ErrSyntax, got %v", err)
+	}
+	if _, err := Compile(Rule{Regex: "("}); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	var rules []*Compiled
+	for _, expr := range []string{"terraform", "kubernetes", "cve:*"} {
+		c, err := Compile(Rule{Name: expr, Expression: expr})
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		rules = append(rules, c)
+	}
+
+	matched := Evaluate(rules, testItem())
+	if len(matched) != 2 || matched[0].Name != "terraform" || matched[1].Name != "cve:*" {
+		t.Errorf("expected terraform and cve:* to match, got %v", matched)
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	cases := map[string]Target{
+		"log":                                  {Type: TargetLog},
+		"email:oncall@example.com":             {Type: TargetEmail, Address: "oncall@example.com"},
+		"webhook:https://example.com/hook?a=1": {Type: TargetWebhook, Address: "https://example.com/hook?a=1", Format: webhook.FormatJSON},
+	}
+	for in, want := range cases {
+		got, err := ParseTarget(in)
+		if err != nil || got != want {
+			t.Errorf("ParseTarget(%q) = %+v, %v, want %+v", in, got, err, want)
+		}
+		if got.String() != in {
+			t.Errorf("expected %q to round-trip, got %q", in, got.String())
+		}
+	}
+	for _, in := range []string{"", "email", "webhook:", "log:x", "pager:123"} {
+		if _, err := ParseTarget(in); !errors.Is(err, ErrInvalidTarget) {
+			t.Errorf("ParseTarget(%q) expected ErrInvalidTarget, got %v", in, err)
+		}
+	}
+}
+
+// recordingSender records the emails it is asked to send
+type recordingSender struct {
+	to     []string
+	emails []digest.Email
+}
+
+func (s *recordingSender) Send(_ context.Context, to string, email digest.Email) error {
+	s.to = append(s.to, to)
+	s.emails = append(s.emails, email)
+	return nil
+}
+
+func TestNotifiers_Email(t *testing.T) {
+	sender := &recordingSender{}
+	notifiers := Notifiers{TargetEmail: EmailNotifier{Sender: sender}}
+	a := Alert{
+		Rule:      Rule{ID: primitive.NewObjectID(), Name: "Terraform <breaking>"},
+		Item:      testItem().doc,
+		MatchedAt: time.Now(),
+	}
+
+	if err := notifiers.Notify(context.Background(), Target{Type: TargetEmail, Address: "oncall@example.com"}, a); err != nil {
+		t.Fatalf("failed to notify: %v", err)
+	}
+	if len(sender.emails) != 1 || sender.to[0] != "oncall@example.com" {
+		t.Fatalf("expected one email to oncall, got %v", sender.to)
+	}
+	email := sender.emails[0]
+	if email.Subject != "[Alert] Terraform <breaking>: Terraform 1.10 released" {
+		t.Errorf("unexpected subject %q", email.Subject)
+	}
+	if !strings.Contains(email.HTML, "Terraform &lt;breaking&gt;") || !strings.Contains(email.Text, "Feed: Terraform Releases") {
+		t.Errorf("unexpected email %+v", email)
+	}
+
+	if err := notifiers.Notify(context.Background(), Target{Type: TargetWebhook}, a); !errors.Is(err, ErrInvalidTarget) {
+		t.Errorf("expected ErrInvalidTarget without a webhook notifier, got %v", err)
+	}
+}
@@ -0,0 +1,352 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+)
+
+// ErrSyntax is returned for expressions that cannot be parsed
+var ErrSyntax = errors.New("invalid alert expression")
+
+// Expression fields besides the entity types
+const (
+	fieldCategory = "category"
+	fieldFeed     = "feed"
+	fieldKeyword  = "keyword"
+	fieldRelease  = "release"
+)
+
+// Release conditions of the release field
+const (
+	releaseAny         = "*"
+	releaseBreaking    = "breaking"
+	releaseSecurity    = "security"
+	releaseDeprecation = "deprecation"
+)
+
+// Expr is a parsed boolean alert expression
+type Expr interface {
+	Eval(item *Item) bool
+	String() string
+}
+
+type andExpr struct{ left, right Expr }
+
+func (e andExpr) Eval(item *Item) bool { return e.left.Eval(item) && e.right.Eval(item) }
+func (e andExpr) String() string       { return "(" + e.left.String() + " AND " + e.right.String() + ")" }
+
+type orExpr struct{ left, right Expr }
+
+func (e orExpr) Eval(item *Item) bool { return e.left.Eval(item) || e.right.Eval(item) }
+func (e orExpr) String() string       { return "(" + e.left.String() + " OR " + e.right.String() + ")" }
+
+type notExpr struct{ expr Expr }
+
+func (e notExpr) Eval(item *Item) bool { return !e.expr.Eval(item) }
+func (e notExpr) String() string       { return "NOT " + e.expr.String() }
+
+// textExpr matches a word, a prefix ending in * or a quoted phrase in the title, summary,
+// keywords and article text, ignoring case
+type textExpr struct {
+	source  string
+	pattern *regexp.Regexp
+}
+
+func (e textExpr) Eval(item *Item) bool { return e.pattern.MatchString(item.text) }
+func (e textExpr) String() string       { return e.source }
+
+// fieldExpr matches a category, feed, keyword, release condition or entity
+type fieldExpr struct {
+	field string
+	value string
+}
+
+func (e fieldExpr) String() string { return e.field + ":" + quote(e.value) }
+
+func (e fieldExpr) Eval(item *Item) bool {
+	doc := item.doc
+	switch e.field {
+	case fieldCategory:
+		return slices.ContainsFunc(doc.Categories, func(c string) bool { return strings.EqualFold(c, e.value) })
+	case fieldFeed:
+		return strings.EqualFold(doc.Feed, e.value)
+	case fieldKeyword:
+		return slices.Contains(doc.Keywords, strings.ToLower(e.value))
+	case fieldRelease:
+		r := doc.Release
+		if r == nil {
+			return false
+		}
+		switch e.value {
+		case releaseBreaking:
+			return len(r.BreakingChanges) > 0
+		case releaseSecurity:
+			return len(r.SecurityFixes) > 0
+		case releaseDeprecation:
+			return len(r.Deprecations) > 0
+		}
+		return true
+	}
+	// Entity fields match the lookup key, so "product:Terraform" also matches "terraform"
+	return slices.ContainsFunc(doc.Entities, func(en entity.Entity) bool {
+		if en.Type != e.field {
+			return false
+		}
+		return e.value == "*" || en.Key == entity.Key(e.value) || en.Key == e.value
+	})
+}
+
+// Parse parses a boolean alert expression. Terms are combined with AND, OR and NOT (in capitals)
+// and grouped with parentheses; terms next to each other are combined with AND. A term is one of:
+//
+//   - a word, matched as a whole word: openssl
+//   - a prefix, matched at the start of a word: deprecat*
+//   - a phrase: "breaking change"
+//   - a category, feed or keyword: category:Security, feed:"Go Blog", keyword:kubernetes
+//   - an entity by type and name, or * for any: product:terraform, cve:CVE-2024-3094, cve:*
+//   - a release condition: release:breaking, release:security, release:deprecation, release:*
+func Parse(s string) (Expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty expression", ErrSyntax)
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrSyntax, p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenPhrase
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")"})
+			i++
+		case c == '"':
+			phrase, next, err := readQuoted(r, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, text: phrase})
+			i = next
+		default:
+			start := i
+			for i < len(r) && !unicode.IsSpace(r[i]) && r[i] != '(' && r[i] != ')' && r[i] != '"' {
+				i++
+			}
+			word := string(r[start:i])
+			// A quoted value directly after a field name belongs to the field, e.g. feed:"Go Blog"
+			if strings.HasSuffix(word, ":") && i < len(r) && r[i] == '"' {
+				value, next, err := readQuoted(r, i)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, token{kind: tokenTerm, text: word + value})
+				i = next
+				continue
+			}
+			switch word {
+			case "AND":
+				tokens = append(tokens, token{kind: tokenAnd, text: word})
+			case "OR":
+				tokens = append(tokens, token{kind: tokenOr, text: word})
+			case "NOT":
+				tokens = append(tokens, token{kind: tokenNot, text: word})
+			default:
+				tokens = append(tokens, token{kind: tokenTerm, text: word})
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// readQuoted reads the quoted string starting at r[i] and returns it with the index after the closing quote.
+func readQuoted(r []rune, i int) (string, int, error) {
+	end := slices.Index(r[i+1:], '"')
+	if end < 0 {
+		return "", 0, fmt.Errorf("%w: unterminated quote", ErrSyntax)
+	}
+	return string(r[i+1 : i+1+end]), i + end + 2, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind != tokenOr {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr || t.kind == tokenClose {
+			return left, nil
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	t, ok := p.peek()
+	if ok && t.kind == tokenNot {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+	p.pos++
+	switch t.kind {
+	case tokenOpen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != tokenClose {
+			return nil, fmt.Errorf("%w: missing closing parenthesis", ErrSyntax)
+		}
+		p.pos++
+		return expr, nil
+	case tokenPhrase:
+		return newTextExpr(t.text, false)
+	case tokenTerm:
+		return newTermExpr(t.text)
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", ErrSyntax, t.text)
+}
+
+// newTermExpr creates the expression of an unquoted term, which is a field term when it starts
+// with a known field name and a colon, and a word or prefix otherwise.
+func newTermExpr(term string) (Expr, error) {
+	if field, value, ok := strings.Cut(term, ":"); ok {
+		field = strings.ToLower(field)
+		if isField(field) {
+			if value == "" {
+				return nil, fmt.Errorf("%w: missing value for %s", ErrSyntax, field)
+			}
+			if field == fieldRelease && !slices.Contains([]string{releaseAny, releaseBreaking, releaseSecurity, releaseDeprecation}, value) {
+				return nil, fmt.Errorf("%w: release must be *, breaking, security or deprecation, got %q", ErrSyntax, value)
+			}
+			return fieldExpr{field: field, value: value}, nil
+		}
+	}
+	if prefix, ok := strings.CutSuffix(term, "*"); ok {
+		return newTextExpr(prefix, true)
+	}
+	return newTextExpr(term, false)
+}
+
+func isField(field string) bool {
+	return field == fieldCategory || field == fieldFeed || field == fieldKeyword || field == fieldRelease || slices.Contains(entity.Types, field)
+}
+
+// newTextExpr compiles a word or phrase into a case-insensitive pattern matching whole words,
+// allowing any whitespace between the words of a phrase. A prefix only needs to start a word.
+func newTextExpr(text string, prefix bool) (Expr, error) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("%w: empty term", ErrSyntax)
+	}
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(strings.ToLower(w))
+	}
+	pattern := `(?:^|[^\pL\pN_])` + strings.Join(words, `\s+`)
+	if !prefix {
+		pattern += `(?:$|[^\pL\pN_])`
+	}
+	source := quote(text)
+	if prefix {
+		source = text + "*"
+	}
+	return textExpr{source: source, pattern: regexp.MustCompile(pattern)}, nil
+}
+
+// quote quotes values containing whitespace.
+func quote(s string) string {
+	if strings.ContainsFunc(s, unicode.IsSpace) {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+)
+
+func testItem() *Item {
+	return NewItem(internal.FeedItemDocument{
+		Title:      "Terraform 1.10 released",
+		Summary:    "The release contains breaking changes to the state format and fixes CVE-2026-1234 in OpenSSL.",
+		Text:       "Providers are deprecated in favour of modules.",
+		Feed:       "Terraform Releases",
+		Categories: []string{"DevOps", "Security"},
+		Keywords:   []string{"infrastructure as code"},
+		Entities: []entity.Entity{
+			{Type: entity.TypeProduct, Name: "Terraform", Key: "terraform"},
+			{Type: entity.TypeProduct, Name: "OpenSSL", Key: "openssl"},
+			{Type: entity.TypeCVE, Name: "CVE-2026-1234", Key: "cve-2026-1234"},
+		},
+		Release: &internal.Release{Product: "Terraform", BreakingChanges: []string{"State format v5"}},
+	})
+}
+
+func TestParse_Eval(t *testing.T) {
+	cases := map[string]bool{
+		"terraform":                        true,
+		"Terraform AND released":           true,
+		"terraform kubernetes":             false,
+		"terraform OR kubernetes":          true,
+		"NOT kubernetes":                   true,
+		"NOT (terraform OR kubernetes)":    false,
+		`"breaking changes"`:               true,
+		`"breaking   Changes"`:             true,
+		`"changes breaking"`:               false,
+		"deprecat*":                        true,
+		"terra":                            false,
+		"terra*":                           true,
+		"modules":                          true,
+		`"as code"`:                        true,
+		"category:security":                true,
+		"category:Cloud":                   false,
+		`feed:"Terraform Releases"`:        true,
+		"keyword:infrastructure":           false,
+		`keyword:"infrastructure as code"`: true,
+		"product:Terraform":                true,
+		"product:kubernetes":               false,
+		"cve:*":                            true,
+		"cve:CVE-2026-1234":                true,
+		"cve:* AND (product:openssl OR product:curl)": true,
+		"person:*":         false,
+		"release:breaking": true,
+		"release:security": false,
+		"product:terraform AND (release:breaking OR \"breaking change\")": true,
+		"unknown:field": false,
+		"kubernetes OR terraform AND NOT released": false,
+	}
+	item := testItem()
+	for expr, want := range cases {
+		e, err := Parse(expr)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", expr, err)
+			continue
+		}
+		if got := e.Eval(item); got != want {
+			t.Errorf("Parse(%q) = %s evaluated to %t, want %t", expr, e, got, want)
+		}
+	}
+}
+
+func TestParse_Precedence(t *testing.T) {
+	e, err := Parse("a OR b c AND NOT d")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if got, want := e.String(), "(a OR ((b AND c) AND NOT d))"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "   ", "(terraform", "terraform)", `"open`, "AND terraform", "terraform OR", "NOT", "release:minor", "cve:", `feed:"Go`} {
+		if _, err := Parse(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) expected ErrSyntax, got %v", expr, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"github.com/demeyerthom/feeds-aggregator/internal/webhook"
+)
+
+// Alert is a rule matched by a feed item
+type Alert struct {
+	Rule      Rule
+	Item      internal.FeedItemDocument
+	MatchedAt time.Time
+}
+
+// ID identifies the alert of a rule for an item, so receivers can drop alerts they already handled.
+func (a Alert) ID() string {
+	return "alert-" + a.Rule.ID.Hex() + "-" + a.Item.ID.Hex()
+}
+
+// Notifier delivers alerts to the targets of one type
+type Notifier interface {
+	Notify(ctx context.Context, target Target, a Alert) error
+}
+
+// Notifiers maps target types to the notifier delivering them
+type Notifiers map[string]Notifier
+
+// Notify delivers a to target with the notifier of the target type.
+func (n Notifiers) Notify(ctx context.Context, target Target, a Alert) error {
+	notifier, ok := n[target.Type]
+	if !ok {
+		return fmt.Errorf("%w: no notifier for %q", ErrInvalidTarget, target.Type)
+	}
+	return notifier.Notify(ctx, target, a)
+}
+
+// LogNotifier writes alerts to a logger, for alerts picked up from the logs
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+func (n LogNotifier) Notify(ctx context.Context, _ Target, a Alert) error {
+	n.Logger.WarnContext(ctx, "Alert rule matched", "rule", a.Rule.Name, "id", a.Item.ID.Hex(), "title", a.Item.Title, "link", a.Item.Link, "feed", a.Item.Feed)
+	return nil
+}
+
+// EmailNotifier sends alerts by email
+type EmailNotifier struct {
+	Sender digest.Sender
+}
+
+func (n EmailNotifier) Notify(ctx context.Context, target Target, a Alert) error {
+	return n.Sender.Send(ctx, target.Address, newEmail(a))
+}
+
+// newEmail renders the alert email of a.
+func newEmail(a Alert) digest.Email {
+	item := a.Item
+	var text, body strings.Builder
+	fmt.Fprintf(&text, "Alert rule %q matched a new item.\n\n%s\n%s\n", a.Rule.Name, item.Title, item.Link)
+	fmt.Fprintf(&body, "<p>Alert rule <strong>%s</strong> matched a new item.</p>\n<p><a href=\"%s\">%s</a>",
+		html.EscapeString(a.Rule.Name), html.EscapeString(item.Link), html.EscapeString(item.Title))
+	if item.Feed != "" {
+		fmt.Fprintf(&text, "Feed: %s\n", item.Feed)
+		fmt.Fprintf(&body, " (%s)", html.EscapeString(item.Feed))
+	}
+	body.WriteString("</p>\n")
+	if item.Summary != "" {
+		fmt.Fprintf(&text, "\n%s\n", item.Summary)
+		fmt.Fprintf(&body, "<p>%s</p>\n", html.EscapeString(item.Summary))
+	}
+	return digest.Email{
+		Subject: fmt.Sprintf("[Alert] %s: %s", a.Rule.Name, item.Title),
+		Text:    text.String(),
+		HTML:    body.String(),
+	}
+}
+
+// WebhookNotifier posts alerts to webhooks as alert events, or as Slack or Discord messages
+type WebhookNotifier struct {
+	Client *webhook.Client
+}
+
+func (n WebhookNotifier) Notify(ctx context.Context, target Target, a Alert) error {
+	now := time.Now()
+	event := webhook.NewEvent(a.ID(), a.Item, now)
+	event.Type = webhook.EventAlertMatched
+	event.Rule = &webhook.Rule{ID: a.Rule.ID.Hex(), Name: a.Rule.Name}
+
+	format := target.Format
+	if format == "" {
+		format = webhook.FormatJSON
+	}
+	_, err := n.Client.Send(ctx, webhook.Subscription{URL: target.Address, Format: format, Secret: target.Secret}, event, now)
+	return err
+}
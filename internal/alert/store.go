@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrRuleNotFound is returned when an alert rule does not exist
+var ErrRuleNotFound = errors.New("alert rule not found")
+
+// Store persists alert rules in MongoDB
+type Store struct {
+	rules *mongo.Collection
+}
+
+// NewStore creates a store backed by the alert rule collection.
+func NewStore(rules *mongo.Collection) *Store {
+	return &Store{rules: rules}
+}
+
+// EnsureIndexes creates the unique index on rule names.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.rules.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Enabled returns the enabled rules.
+func (s *Store) Enabled(ctx context.Context) ([]Rule, error) {
+	return s.find(ctx, bson.M{"disabled": bson.M{"$ne": true}})
+}
+
+// List returns all rules, ordered by name.
+func (s *Store) List(ctx context.Context) ([]Rule, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *Store) find(ctx context.Context, filter bson.M) ([]Rule, error) {
+	cursor, err := s.rules.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Get returns the rule with the given ID.
+func (s *Store) Get(ctx context.Context, id primitive.ObjectID) (Rule, error) {
+	var r Rule
+	err := s.rules.FindOne(ctx, bson.M{"_id": id}).Decode(&r)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r, ErrRuleNotFound
+	}
+	return r, err
+}
+
+// Add validates and stores a new rule and returns it with its ID.
+func (s *Store) Add(ctx context.Context, r Rule) (Rule, error) {
+	if _, err := Compile(r); err != nil {
+		return Rule{}, err
+	}
+	r.ID = primitive.NewObjectID()
+	r.CreatedAt = time.Now()
+	if _, err := s.rules.InsertOne(ctx, r); err != nil {
+		return Rule{}, err
+	}
+	return r, nil
+}
+
+// Remove deletes the rule with the given ID.
+func (s *Store) Remove(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.rules.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package api implements the HTTP API over processed feed items, the item states of users and their
+// feed subscriptions. Users authenticate with API keys and see the items of the feeds they
+// subscribe to.
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/demeyerthom/feeds-aggregator/internal/taxonomy"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+)
+
+const (
+	// defaultLimit is the page size when the request does not set one
+	defaultLimit = 20
+	// maxLimit is the largest page size a request may ask for
+	maxLimit = 100
+	// defaultFeedSize is the number of entries in output feeds when the configuration does not set one
+	defaultFeedSize = 50
+)
+
+// Config holds the dependencies and settings of the API server
+type Config struct {
+	// Items reads and searches feed items
+	Items *feeditem.Store
+	// Taxonomy lists the categories
+	Taxonomy *taxonomy.Store
+	// States reads and updates the item states of users
+	States *itemstate.Store
+	// Accounts authenticates API keys and manages subscriptions; nil serves every request anonymously
+	Accounts *account.Store
+	// Anonymous allows requests without an API key, which see all items and have no item states
+	Anonymous bool
+	// BaseURL is the public URL of the API used in output feeds; empty derives it from the request
+	BaseURL string
+	// FeedSize is the number of entries in output feeds
+	FeedSize int
+	// Embedder embeds semantic search queries with the model of Vectors
+	Embedder llm.Embedder
+	// Vectors is the index of item embeddings searched by meaning; nil disables semantic search
+	Vectors *vector.LiveIndex
+}
+
+// Server serves the API endpoints
+type Server struct {
+	items     *feeditem.Store
+	taxonomy  *taxonomy.Store
+	states    *itemstate.Store
+	accounts  *account.Store
+	anonymous bool
+	baseURL   string
+	feedSize  int
+	embedder  llm.Embedder
+	vectors   *vector.LiveIndex
+}
+
+// New creates an API server from cfg.
+func New(cfg Config) *Server {
+	feedSize := cfg.FeedSize
+	if feedSize <= 0 {
+		feedSize = defaultFeedSize
+	}
+	return &Server{
+		items:     cfg.Items,
+		taxonomy:  cfg.Taxonomy,
+		states:    cfg.States,
+		accounts:  cfg.Accounts,
+		anonymous: cfg.Anonymous,
+		baseURL:   strings.TrimSuffix(cfg.BaseURL, "/"),
+		feedSize:  feedSize,
+		embedder:  cfg.Embedder,
+		vectors:   cfg.Vectors,
+	}
+}
+
+// Handler returns the HTTP handler with all routes registered.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items", s.listItems)
+	mux.HandleFunc("GET /items/{id}", s.getItem)
+	mux.HandleFunc("PATCH /items/{id}/state", s.updateItemState)
+	mux.HandleFunc("POST /mark-read", s.markRead)
+	mux.HandleFunc("GET /unread", s.unreadCounts)
+	mux.HandleFunc("GET /subscriptions", s.listSubscriptions)
+	mux.HandleFunc("POST /subscriptions", s.subscribe)
+	mux.HandleFunc("DELETE /subscriptions/{id}", s.unsubscribe)
+	mux.HandleFunc("GET /search", s.search)
+	mux.HandleFunc("GET /search/semantic", s.semanticSearch)
+	mux.HandleFunc("GET /categories", s.listCategories)
+	mux.HandleFunc("GET /feeds", s.listFeeds)
+	mux.HandleFunc("GET /syndication/{format}", s.syndicate)
+	mux.HandleFunc("GET /syndication/{format}/category/{category...}", s.syndicate)
+	mux.HandleFunc("GET /syndication/{format}/feed/{feed...}", s.syndicate)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return logRequests(s.authenticate(mux))
+}
+
+// errorResponse is the body of error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v as the JSON body of a response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write response", "err", err)
+	}
+}
+
+// writeError writes an error response. Server errors are logged and not exposed to the client.
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	message := err.Error()
+	if status >= http.StatusInternalServerError {
+		slog.Error("Request failed", "err", err, "method", r.Method, "path", r.URL.Path)
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs every request with its status and duration.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		slog.Debug("Handled request", "method", r.Method, "path", r.URL.Path, "query", redactQuery(r.URL),
+			"status", rec.status, "duration", time.Since(start))
+	})
+}
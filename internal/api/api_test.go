@@ -0,0 +1,418 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"github.com/demeyerthom/feeds-aggregator/internal/vector"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseListRequest(t *testing.T) {
+	after := feeditem.Cursor{CreatedAt: time.UnixMilli(1719360000000).UTC(), ID: primitive.NewObjectID()}
+	q := url.Values{
+		"category": {"Security"},
+		"feed":     {"Go changelog"},
+		"status":   {"processed"},
+		"from":     {"2024-06-01"},
+		"to":       {"2024-07-01T12:00:00Z"},
+		"limit":    {"50"},
+		"sort":     {"relevance"},
+		"cursor":   {after.Encode()},
+	}
+
+	req, err := parseListRequest(q)
+	if err != nil {
+		t.Fatalf("parseListRequest() error = %v", err)
+	}
+	f := req.Filter
+	if f.Category != "Security" || f.Feed != "Go changelog" || f.Status != "processed" || f.Sort != feeditem.SortRelevance {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+	if !f.From.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) || !f.To.Equal(time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date range: %v - %v", f.From, f.To)
+	}
+	if req.Limit != 50 {
+		t.Errorf("limit = %d, want 50", req.Limit)
+	}
+	if req.After == nil || req.After.ID != after.ID || !req.After.CreatedAt.Equal(after.CreatedAt) {
+		t.Errorf("cursor = %+v, want %+v", req.After, after)
+	}
+}
+
+func TestParseListRequest_Defaults(t *testing.T) {
+	req, err := parseListRequest(url.Values{})
+	if err != nil {
+		t.Fatalf("parseListRequest() error = %v", err)
+	}
+	if req.Limit != defaultLimit || req.After != nil || !reflect.DeepEqual(req.Filter, feeditem.Filter{}) {
+		t.Errorf("unexpected defaults: %+v", req)
+	}
+}
+
+func TestParseListRequest_Invalid(t *testing.T) {
+	for _, q := range []url.Values{
+		{"status": {"unknown"}},
+		{"sort": {"oldest"}},
+		{"from": {"yesterday"}},
+		{"to": {"01/07/2024"}},
+		{"limit": {"0"}},
+		{"limit": {"101"}},
+		{"limit": {"ten"}},
+		{"cursor": {"not-a-cursor"}},
+		{"state": {"read"}},
+		{"state": {"starred"}, "sort": {"relevance"}},
+		{"state": {"archived"}, "status": {"processed"}},
+	} {
+		if _, err := parseListRequest(q); err == nil {
+			t.Errorf("parseListRequest(%v) expected error", q)
+		}
+	}
+}
+
+func TestParseListRequest_State(t *testing.T) {
+	req, err := parseListRequest(url.Values{"state": {"unread"}, "sort": {"relevance"}, "category": {"Security"}})
+	if err != nil {
+		t.Fatalf("parseListRequest() error = %v", err)
+	}
+	if req.State != itemstate.StateUnread || req.Filter.Sort != feeditem.SortRelevance || req.Filter.Category != "Security" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	h := New(Config{}).Handler()
+
+	for _, target := range []string{"/items?limit=1000", "/items?cursor=invalid", "/items/not-an-id"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want 400", target, rec.Code)
+		}
+		var body errorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error == "" {
+			t.Errorf("GET %s: expected JSON error body, got %q (%v)", target, rec.Body.String(), err)
+		}
+	}
+}
+
+func TestHandler_StatesRequireUser(t *testing.T) {
+	h := New(Config{}).Handler()
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/items?state=unread", nil),
+		httptest.NewRequest(http.MethodPatch, "/items/"+primitive.NewObjectID().Hex()+"/state", strings.NewReader(`{"read": true}`)),
+		httptest.NewRequest(http.MethodPost, "/mark-read", strings.NewReader(`{"all": true}`)),
+		httptest.NewRequest(http.MethodGet, "/unread", nil),
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status = %d, want 401", r.Method, r.URL, rec.Code)
+		}
+	}
+}
+
+func TestHandler_InvalidStateBodies(t *testing.T) {
+	h := New(Config{}).Handler()
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodPatch, "/items/"+primitive.NewObjectID().Hex()+"/state", strings.NewReader(`{}`)),
+		httptest.NewRequest(http.MethodPatch, "/items/"+primitive.NewObjectID().Hex()+"/state", strings.NewReader(`{"read": "yes"}`)),
+		httptest.NewRequest(http.MethodPatch, "/items/not-an-id/state", strings.NewReader(`{"read": true}`)),
+		httptest.NewRequest(http.MethodPost, "/mark-read", strings.NewReader(`{"all": true, "feed": "Go Blog"}`)),
+		httptest.NewRequest(http.MethodPost, "/mark-read", strings.NewReader(`{"category": "Go", "unknown": 1}`)),
+	} {
+		r = r.WithContext(withPrincipal(r.Context(), principal{user: "alice"}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want 400", r.Method, r.URL, rec.Code)
+		}
+	}
+}
+
+func TestHandler_Authentication(t *testing.T) {
+	accounts := account.NewStore(nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	New(Config{Accounts: accounts}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("GET /items without a key: status = %d, want 401 with a challenge", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	New(Config{Accounts: accounts}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("GET /healthz without a key: status = %d, want 204", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	New(Config{Accounts: accounts, Anonymous: true}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unread", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous GET /unread: status = %d, want 401", rec.Code)
+	}
+	var body errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Error != errUserRequired.Error() {
+		t.Errorf("expected anonymous requests to reach the handler, got %q (%v)", body.Error, err)
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/syndication/atom?key=fa_query", nil)
+	if got := requestKey(r); got != "fa_query" {
+		t.Errorf("requestKey() = %q, want the key parameter", got)
+	}
+	r.Header.Set("Authorization", "Bearer fa_header")
+	if got := requestKey(r); got != "fa_header" {
+		t.Errorf("requestKey() = %q, want the bearer token", got)
+	}
+	if got := redactQuery(r.URL); strings.Contains(got, "fa_query") {
+		t.Errorf("redactQuery() = %q, should not contain the key", got)
+	}
+}
+
+func TestVisible(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/items", nil)
+	if !visible(r, "https://go.dev/blog/feed.atom") || visibleFeeds(r) != nil {
+		t.Error("expected anonymous requests to see all feeds")
+	}
+
+	r = r.WithContext(withPrincipal(r.Context(), principal{user: "alice", feedURLs: []string{"https://go.dev/blog/feed.atom"}}))
+	if !visible(r, "https://go.dev/blog/feed.atom") || visible(r, "https://blog.rust-lang.org/feed.xml") {
+		t.Error("expected users to see the feeds they subscribe to only")
+	}
+
+	r = r.WithContext(withPrincipal(r.Context(), principal{user: "bob", feedURLs: []string{}}))
+	if visible(r, "https://go.dev/blog/feed.atom") || visibleFeeds(r) == nil {
+		t.Error("expected users without subscriptions to see no feeds")
+	}
+}
+
+func TestHandler_SubscriptionsRequireUser(t *testing.T) {
+	h := New(Config{}).Handler()
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/subscriptions", nil),
+		httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(`{"title": "Go Blog", "xmlUrl": "https://go.dev/blog/feed.atom"}`)),
+		httptest.NewRequest(http.MethodDelete, "/subscriptions/"+primitive.NewObjectID().Hex(), nil),
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status = %d, want 401", r.Method, r.URL, rec.Code)
+		}
+	}
+}
+
+func TestParseStateUpdate(t *testing.T) {
+	read := true
+	u, err := parseStateUpdate(StateUpdate{Read: &read})
+	if err != nil || u.Read == nil || !*u.Read {
+		t.Errorf("parseStateUpdate() = %+v, %v", u, err)
+	}
+
+	long := strings.Repeat("é", maxNotesLength+1)
+	for _, req := range []StateUpdate{{}, {Notes: &long}} {
+		if _, err := parseStateUpdate(req); err == nil {
+			t.Errorf("parseStateUpdate(%+v) expected error", req)
+		}
+	}
+}
+
+func TestParseMarkRead(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	earlier, later := now.Add(-time.Hour), now.Add(time.Hour)
+
+	cases := []struct {
+		req    MarkReadRequest
+		scope  string
+		value  string
+		before time.Time
+	}{
+		{MarkReadRequest{All: true}, itemstate.ScopeAll, "", now},
+		{MarkReadRequest{Category: "Security", Before: &earlier}, itemstate.ScopeCategory, "Security", earlier},
+		{MarkReadRequest{Feed: "Go Blog", Before: &later}, itemstate.ScopeFeed, "Go Blog", now},
+	}
+	for _, c := range cases {
+		scope, value, before, err := parseMarkRead(c.req, now)
+		if err != nil || scope != c.scope || value != c.value || !before.Equal(c.before) {
+			t.Errorf("parseMarkRead(%+v) = %s %q %v %v", c.req, scope, value, before, err)
+		}
+	}
+	for _, req := range []MarkReadRequest{{}, {All: true, Category: "Security"}, {Category: "Security", Feed: "Go Blog"}} {
+		if _, _, _, err := parseMarkRead(req, now); err == nil {
+			t.Errorf("parseMarkRead(%+v) expected error", req)
+		}
+	}
+}
+
+func TestHandler_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Config{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/items", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /items: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestWriteError_HidesServerErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeError(rec, httptest.NewRequest(http.MethodGet, "/items", nil), http.StatusInternalServerError, errors.New("connection refused"))
+
+	var body errorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Error != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("error = %q, should not expose the cause", body.Error)
+	}
+}
+
+func TestNewItem(t *testing.T) {
+	doc := internal.FeedItemDocument{ID: primitive.NewObjectID(), Title: "Title", Status: internal.StatusPending}
+
+	item := newItem(doc)
+	if item.ID != doc.ID.Hex() || item.Title != "Title" || item.Status != internal.StatusPending {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.Categories == nil {
+		t.Error("categories should be an empty array rather than null")
+	}
+	if item.ClusterID != "" {
+		t.Errorf("cluster ID = %q, want empty for unclustered items", item.ClusterID)
+	}
+}
+
+func TestParseSearchRequest(t *testing.T) {
+	q := url.Values{
+		"q":        {`"log bridge" opentelemetry -java`},
+		"category": {"DevOps & Infrastructure"},
+		"language": {"en"},
+		"from":     {"2024-05-01"},
+		"limit":    {"10"},
+	}
+
+	query, err := parseSearchRequest(q)
+	if err != nil {
+		t.Fatalf("parseSearchRequest() error = %v", err)
+	}
+	if query.Text != `"log bridge" opentelemetry -java` || query.Category != "DevOps & Infrastructure" || query.Language != "en" {
+		t.Errorf("unexpected query: %+v", query)
+	}
+	if !query.From.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || !query.To.IsZero() {
+		t.Errorf("unexpected date range: %v - %v", query.From, query.To)
+	}
+	if query.Limit != 10 {
+		t.Errorf("limit = %d, want 10", query.Limit)
+	}
+}
+
+func TestParseSearchRequest_Invalid(t *testing.T) {
+	for _, q := range []url.Values{
+		{},
+		{"q": {"  "}},
+		{"q": {"otel"}, "from": {"last month"}},
+		{"q": {"otel"}, "limit": {"500"}},
+	} {
+		if _, err := parseSearchRequest(q); err == nil {
+			t.Errorf("parseSearchRequest(%v) expected error", q)
+		}
+	}
+}
+
+func TestSearchItem_JSON(t *testing.T) {
+	b, err := json.Marshal(SearchItem{Item: Item{ID: "id", Title: "Title"}, Score: 1.5})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if fields["title"] != "Title" || fields["score"] != 1.5 {
+		t.Errorf("item fields should be inlined next to the score, got %s", b)
+	}
+}
+
+func TestHandler_SemanticSearch(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Config{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/semantic?q=go", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without embeddings: status = %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	New(Config{Vectors: vector.NewLiveIndex(nil, "model")}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/semantic", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without a query: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandler_SyndicationUnknownFormat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(Config{}).Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/syndication/opml", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestNewSyndicationFeed(t *testing.T) {
+	published := time.Date(2024, 6, 25, 8, 0, 0, 0, time.UTC)
+	docs := []internal.FeedItemDocument{
+		{ID: primitive.NewObjectID(), Title: "Newest", Link: "https://example.com/a", Summary: "Summary", Categories: []string{"CI/CD"},
+			Feed: "Example", FeedURL: "https://example.com/feed.xml", CreatedAt: time.Date(2024, 6, 26, 0, 0, 0, 0, time.UTC)},
+		{ID: primitive.NewObjectID(), Title: "Older", PublishedAt: &published, CreatedAt: time.Date(2024, 6, 25, 9, 0, 0, 0, time.UTC)},
+	}
+
+	feed := newSyndicationFeed("https://feeds.example.com", "/syndication/atom/category/CI%2FCD", feeditem.Filter{Category: "CI/CD"}, docs)
+
+	if feed.Title != "Feeds Aggregator: CI/CD" {
+		t.Errorf("title = %q", feed.Title)
+	}
+	if feed.FeedURL != "https://feeds.example.com/syndication/atom/category/CI%2FCD" || feed.ID != feed.FeedURL {
+		t.Errorf("feed URL = %q, ID = %q", feed.FeedURL, feed.ID)
+	}
+	if !feed.Updated.Equal(docs[0].CreatedAt) {
+		t.Errorf("updated = %v, want the newest item", feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+	if e := feed.Entries[0]; e.ID != "https://feeds.example.com/items/"+docs[0].ID.Hex() || e.Summary != "Summary" || e.Source != "Example" || !e.Published.Equal(docs[0].CreatedAt) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e := feed.Entries[1]; !e.Published.Equal(published) {
+		t.Errorf("published = %v, want the publication date of the item", e.Published)
+	}
+}
+
+func TestPublicURL(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/syndication/rss", nil)
+	r.Host = "internal:8080"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "feeds.example.com")
+
+	if got := New(Config{}).publicURL(r); got != "https://feeds.example.com" {
+		t.Errorf("derived URL = %q", got)
+	}
+	if got := New(Config{BaseURL: "https://api.example.com/"}).publicURL(r); got != "https://api.example.com" {
+		t.Errorf("configured URL = %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+)
+
+// keyParam is the query parameter carrying the API key for clients that cannot set headers, such as
+// feed readers subscribing to the output feeds
+const keyParam = "key"
+
+// errUserRequired is returned for requests that need a user without an API key
+var errUserRequired = errors.New("an API key is required")
+
+// principal is the authenticated user of a request with the feeds they subscribe to
+type principal struct {
+	user     string
+	feedURLs []string
+}
+
+// principalKey is the context key of the principal of a request
+type principalKey struct{}
+
+// withPrincipal returns a copy of ctx carrying p.
+func withPrincipal(ctx context.Context, p principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// requestUser returns the user of the request, empty for anonymous requests.
+func requestUser(r *http.Request) string {
+	p, _ := r.Context().Value(principalKey{}).(principal)
+	return p.user
+}
+
+// visibleFeeds returns the URLs of the feeds whose items the user of the request may see, or nil
+// when anonymous requests see all items.
+func visibleFeeds(r *http.Request) []string {
+	p, ok := r.Context().Value(principalKey{}).(principal)
+	if !ok {
+		return nil
+	}
+	return p.feedURLs
+}
+
+// visible reports whether the user of the request may see items of the feed at feedURL.
+func visible(r *http.Request, feedURL string) bool {
+	urls := visibleFeeds(r)
+	return urls == nil || slices.Contains(urls, feedURL)
+}
+
+// requestKey returns the API key of the request from the Authorization bearer token or the key
+// query parameter.
+func requestKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.URL.Query().Get(keyParam)
+}
+
+// authenticate resolves the API key of each request to its user and their subscriptions. Requests
+// without a key are rejected unless anonymous access is allowed; the health check and servers
+// without accounts are always open.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.accounts == nil || r.URL.Path == "/healthz" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := requestKey(r)
+		if key == "" {
+			if s.anonymous {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="feeds"`)
+			writeError(w, r, http.StatusUnauthorized, errUserRequired)
+			return
+		}
+
+		user, err := s.accounts.Authenticate(r.Context(), key)
+		if errors.Is(err, account.ErrUnauthorized) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="feeds", error="invalid_token"`)
+			writeError(w, r, http.StatusUnauthorized, err)
+			return
+		}
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		urls, err := s.accounts.FeedURLs(r.Context(), user.ID)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal{user: user.ID, feedURLs: urls})))
+	})
+}
+
+// redactQuery returns the raw query of u with the API key replaced, for logging.
+func redactQuery(u *url.URL) string {
+	q := u.Query()
+	if !q.Has(keyParam) {
+		return u.RawQuery
+	}
+	q.Set(keyParam, "REDACTED")
+	return q.Encode()
+}
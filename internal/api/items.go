@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// dateLayout is accepted for date filters besides RFC 3339 timestamps
+const dateLayout = "2006-01-02"
+
+// statuses are the valid values of the status filter
+var statuses = []string{internal.StatusPending, internal.StatusProcessed, internal.StatusFailed}
+
+// listRequest holds the parsed parameters of a list items request
+type listRequest struct {
+	Filter feeditem.Filter
+	// State lists the items of the user in an item state when not empty
+	State string
+	After *feeditem.Cursor
+	Limit int
+}
+
+// parseListRequest parses the query parameters category, feed, status, from, to, sort, state, cursor
+// and limit.
+func parseListRequest(q url.Values) (listRequest, error) {
+	req := listRequest{
+		Filter: feeditem.Filter{
+			Category: q.Get("category"),
+			Feed:     q.Get("feed"),
+			Status:   q.Get("status"),
+			Sort:     q.Get("sort"),
+		},
+		State: q.Get("state"),
+		Limit: defaultLimit,
+	}
+	if req.Filter.Status != "" && !slices.Contains(statuses, req.Filter.Status) {
+		return req, fmt.Errorf("status must be one of %v", statuses)
+	}
+	if req.Filter.Sort != "" && !slices.Contains(feeditem.Sorts, req.Filter.Sort) {
+		return req, fmt.Errorf("sort must be one of %v", feeditem.Sorts)
+	}
+	if req.State != "" && !slices.Contains(itemstate.States, req.State) {
+		return req, fmt.Errorf("state must be one of %v", itemstate.States)
+	}
+	if (req.State == itemstate.StateStarred || req.State == itemstate.StateArchived) && (req.Filter.Status != "" || req.Filter.Sort == feeditem.SortRelevance) {
+		return req, fmt.Errorf("%s items are listed newest first and cannot be filtered by status", req.State)
+	}
+
+	var err error
+	if req.Filter.From, err = parseTime(q.Get("from")); err != nil {
+		return req, fmt.Errorf("from: %w", err)
+	}
+	if req.Filter.To, err = parseTime(q.Get("to")); err != nil {
+		return req, fmt.Errorf("to: %w", err)
+	}
+
+	if s := q.Get("limit"); s != "" {
+		req.Limit, err = strconv.Atoi(s)
+		if err != nil || req.Limit < 1 || req.Limit > maxLimit {
+			return req, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+	}
+
+	if s := q.Get("cursor"); s != "" {
+		after, err := feeditem.DecodeCursor(s)
+		if err != nil {
+			return req, err
+		}
+		req.After = &after
+	}
+	return req, nil
+}
+
+// parseTime parses an RFC 3339 timestamp or a date. An empty string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// listItems serves GET /items, newest or most relevant first with cursor-based pagination. Users
+// see the items of the feeds they subscribe to with their state, and the state parameter lists
+// their unread, starred or archived items.
+func (s *Server) listItems(w http.ResponseWriter, r *http.Request) {
+	req, err := parseListRequest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	user := requestUser(r)
+	if req.State != "" && user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	req.Filter.FeedURLs = visibleFeeds(r)
+
+	var page feeditem.Page
+	if req.State != "" {
+		page, err = s.states.List(r.Context(), user, req.State, req.Filter, req.After, req.Limit)
+	} else {
+		page, err = s.items.List(r.Context(), req.Filter, req.After, req.Limit)
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err := s.newItems(r.Context(), user, page.Items)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	list := ItemList{Items: items}
+	if page.Next != nil {
+		list.NextCursor = page.Next.Encode()
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// getItem serves GET /items/{id}, with the state of the user.
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.New("invalid item ID"))
+		return
+	}
+
+	doc, err := s.items.Get(r.Context(), id)
+	if errors.Is(err, feeditem.ErrNotFound) || (err == nil && !visible(r, doc.FeedURL)) {
+		writeError(w, r, http.StatusNotFound, feeditem.ErrNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	items, err := s.newItems(r.Context(), requestUser(r), []internal.FeedItemDocument{doc})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, items[0])
+}
+
+// listCategories serves GET /categories: the taxonomy with the item counts of the feeds the user
+// may see, followed by the categories found on those items outside the taxonomy.
+func (s *Server) listCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := s.taxonomy.List(r.Context())
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	counts, err := s.taxonomy.Counts(r.Context(), visibleFeeds(r))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]Category, 0, len(categories))
+	for _, c := range categories {
+		result = append(result, Category{Name: c.Name, Description: c.Description, Parent: c.Parent, Aliases: c.Aliases, Items: counts[c.Name], Managed: true})
+		delete(counts, c.Name)
+	}
+	unmanaged := make([]string, 0, len(counts))
+	for name := range counts {
+		unmanaged = append(unmanaged, name)
+	}
+	sort.Strings(unmanaged)
+	for _, name := range unmanaged {
+		result = append(result, Category{Name: name, Items: counts[name]})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// listFeeds serves GET /feeds: the feeds items were ingested from, those the user subscribes to
+// for users.
+func (s *Server) listFeeds(w http.ResponseWriter, r *http.Request) {
+	feeds, err := s.items.Feeds(r.Context(), visibleFeeds(r))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if feeds == nil {
+		feeds = []feeditem.Feed{}
+	}
+	writeJSON(w, http.StatusOK, feeds)
+}
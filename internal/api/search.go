@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// semanticCandidates is the number of nearest items a semantic search reads before keeping those
+// the user may see and the filters match
+const semanticCandidates = 500
+
+// errSemanticSearchDisabled is returned for semantic searches when no embeddings are configured
+var errSemanticSearchDisabled = errors.New("semantic search is not enabled")
+
+// SearchItem is a feed item matching a search with its relevance score
+type SearchItem struct {
+	Item
+	Score float64 `json:"score"`
+}
+
+// SearchResults are the feed items matching a search, most relevant first
+type SearchResults struct {
+	Items []SearchItem `json:"items"`
+}
+
+// parseSearchRequest parses the query parameters q, category, language, from, to and limit.
+func parseSearchRequest(q url.Values) (feeditem.SearchQuery, error) {
+	query := feeditem.SearchQuery{
+		Text:     strings.TrimSpace(q.Get("q")),
+		Category: q.Get("category"),
+		Language: q.Get("language"),
+		Limit:    defaultLimit,
+	}
+	if query.Text == "" {
+		return query, errors.New("q must not be empty")
+	}
+
+	var err error
+	if query.From, err = parseTime(q.Get("from")); err != nil {
+		return query, fmt.Errorf("from: %w", err)
+	}
+	if query.To, err = parseTime(q.Get("to")); err != nil {
+		return query, fmt.Errorf("to: %w", err)
+	}
+	if s := q.Get("limit"); s != "" {
+		query.Limit, err = strconv.Atoi(s)
+		if err != nil || query.Limit < 1 || query.Limit > maxLimit {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	return query, nil
+}
+
+// search serves GET /search, a full-text search over titles, summaries and article text of the
+// items the user may see. Quoted phrases must match exactly and terms prefixed with "-" exclude items.
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchRequest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	query.FeedURLs = visibleFeeds(r)
+	results, err := s.items.Search(r.Context(), query)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	docs := make([]internal.FeedItemDocument, 0, len(results))
+	for _, result := range results {
+		docs = append(docs, result.FeedItemDocument)
+	}
+	items, err := s.newItems(r.Context(), requestUser(r), docs)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	list := SearchResults{Items: make([]SearchItem, 0, len(results))}
+	for i, result := range results {
+		list.Items = append(list.Items, SearchItem{Item: items[i], Score: result.Score})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// semanticSearch serves GET /search/semantic, which ranks the processed items the user may see by the
+// similarity of their embeddings to the embedded query, so items match by meaning rather than by
+// words. It filters by category, from and to like /search.
+func (s *Server) semanticSearch(w http.ResponseWriter, r *http.Request) {
+	if s.vectors == nil {
+		writeError(w, r, http.StatusNotFound, errSemanticSearchDisabled)
+		return
+	}
+	query, err := parseSearchRequest(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	vectors, err := s.embedder.Embed(r.Context(), []string{query.Text})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	results := s.vectors.Search(vectors[0], semanticCandidates)
+	ids := make([]primitive.ObjectID, 0, len(results))
+	for _, result := range results {
+		if id, err := primitive.ObjectIDFromHex(result.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	found, err := s.items.Find(r.Context(), ids, feeditem.Filter{
+		Category: query.Category,
+		FeedURLs: visibleFeeds(r),
+		Status:   internal.StatusProcessed,
+		From:     query.From,
+		To:       query.To,
+	})
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	byID := make(map[string]internal.FeedItemDocument, len(found))
+	for _, doc := range found {
+		byID[doc.ID.Hex()] = doc
+	}
+
+	// Keep the similarity order of the index
+	docs := make([]internal.FeedItemDocument, 0, query.Limit)
+	scores := make([]float64, 0, query.Limit)
+	for _, result := range results {
+		doc, ok := byID[result.ID]
+		if !ok {
+			continue
+		}
+		docs = append(docs, doc)
+		scores = append(scores, result.Score)
+		if len(docs) == query.Limit {
+			break
+		}
+	}
+	items, err := s.newItems(r.Context(), requestUser(r), docs)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	list := SearchResults{Items: make([]SearchItem, 0, len(docs))}
+	for i := range docs {
+		list.Items = append(list.Items, SearchItem{Item: items[i], Score: scores[i]})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
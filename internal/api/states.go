@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxBodySize is the largest request body accepted
+	maxBodySize = 64 << 10
+	// maxNotesLength is the longest notes a user may keep on an item
+	maxNotesLength = 10000
+)
+
+// newItems converts feed item documents to their JSON representation, with the states of user when
+// the request has one.
+func (s *Server) newItems(ctx context.Context, user string, docs []internal.FeedItemDocument) ([]Item, error) {
+	items := make([]Item, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, newItem(doc))
+	}
+	if user == "" || len(docs) == 0 {
+		return items, nil
+	}
+
+	markers, err := s.states.Markers(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	states, err := s.states.States(ctx, user, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		state := newItemState(markers, states[doc.ID], doc)
+		items[i].State = &state
+	}
+	return items, nil
+}
+
+// decodeBody decodes the JSON request body into v, rejecting unknown fields.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// parseStateUpdate validates an item state update.
+func parseStateUpdate(req StateUpdate) (itemstate.Update, error) {
+	u := itemstate.Update{Read: req.Read, Starred: req.Starred, Archived: req.Archived, Notes: req.Notes}
+	if u.Read == nil && u.Starred == nil && u.Archived == nil && u.Notes == nil {
+		return u, itemstate.ErrInvalidUpdate
+	}
+	if u.Notes != nil && len([]rune(*u.Notes)) > maxNotesLength {
+		return u, fmt.Errorf("notes must be at most %d characters", maxNotesLength)
+	}
+	return u, nil
+}
+
+// updateItemState serves PATCH /items/{id}/state, marking an item read or unread, starring,
+// archiving or annotating it for the user.
+func (s *Server) updateItemState(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.New("invalid item ID"))
+		return
+	}
+	var req StateUpdate
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	update, err := parseStateUpdate(req)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	doc, err := s.items.Get(r.Context(), id)
+	if errors.Is(err, feeditem.ErrNotFound) || (err == nil && !visible(r, doc.FeedURL)) {
+		writeError(w, r, http.StatusNotFound, feeditem.ErrNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	st, err := s.states.Update(r.Context(), user, doc, update)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	markers, err := s.states.Markers(r.Context(), user)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newItemState(markers, st, doc))
+}
+
+// parseMarkRead validates a bulk read mark and returns its scope, value and time at now.
+func parseMarkRead(req MarkReadRequest, now time.Time) (scope, value string, before time.Time, err error) {
+	before = now
+	if req.Before != nil && req.Before.Before(now) {
+		before = *req.Before
+	}
+	set := 0
+	for _, ok := range []bool{req.All, req.Category != "", req.Feed != ""} {
+		if ok {
+			set++
+		}
+	}
+	switch {
+	case set != 1:
+		return "", "", before, errors.New("exactly one of all, category and feed must be set")
+	case req.Category != "":
+		return itemstate.ScopeCategory, req.Category, before, nil
+	case req.Feed != "":
+		return itemstate.ScopeFeed, req.Feed, before, nil
+	default:
+		return itemstate.ScopeAll, "", before, nil
+	}
+}
+
+// markRead serves POST /mark-read, marking all items, or those of a category or feed, created up to
+// a time as read for the user.
+func (s *Server) markRead(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	var req MarkReadRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	scope, value, before, err := parseMarkRead(req, time.Now().UTC())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.states.MarkRead(r.Context(), user, scope, value, before); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// unreadCounts serves GET /unread: the number of unread items of the user by category, in the
+// feeds they subscribe to.
+func (s *Server) unreadCounts(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	counts, err := s.states.UnreadCounts(r.Context(), user, visibleFeeds(r))
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, UnreadCounts{Categories: counts})
+}
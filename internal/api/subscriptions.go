@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// listSubscriptions serves GET /subscriptions: the feed subscriptions of the user.
+func (s *Server) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	subscriptions, err := s.accounts.Subscriptions(r.Context(), user)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		result = append(result, newSubscription(sub))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// subscribe serves POST /subscriptions, subscribing the user to a feed. The ingester starts polling
+// the feed in its next cycle unless another user already subscribes to it.
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	var req SubscriptionRequest
+	if err := decodeBody(w, r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	sub, err := s.accounts.Subscribe(r.Context(), account.Subscription{
+		User:    user,
+		Title:   strings.TrimSpace(req.Title),
+		URL:     strings.TrimSpace(req.XMLURL),
+		Type:    req.Type,
+		Filters: req.Filters,
+	})
+	switch {
+	case errors.Is(err, account.ErrInvalidSubscription):
+		writeError(w, r, http.StatusBadRequest, err)
+	case errors.Is(err, account.ErrDuplicateSubscription):
+		writeError(w, r, http.StatusConflict, err)
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, err)
+	default:
+		writeJSON(w, http.StatusCreated, newSubscription(sub))
+	}
+}
+
+// unsubscribe serves DELETE /subscriptions/{id}. Items already ingested from the feed are no longer
+// shown to the user.
+func (s *Server) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	user := requestUser(r)
+	if user == "" {
+		writeError(w, r, http.StatusUnauthorized, errUserRequired)
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, errors.New("invalid subscription ID"))
+		return
+	}
+
+	err = s.accounts.Unsubscribe(r.Context(), user, id)
+	if errors.Is(err, account.ErrSubscriptionNotFound) {
+		writeError(w, r, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
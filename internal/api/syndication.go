@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"github.com/demeyerthom/feeds-aggregator/internal/syndication"
+)
+
+// feedTitle is the title of output feeds, suffixed with their selection
+const feedTitle = "Feeds Aggregator"
+
+// syndicate serves the output feeds: all processed items, the items of a category or the items of
+// a source feed, as Atom, RSS 2.0 or JSON Feed 1.1. Feed readers pass the API key of a user in the
+// key parameter to get the items of their subscriptions.
+func (s *Server) syndicate(w http.ResponseWriter, r *http.Request) {
+	format := r.PathValue("format")
+	if !slices.Contains(syndication.Formats, format) {
+		writeError(w, r, http.StatusNotFound, fmt.Errorf("format must be one of %v", syndication.Formats))
+		return
+	}
+
+	filter := feeditem.Filter{
+		Category: r.PathValue("category"),
+		Feed:     r.PathValue("feed"),
+		FeedURLs: visibleFeeds(r),
+		Status:   internal.StatusProcessed,
+	}
+	page, err := s.items.List(r.Context(), filter, nil, s.feedSize)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	feed := newSyndicationFeed(s.publicURL(r), r.URL.EscapedPath(), filter, page.Items)
+	w.Header().Set("Content-Type", syndication.ContentType(format))
+	if err := syndication.Write(w, format, feed); err != nil {
+		writeError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// newSyndicationFeed creates the output feed of docs, selected by filter and served from path.
+func newSyndicationFeed(baseURL, path string, filter feeditem.Filter, docs []internal.FeedItemDocument) syndication.Feed {
+	feed := syndication.Feed{
+		ID:          baseURL + path,
+		Title:       feedTitle,
+		Description: "Summaries of all processed feed items",
+		HomeURL:     baseURL + "/",
+		FeedURL:     baseURL + path,
+		Updated:     time.Now().UTC(),
+	}
+	switch {
+	case filter.Category != "":
+		feed.Title += ": " + filter.Category
+		feed.Description = "Summaries of feed items in the " + filter.Category + " category"
+	case filter.Feed != "":
+		feed.Title += ": " + filter.Feed
+		feed.Description = "Summaries of feed items from " + filter.Feed
+	}
+
+	for _, doc := range docs {
+		published := doc.CreatedAt
+		if doc.PublishedAt != nil {
+			published = *doc.PublishedAt
+		}
+		feed.Entries = append(feed.Entries, syndication.Entry{
+			ID:         baseURL + "/items/" + doc.ID.Hex(),
+			Title:      doc.Title,
+			Link:       doc.Link,
+			Summary:    doc.Summary,
+			Categories: doc.Categories,
+			Source:     doc.Feed,
+			SourceURL:  doc.FeedURL,
+			Published:  published,
+			Updated:    doc.CreatedAt,
+		})
+	}
+	// Items are listed newest first, so the first one was updated last
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	}
+	return feed
+}
+
+// publicURL returns the configured base URL, or derives it from the request behind a proxy.
+func (s *Server) publicURL(r *http.Request) string {
+	if s.baseURL != "" {
+		return s.baseURL
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/account"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	"github.com/demeyerthom/feeds-aggregator/internal/feedfilter"
+	"github.com/demeyerthom/feeds-aggregator/internal/itemstate"
+)
+
+// Item is the JSON representation of a feed item
+type Item struct {
+	ID              string                `json:"id"`
+	Link            string                `json:"link"`
+	Title           string                `json:"title"`
+	Feed            string                `json:"feed,omitempty"`
+	FeedURL         string                `json:"feedUrl,omitempty"`
+	FeedType        string                `json:"feedType,omitempty"`
+	Status          string                `json:"status,omitempty"`
+	Error           string                `json:"error,omitempty"`
+	Summary         string                `json:"summary,omitempty"`
+	OriginalSummary string                `json:"originalSummary,omitempty"`
+	Language        string                `json:"language,omitempty"`
+	SummaryLanguage string                `json:"summaryLanguage,omitempty"`
+	Categories      []string              `json:"categories"`
+	Entities        []entity.Entity       `json:"entities,omitempty"`
+	Keywords        []string              `json:"keywords,omitempty"`
+	Release         *internal.Release     `json:"release,omitempty"`
+	Alerts          []internal.AlertMatch `json:"alerts,omitempty"`
+	Relevance       *internal.Relevance   `json:"relevance,omitempty"`
+	ClusterID       string                `json:"clusterId,omitempty"`
+	Suspicious      bool                  `json:"suspicious,omitempty"`
+	Provider        string                `json:"provider,omitempty"`
+	Model           string                `json:"model,omitempty"`
+	PublishedAt     *time.Time            `json:"publishedAt,omitempty"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	// State is the state of the item for the user of the request, omitted for anonymous requests
+	State *ItemState `json:"state,omitempty"`
+}
+
+// ItemState is the state of a feed item for a user
+type ItemState struct {
+	Read       bool       `json:"read"`
+	Starred    bool       `json:"starred"`
+	Archived   bool       `json:"archived"`
+	Notes      string     `json:"notes,omitempty"`
+	ReadAt     *time.Time `json:"readAt,omitempty"`
+	StarredAt  *time.Time `json:"starredAt,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+// StateUpdate is the body of an item state update; omitted fields are left as they are and empty
+// notes remove them
+type StateUpdate struct {
+	Read     *bool   `json:"read"`
+	Starred  *bool   `json:"starred"`
+	Archived *bool   `json:"archived"`
+	Notes    *string `json:"notes"`
+}
+
+// MarkReadRequest is the body of a bulk read mark, setting exactly one of All, Category and Feed
+type MarkReadRequest struct {
+	All      bool   `json:"all,omitempty"`
+	Category string `json:"category,omitempty"`
+	Feed     string `json:"feed,omitempty"`
+	// Before marks the items created up to this time, now when omitted, so items that arrived after
+	// the client loaded its list stay unread
+	Before *time.Time `json:"before,omitempty"`
+}
+
+// UnreadCounts holds the number of unread items of a user by category
+type UnreadCounts struct {
+	Categories map[string]int64 `json:"categories"`
+}
+
+// ItemList is a page of feed items
+type ItemList struct {
+	Items []Item `json:"items"`
+	// NextCursor is passed as the cursor parameter to get the next page; empty on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Category is a category with the number of feed items carrying it
+type Category struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Parent      string   `json:"parent,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Items       int64    `json:"items"`
+	// Managed is false for categories the LLM created outside the taxonomy
+	Managed bool `json:"managed"`
+}
+
+// Subscription is the subscription of a user to a feed
+type Subscription struct {
+	ID        string            `json:"id"`
+	Title     string            `json:"title"`
+	XMLURL    string            `json:"xmlUrl"`
+	Type      string            `json:"type,omitempty"`
+	Filters   []feedfilter.Rule `json:"filters,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// SubscriptionRequest is the body of a new subscription, in the format of the entries of feeds.json
+type SubscriptionRequest struct {
+	Title   string            `json:"title"`
+	XMLURL  string            `json:"xmlUrl"`
+	Type    string            `json:"type,omitempty"`
+	Filters []feedfilter.Rule `json:"filters,omitempty"`
+}
+
+// newItem converts a feed item document to its JSON representation.
+func newItem(doc internal.FeedItemDocument) Item {
+	item := Item{
+		ID:              doc.ID.Hex(),
+		Link:            doc.Link,
+		Title:           doc.Title,
+		Feed:            doc.Feed,
+		FeedURL:         doc.FeedURL,
+		FeedType:        doc.FeedType,
+		Status:          doc.Status,
+		Error:           doc.Error,
+		Summary:         doc.Summary,
+		OriginalSummary: doc.OriginalSummary,
+		Language:        doc.Language,
+		SummaryLanguage: doc.SummaryLanguage,
+		Categories:      doc.Categories,
+		Entities:        doc.Entities,
+		Keywords:        doc.Keywords,
+		Release:         doc.Release,
+		Alerts:          doc.Alerts,
+		Relevance:       doc.Relevance,
+		Suspicious:      doc.Suspicious,
+		Provider:        doc.Provider,
+		Model:           doc.Model,
+		PublishedAt:     doc.PublishedAt,
+		CreatedAt:       doc.CreatedAt,
+	}
+	if item.Categories == nil {
+		item.Categories = []string{}
+	}
+	if !doc.ClusterID.IsZero() {
+		item.ClusterID = doc.ClusterID.Hex()
+	}
+	return item
+}
+
+// newItemState converts the state st of the item doc, read unless marked otherwise when a marker
+// in m covers it, to its JSON representation.
+func newItemState(m itemstate.Markers, st itemstate.State, doc internal.FeedItemDocument) ItemState {
+	state := ItemState{
+		Read:       m.IsRead(st, doc),
+		Starred:    st.Starred,
+		Archived:   st.Archived,
+		Notes:      st.Notes,
+		ReadAt:     st.ReadAt,
+		StarredAt:  st.StarredAt,
+		ArchivedAt: st.ArchivedAt,
+	}
+	if !st.UpdatedAt.IsZero() {
+		state.UpdatedAt = &st.UpdatedAt
+	}
+	return state
+}
+
+// newSubscription converts a subscription to its JSON representation.
+func newSubscription(sub account.Subscription) Subscription {
+	return Subscription{
+		ID:        sub.ID.Hex(),
+		Title:     sub.Title,
+		XMLURL:    sub.URL,
+		Type:      sub.Type,
+		Filters:   sub.Filters,
+		CreatedAt: sub.CreatedAt,
+	}
+}
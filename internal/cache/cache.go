@@ -0,0 +1,63 @@
+// Package cache provides a Redis-backed cache for LLM responses, keyed by a hash of the input
+// text, the model and the prompt version so that a change to either invalidates the entry.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix is the Redis key prefix for cached LLM responses
+const keyPrefix = "feeds:llm:cache:"
+
+// ResponseCache stores JSON-encoded LLM results in Redis with a fixed TTL
+type ResponseCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewResponseCache creates a response cache whose entries expire after ttl.
+func NewResponseCache(rdb *redis.Client, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{rdb: rdb, ttl: ttl}
+}
+
+// Key returns the cache key for text processed by model with the given prompt version.
+func Key(text, model, promptVersion string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(promptVersion))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return keyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// Get decodes the entry stored under key into v. It reports whether an entry was found.
+func (c *ResponseCache) Get(ctx context.Context, key string, v any) (bool, error) {
+	b, err := c.rdb.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set stores v under key.
+func (c *ResponseCache) Set(ctx context.Context, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, key, b, c.ttl).Err()
+}
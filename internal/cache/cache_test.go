@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	key := Key("text", "model", "v1")
+
+	if !strings.HasPrefix(key, keyPrefix) {
+		t.Fatalf("expected key to be namespaced, got %q", key)
+	}
+	if key != Key("text", "model", "v1") {
+		t.Error("expected key to be deterministic")
+	}
+
+	others := []string{
+		Key("other text", "model", "v1"),
+		Key("text", "other-model", "v1"),
+		Key("text", "model", "v2"),
+		// The separator prevents ambiguity between adjacent fields
+		Key("text", "model", "v"+"1"+"\x00"),
+		Key("1\x00text", "model", "v"),
+	}
+	for _, other := range others {
+		if other == key {
+			t.Errorf("expected different inputs to produce a different key")
+		}
+	}
+}
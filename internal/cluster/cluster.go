@@ -0,0 +1,100 @@
+// Package cluster groups near-duplicate feed items from different feeds into story clusters, so a
+// story covered by several sources is shown once with all of its sources.
+package cluster
+
+import (
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/simhash"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Methods by which an item joined a cluster
+const (
+	MethodOrigin    = "origin"
+	MethodSimHash   = "simhash"
+	MethodEmbedding = "embedding"
+)
+
+// Member is a feed item that belongs to a story cluster
+type Member struct {
+	ItemID  primitive.ObjectID `bson:"item_id" json:"itemId"`
+	Title   string             `bson:"title" json:"title"`
+	Link    string             `bson:"link" json:"link"`
+	Feed    string             `bson:"feed,omitempty" json:"feed,omitempty"`
+	Method  string             `bson:"method" json:"method"`
+	AddedAt time.Time          `bson:"added_at" json:"addedAt"`
+}
+
+// Cluster is a story covered by one or more feed items
+type Cluster struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title     string             `bson:"title" json:"title"`
+	Members   []Member           `bson:"members" json:"members"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// Candidate is a recent feed item a new item may be a near-duplicate of
+type Candidate struct {
+	ItemID    primitive.ObjectID
+	SimHash   uint64
+	ClusterID primitive.ObjectID
+	// Similarity is the embedding similarity to the new item, or 0 when unknown
+	Similarity float64
+}
+
+// Thresholds decide when two items are near-duplicates
+type Thresholds struct {
+	// MaxDistance is the largest SimHash distance, in bits, of near-duplicates
+	MaxDistance int
+	// MinSimilarity is the smallest embedding similarity of near-duplicates; 0 disables embeddings
+	MinSimilarity float64
+}
+
+// Match is the candidate a new item is a near-duplicate of
+type Match struct {
+	Candidate
+	Method   string
+	Distance int
+}
+
+// BestMatch returns the candidate hash is the nearest duplicate of. SimHash matches are preferred,
+// closest first; otherwise the most similar embedding match is used. On ties, candidates that
+// already belong to a cluster win so clusters grow instead of splitting. A fingerprint of 0, from
+// text without words, never matches on SimHash.
+func BestMatch(hash uint64, candidates []Candidate, t Thresholds) (Match, bool) {
+	var best Match
+	found := false
+	better := func(m Match) bool {
+		if !found {
+			return true
+		}
+		if m.Method != best.Method {
+			return m.Method == MethodSimHash
+		}
+		if m.Method == MethodSimHash && m.Distance != best.Distance {
+			return m.Distance < best.Distance
+		}
+		if m.Method == MethodEmbedding && m.Similarity != best.Similarity {
+			return m.Similarity > best.Similarity
+		}
+		return best.ClusterID.IsZero() && !m.ClusterID.IsZero()
+	}
+
+	for _, c := range candidates {
+		m := Match{Candidate: c, Distance: simhash.Distance(hash, c.SimHash)}
+		switch {
+		case hash != 0 && c.SimHash != 0 && m.Distance <= t.MaxDistance:
+			m.Method = MethodSimHash
+		case t.MinSimilarity > 0 && c.Similarity >= t.MinSimilarity:
+			m.Method = MethodEmbedding
+		default:
+			continue
+		}
+		if better(m) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBestMatch_PrefersClosestSimHash(t *testing.T) {
+	far := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xF7}
+	near := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xF1}
+	embedded := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xFF00, Similarity: 0.99}
+
+	m, ok := BestMatch(0xF0, []Candidate{far, embedded, near}, Thresholds{MaxDistance: 3, MinSimilarity: 0.9})
+	if !ok {
+		t.Fatal("expected a match")
+	}
+	if m.ItemID != near.ItemID || m.Method != MethodSimHash || m.Distance != 1 {
+		t.Errorf("expected closest SimHash match, got %+v", m)
+	}
+}
+
+func TestBestMatch_FallsBackToEmbedding(t *testing.T) {
+	a := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xFFFF, Similarity: 0.91}
+	b := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xFFFF, Similarity: 0.95}
+
+	m, ok := BestMatch(0, []Candidate{a, b}, Thresholds{MaxDistance: 3, MinSimilarity: 0.9})
+	if !ok || m.ItemID != b.ItemID || m.Method != MethodEmbedding {
+		t.Errorf("expected most similar embedding match, got %+v (ok=%v)", m, ok)
+	}
+
+	if _, ok := BestMatch(0, []Candidate{a, b}, Thresholds{MaxDistance: 3}); ok {
+		t.Error("expected no match when embedding similarity is disabled")
+	}
+}
+
+func TestBestMatch_PrefersExistingCluster(t *testing.T) {
+	loose := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xF1}
+	clustered := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xF2, ClusterID: primitive.NewObjectID()}
+
+	m, ok := BestMatch(0xF0, []Candidate{loose, clustered}, Thresholds{MaxDistance: 3})
+	if !ok || m.ItemID != clustered.ItemID {
+		t.Errorf("expected tie to be broken in favour of the clustered candidate, got %+v", m)
+	}
+}
+
+func TestBestMatch_NoMatch(t *testing.T) {
+	c := Candidate{ItemID: primitive.NewObjectID(), SimHash: 0xFFFF}
+	if _, ok := BestMatch(0x0001, []Candidate{c}, Thresholds{MaxDistance: 3}); ok {
+		t.Error("expected no match for distant fingerprints")
+	}
+	if _, ok := BestMatch(0x0001, nil, Thresholds{MaxDistance: 3}); ok {
+		t.Error("expected no match without candidates")
+	}
+}
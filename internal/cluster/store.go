@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store persists story clusters in MongoDB and links feed items to them
+type Store struct {
+	clusters  *mongo.Collection
+	feedItems *mongo.Collection
+}
+
+// NewStore creates a cluster store backed by the clusters and feed items collections.
+func NewStore(clusters, feedItems *mongo.Collection) *Store {
+	return &Store{clusters: clusters, feedItems: feedItems}
+}
+
+// EnsureIndexes creates the indexes used to find candidates and the clusters of items.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.clusters.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "members.item_id", Value: 1}}}); err != nil {
+		return err
+	}
+	_, err := s.feedItems.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "created_at", Value: -1}}})
+	return err
+}
+
+// SetSimHash stores the fingerprint of a feed item. Fingerprints are stored as int64, since MongoDB
+// has no unsigned integers.
+func (s *Store) SetSimHash(ctx context.Context, id primitive.ObjectID, hash uint64) error {
+	_, err := s.feedItems.UpdateByID(ctx, id, bson.M{"$set": bson.M{"simhash": int64(hash)}})
+	return err
+}
+
+// Candidates returns the fingerprinted feed items created since since, other than id.
+func (s *Store) Candidates(ctx context.Context, id primitive.ObjectID, since time.Time) ([]Candidate, error) {
+	cursor, err := s.feedItems.Find(ctx, bson.M{
+		"_id":        bson.M{"$ne": id},
+		"created_at": bson.M{"$gte": since},
+		"simhash":    bson.M{"$exists": true},
+	}, options.Find().SetProjection(bson.M{"simhash": 1, "cluster_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0, len(docs))
+	for _, doc := range docs {
+		candidates = append(candidates, Candidate{ItemID: doc.ID, SimHash: uint64(doc.SimHash), ClusterID: doc.ClusterID})
+	}
+	return candidates, nil
+}
+
+// Join adds item to the cluster of match, creating a cluster for both when match is not clustered
+// yet. It returns the ID of the cluster. Join is safe to retry: an item that is already clustered
+// keeps its cluster and is never added to a cluster twice.
+func (s *Store) Join(ctx context.Context, item internal.FeedItemDocument, match Match) (primitive.ObjectID, error) {
+	var current internal.FeedItemDocument
+	err := s.feedItems.FindOne(ctx, bson.M{"_id": item.ID}, options.FindOne().SetProjection(bson.M{"cluster_id": 1})).Decode(&current)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if !current.ClusterID.IsZero() {
+		return current.ClusterID, nil
+	}
+
+	now := time.Now()
+	member := Member{ItemID: item.ID, Title: item.Title, Link: item.Link, Feed: item.Feed, Method: match.Method, AddedAt: now}
+
+	clusterID := match.ClusterID
+	if clusterID.IsZero() {
+		clusterID, err = s.create(ctx, match.ItemID, member, now)
+	} else {
+		err = s.addMember(ctx, clusterID, member, now)
+	}
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	_, err = s.feedItems.UpdateByID(ctx, item.ID, bson.M{"$set": bson.M{"cluster_id": clusterID}})
+	return clusterID, err
+}
+
+// addMember adds member to the cluster with the given ID unless the item is a member already.
+func (s *Store) addMember(ctx context.Context, clusterID primitive.ObjectID, member Member, now time.Time) error {
+	_, err := s.clusters.UpdateOne(ctx,
+		bson.M{"_id": clusterID, "members.item_id": bson.M{"$ne": member.ItemID}},
+		bson.M{"$push": bson.M{"members": member}, "$set": bson.M{"updated_at": now}})
+	return err
+}
+
+// create starts a cluster with the matched item as origin and member as its first duplicate.
+// When another worker clustered the matched item in the meantime, member joins that cluster instead.
+func (s *Store) create(ctx context.Context, originID primitive.ObjectID, member Member, now time.Time) (primitive.ObjectID, error) {
+	var origin internal.FeedItemDocument
+	if err := s.feedItems.FindOne(ctx, bson.M{"_id": originID}).Decode(&origin); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	c := Cluster{
+		ID:    primitive.NewObjectID(),
+		Title: origin.Title,
+		Members: []Member{
+			{ItemID: origin.ID, Title: origin.Title, Link: origin.Link, Feed: origin.Feed, Method: MethodOrigin, AddedAt: now},
+			member,
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.clusters.InsertOne(ctx, c); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	// Claim the origin only if it is still unclustered
+	res, err := s.feedItems.UpdateOne(ctx,
+		bson.M{"_id": origin.ID, "cluster_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"cluster_id": c.ID}})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if res.ModifiedCount == 1 {
+		return c.ID, nil
+	}
+
+	if _, err := s.clusters.DeleteOne(ctx, bson.M{"_id": c.ID}); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := s.feedItems.FindOne(ctx, bson.M{"_id": origin.ID}).Decode(&origin); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return origin.ClusterID, s.addMember(ctx, origin.ClusterID, member, now)
+}
@@ -3,8 +3,29 @@ package internal
 const (
 	// Temporal constants
 	TaskQueueName = "schedule"
+	// LLMTaskQueueName is the task queue for LLM-bound activities, throttled separately from fetch work
+	LLMTaskQueueName = "llm"
 
 	// MongoDB constants
 	MongoDBName             = "feeds"
 	MongoFeedItemCollection = "feed_items"
+	MongoCategoryCollection = "categories"
+	// MongoEmbeddingCollection holds feed item vectors, kept apart so item queries do not load them
+	MongoEmbeddingCollection = "feed_item_embeddings"
+	MongoClusterCollection   = "story_clusters"
+	// MongoDigestSubscriberCollection holds the recipients of the daily and weekly digests
+	MongoDigestSubscriberCollection = "digest_subscribers"
+	// MongoWebhookCollection holds the webhook subscriptions and MongoWebhookDeliveryCollection their delivery log
+	MongoWebhookCollection         = "webhooks"
+	MongoWebhookDeliveryCollection = "webhook_deliveries"
+	// MongoAlertRuleCollection holds the alert rules evaluated against processed items
+	MongoAlertRuleCollection = "alert_rules"
+	// MongoItemStateCollection holds the per-user item states and MongoReadMarkerCollection the bulk read marks
+	MongoItemStateCollection  = "item_states"
+	MongoReadMarkerCollection = "read_markers"
+	// MongoUserCollection holds the users of the API, MongoAPIKeyCollection their API keys and
+	// MongoSubscriptionCollection their feed subscriptions, the union of which the ingester polls
+	MongoUserCollection         = "users"
+	MongoAPIKeyCollection       = "api_keys"
+	MongoSubscriptionCollection = "feed_subscriptions"
 )
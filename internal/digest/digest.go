@@ -0,0 +1,161 @@
+// Package digest builds the daily and weekly email digests of processed feed items, grouped by
+// category, and sends them to the subscribers configured in MongoDB.
+package digest
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Frequency is how often a subscriber receives a digest
+type Frequency string
+
+const (
+	Daily  Frequency = "daily"
+	Weekly Frequency = "weekly"
+)
+
+// UncategorizedSection is the section of items without categories in unfiltered digests
+const UncategorizedSection = "Uncategorized"
+
+// ErrInvalidFrequency is returned for frequencies other than daily and weekly
+var ErrInvalidFrequency = errors.New("frequency must be daily or weekly")
+
+// ParseFrequency parses a digest frequency.
+func ParseFrequency(s string) (Frequency, error) {
+	switch f := Frequency(strings.ToLower(strings.TrimSpace(s))); f {
+	case Daily, Weekly:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidFrequency, s)
+}
+
+// Period returns the time covered by one digest of frequency f.
+func (f Frequency) Period() time.Duration {
+	if f == Weekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Subscriber is a recipient of the digest
+type Subscriber struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Email     string             `bson:"email"`
+	Name      string             `bson:"name,omitempty"`
+	Frequency Frequency          `bson:"frequency"`
+	// Categories limits the digest to these categories; empty includes all categories
+	Categories []string `bson:"categories,omitempty"`
+	Disabled   bool     `bson:"disabled,omitempty"`
+	// LastSentAt is the end of the period covered by the last digest sent to the subscriber
+	LastSentAt *time.Time `bson:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at"`
+}
+
+// Since returns the start of the next digest of the subscriber ending at until: the end of the
+// previous digest, or one period before until for new subscribers.
+func (s Subscriber) Since(until time.Time) time.Time {
+	if s.LastSentAt != nil {
+		return *s.LastSentAt
+	}
+	return until.Add(-s.Frequency.Period())
+}
+
+// Item is a feed item in a digest
+type Item struct {
+	Title   string
+	Link    string
+	Summary string
+	Feed    string
+}
+
+// Section holds the items of one category
+type Section struct {
+	Category string
+	Items    []Item
+	// More is the number of items left out to keep the section short
+	More int
+}
+
+// Digest is the content of one digest email
+type Digest struct {
+	Frequency Frequency
+	Since     time.Time
+	Until     time.Time
+	Sections  []Section
+	// Overview is a short LLM-written overview of the period, empty when not requested or unavailable
+	Overview string
+}
+
+// Items returns the number of items in the digest, including the ones left out of the sections.
+func (d Digest) Items() int {
+	n := 0
+	for _, s := range d.Sections {
+		n += len(s.Items) + s.More
+	}
+	return n
+}
+
+// Empty reports whether the digest has no items.
+func (d Digest) Empty() bool {
+	return len(d.Sections) == 0
+}
+
+// Group sorts docs into a section per category. Each item is listed once, under its first category
+// selected by categories, or its first category when categories is empty. Sections are ordered by
+// number of items, largest first, and list at most perSection items in the order of docs; zero
+// does not limit the sections.
+func Group(docs []internal.FeedItemDocument, categories []string, perSection int) []Section {
+	var sections []Section
+	index := map[string]int{}
+	for _, doc := range docs {
+		category, ok := sectionOf(doc, categories)
+		if !ok {
+			continue
+		}
+		i, exists := index[category]
+		if !exists {
+			i = len(sections)
+			index[category] = i
+			sections = append(sections, Section{Category: category})
+		}
+		if perSection > 0 && len(sections[i].Items) >= perSection {
+			sections[i].More++
+			continue
+		}
+		sections[i].Items = append(sections[i].Items, Item{Title: doc.Title, Link: doc.Link, Summary: doc.Summary, Feed: doc.Feed})
+	}
+
+	sort.SliceStable(sections, func(i, j int) bool {
+		ni, nj := len(sections[i].Items)+sections[i].More, len(sections[j].Items)+sections[j].More
+		if ni != nj {
+			return ni > nj
+		}
+		return sections[i].Category < sections[j].Category
+	})
+	return sections
+}
+
+// sectionOf returns the category doc is listed under, or false when it is filtered out.
+func sectionOf(doc internal.FeedItemDocument, categories []string) (string, bool) {
+	if len(categories) == 0 {
+		if len(doc.Categories) == 0 {
+			return UncategorizedSection, true
+		}
+		return doc.Categories[0], true
+	}
+	for _, c := range doc.Categories {
+		for _, selected := range categories {
+			if c == selected {
+				return c, true
+			}
+		}
+	}
+	return "", false
+}
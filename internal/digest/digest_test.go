@@ -0,0 +1,105 @@
+package digest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func doc(title string, categories ...string) internal.FeedItemDocument {
+	return internal.FeedItemDocument{Title: title, Link: "https://example.com/" + title, Categories: categories}
+}
+
+func TestGroup(t *testing.T) {
+	docs := []internal.FeedItemDocument{
+		doc("a", "Security", "Cloud"),
+		doc("b", "Cloud"),
+		doc("c", "Cloud", "Security"),
+		doc("d"),
+	}
+
+	sections := Group(docs, nil, 0)
+	if len(sections) != 3 {
+		t.Fatalf("expected 3 sections, got %+v", sections)
+	}
+	if sections[0].Category != "Cloud" || len(sections[0].Items) != 2 || sections[0].Items[0].Title != "b" {
+		t.Errorf("expected Cloud with b and c first, got %+v", sections[0])
+	}
+	if sections[1].Category != "Security" || sections[2].Category != UncategorizedSection {
+		t.Errorf("expected Security and then Uncategorized, got %+v", sections)
+	}
+}
+
+func TestGroup_Categories(t *testing.T) {
+	docs := []internal.FeedItemDocument{
+		doc("a", "Cloud", "Security"),
+		doc("b", "Cloud"),
+		doc("c"),
+	}
+
+	sections := Group(docs, []string{"Security"}, 0)
+	if len(sections) != 1 || sections[0].Category != "Security" || len(sections[0].Items) != 1 || sections[0].Items[0].Title != "a" {
+		t.Errorf("expected only a under Security, got %+v", sections)
+	}
+}
+
+func TestGroup_PerSection(t *testing.T) {
+	docs := []internal.FeedItemDocument{doc("a", "Cloud"), doc("b", "Cloud"), doc("c", "Cloud"), doc("d", "Go")}
+
+	d := Digest{Sections: Group(docs, nil, 2)}
+	if len(d.Sections[0].Items) != 2 || d.Sections[0].More != 1 {
+		t.Errorf("expected 2 items and 1 more, got %+v", d.Sections[0])
+	}
+	if d.Items() != 4 {
+		t.Errorf("expected 4 items in total, got %d", d.Items())
+	}
+}
+
+func TestSubscriber_Since(t *testing.T) {
+	until := time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)
+
+	s := Subscriber{Frequency: Weekly}
+	if got := s.Since(until); !got.Equal(until.AddDate(0, 0, -7)) {
+		t.Errorf("expected a new weekly subscriber to start a week ago, got %s", got)
+	}
+
+	last := until.Add(-36 * time.Hour)
+	s = Subscriber{Frequency: Daily, LastSentAt: &last}
+	if got := s.Since(until); !got.Equal(last) {
+		t.Errorf("expected the digest to start at the last digest, got %s", got)
+	}
+}
+
+func TestParseFrequency(t *testing.T) {
+	if f, err := ParseFrequency(" Weekly "); err != nil || f != Weekly {
+		t.Errorf("expected weekly, got %q, %v", f, err)
+	}
+	if _, err := ParseFrequency("monthly"); !errors.Is(err, ErrInvalidFrequency) {
+		t.Errorf("expected ErrInvalidFrequency, got %v", err)
+	}
+}
+
+func TestItemFilter(t *testing.T) {
+	since := time.Date(2026, 10, 11, 7, 0, 0, 0, time.UTC)
+	until := since.AddDate(0, 0, 7)
+
+	f := ItemFilter(since, until, nil)
+	if f["status"] != internal.StatusProcessed {
+		t.Errorf("expected only processed items, got %v", f)
+	}
+	processed, _ := f["processed_at"].(bson.M)
+	if processed["$gt"] != since || processed["$lte"] != until {
+		t.Errorf("expected the period to exclude since and include until, got %v", processed)
+	}
+	if _, ok := f["categories"]; ok {
+		t.Errorf("expected no category filter, got %v", f)
+	}
+
+	f = ItemFilter(since, until, []string{"Security"})
+	if _, ok := f["categories"]; !ok {
+		t.Errorf("expected a category filter, got %v", f)
+	}
+}
@@ -0,0 +1,165 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal SMTP server that accepts one message and hands it to the test
+type smtpSink struct {
+	listener net.Listener
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	s := &smtpSink{listener: l, messages: make(chan sinkMessage, 1)}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 sink ready")
+
+	var msg sinkMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.from = strings.Trim(strings.TrimSpace(line)[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			reply("250 OK")
+			s.messages <- msg
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPSender_Send(t *testing.T) {
+	sink := newSMTPSink(t)
+	sender := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: sink.port(), From: "Feeds <feeds@example.com>"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	email := Email{Subject: "Weekly digest: 1 new item", Text: "Hello\n", HTML: "<p>Hello</p>"}
+	if err := sender.Send(ctx, "Sam <sam@example.com>", email); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	select {
+	case msg := <-sink.messages:
+		if msg.from != "feeds@example.com" || len(msg.to) != 1 || msg.to[0] != "sam@example.com" {
+			t.Errorf("unexpected envelope from %q to %v", msg.from, msg.to)
+		}
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+		if err != nil {
+			t.Fatalf("failed to parse message: %v", err)
+		}
+		if parsed.Header.Get("Subject") != email.Subject {
+			t.Errorf("unexpected subject %q", parsed.Header.Get("Subject"))
+		}
+	case <-ctx.Done():
+		t.Fatal("sink did not receive a message")
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Feeds", Address: "feeds@example.com"}
+	to := &mail.Address{Address: "sam@example.com"}
+	email := Email{Subject: "Wöchentlicher Überblick", Text: "Plain " + strings.Repeat("text ", 30), HTML: "<p>Rich</p>"}
+
+	b, err := BuildMessage(from, to, email, time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != email.Subject {
+		t.Errorf("expected subject %q, got %q (%v)", email.Subject, subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("expected multipart/alternative, got %q (%v)", mediaType, err)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		// The multipart reader decodes quoted-printable parts
+		content, _ := io.ReadAll(p)
+		parts = append(parts, p.Header.Get("Content-Type")+": "+string(content))
+	}
+	if len(parts) != 2 || parts[0] != "text/plain; charset=utf-8: "+email.Text || parts[1] != "text/html; charset=utf-8: "+email.HTML {
+		t.Errorf("expected plain-text and HTML parts, got %s", strconv.Quote(strings.Join(parts, " | ")))
+	}
+}
@@ -0,0 +1,78 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// funcs are the functions available to the email templates
+var funcs = map[string]any{
+	"date": func(t time.Time) string { return t.Format("Mon 2 Jan 2006") },
+}
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/digest.html.tmpl"))
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt.tmpl").Funcs(funcs).ParseFS(templates, "templates/digest.txt.tmpl"))
+)
+
+// Email is a rendered digest with a plain-text and an HTML body
+type Email struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// templateData is the data passed to the email templates
+type templateData struct {
+	Title      string
+	Subscriber Subscriber
+	Digest
+}
+
+// Render renders the digest d for subscriber s.
+func Render(d Digest, s Subscriber) (Email, error) {
+	title := "Weekly digest"
+	if d.Frequency == Daily {
+		title = "Daily digest"
+	}
+	data := templateData{Title: title, Subscriber: s, Digest: d}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return Email{}, fmt.Errorf("render text digest: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return Email{}, fmt.Errorf("render HTML digest: %w", err)
+	}
+
+	return Email{
+		Subject: Subject(title, d),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// Subject returns the subject line of the digest, e.g. "Weekly digest: 12 new items in 3 categories".
+func Subject(title string, d Digest) string {
+	items := d.Items()
+	subject := fmt.Sprintf("%s: %d new %s", title, items, plural(items, "item", "items"))
+	if len(d.Sections) > 1 {
+		subject += fmt.Sprintf(" in %d categories", len(d.Sections))
+	}
+	return subject
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,49 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDigest() Digest {
+	since := time.Date(2026, 10, 11, 7, 0, 0, 0, time.UTC)
+	return Digest{
+		Frequency: Weekly,
+		Since:     since,
+		Until:     since.AddDate(0, 0, 7),
+		Overview:  "A busy week for security.",
+		Sections: []Section{
+			{Category: "Security", Items: []Item{{Title: "Patch <now>", Link: "https://example.com/a?x=1&y=2", Summary: "Update today.", Feed: "Blog"}}, More: 2},
+			{Category: "Cloud", Items: []Item{{Title: "Regions", Link: "https://example.com/b"}}},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	email, err := Render(testDigest(), Subscriber{Name: "Sam"})
+	if err != nil {
+		t.Fatalf("failed to render digest: %v", err)
+	}
+
+	if email.Subject != "Weekly digest: 4 new items in 2 categories" {
+		t.Errorf("unexpected subject %q", email.Subject)
+	}
+	for _, want := range []string{"Hi Sam,", "A busy week for security.", "== Security ==", "* Patch <now> (Blog)", "https://example.com/a?x=1&y=2", "...and 2 more", "Sun 11 Oct 2026 - Sun 18 Oct 2026"} {
+		if !strings.Contains(email.Text, want) {
+			t.Errorf("text body should contain %q:\n%s", want, email.Text)
+		}
+	}
+	for _, want := range []string{"<h2", "Security</h2>", "Patch &lt;now&gt;", `href="https://example.com/a?x=1&amp;y=2"`, "&hellip;and 2 more"} {
+		if !strings.Contains(email.HTML, want) {
+			t.Errorf("HTML body should contain %q:\n%s", want, email.HTML)
+		}
+	}
+}
+
+func TestSubject(t *testing.T) {
+	d := Digest{Sections: []Section{{Category: "Go", Items: []Item{{Title: "Go 1.30"}}}}}
+	if got := Subject("Daily digest", d); got != "Daily digest: 1 new item" {
+		t.Errorf("unexpected subject %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSubscriberNotFound is returned when no subscriber has the given email address
+var ErrSubscriberNotFound = errors.New("subscriber not found")
+
+// Store reads digest subscribers and the processed feed items of a digest from MongoDB
+type Store struct {
+	subscribers *mongo.Collection
+	items       *mongo.Collection
+}
+
+// NewStore creates a store backed by the subscriber and feed item collections.
+func NewStore(subscribers, items *mongo.Collection) *Store {
+	return &Store{subscribers: subscribers, items: items}
+}
+
+// EnsureIndexes creates the unique index on subscriber email addresses and the index used to find
+// the items processed in a period.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.subscribers.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.items.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "processed_at", Value: -1}},
+	})
+	return err
+}
+
+// Subscribers returns the enabled subscribers receiving a digest at frequency f.
+func (s *Store) Subscribers(ctx context.Context, f Frequency) ([]Subscriber, error) {
+	return s.find(ctx, bson.M{"frequency": f, "disabled": bson.M{"$ne": true}})
+}
+
+// List returns all subscribers, ordered by email address.
+func (s *Store) List(ctx context.Context) ([]Subscriber, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *Store) find(ctx context.Context, filter bson.M) ([]Subscriber, error) {
+	cursor, err := s.subscribers.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "email", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var subscribers []Subscriber
+	if err := cursor.All(ctx, &subscribers); err != nil {
+		return nil, err
+	}
+	return subscribers, nil
+}
+
+// Save adds a subscriber or replaces the frequency, name, categories and disabled flag of the
+// subscriber with the same email address, keeping the time the last digest was sent.
+func (s *Store) Save(ctx context.Context, sub Subscriber) error {
+	_, err := s.subscribers.UpdateOne(ctx,
+		bson.M{"email": normalizeEmail(sub.Email)},
+		bson.M{
+			"$set": bson.M{
+				"name":       sub.Name,
+				"frequency":  sub.Frequency,
+				"categories": sub.Categories,
+				"disabled":   sub.Disabled,
+			},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Remove deletes the subscriber with the given email address.
+func (s *Store) Remove(ctx context.Context, email string) error {
+	res, err := s.subscribers.DeleteOne(ctx, bson.M{"email": normalizeEmail(email)})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrSubscriberNotFound
+	}
+	return nil
+}
+
+// MarkSent records that the subscriber received the digest of the period ending at until.
+func (s *Store) MarkSent(ctx context.Context, id primitive.ObjectID, until time.Time) error {
+	_, err := s.subscribers.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_sent_at": until}})
+	return err
+}
+
+// Items returns up to limit items processed after since and up to until, in one of categories
+// when it is not empty. The most relevant items come first, so they are kept when the digest is
+// capped, followed by unscored items; items with equal relevance are most recently processed first.
+func (s *Store) Items(ctx context.Context, since, until time.Time, categories []string, limit int) ([]internal.FeedItemDocument, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"text": 0}).
+		SetSort(bson.D{{Key: "relevance.score", Value: -1}, {Key: "processed_at", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.items.Find(ctx, ItemFilter(since, until, categories), opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// ItemFilter builds the MongoDB filter for the items processed after since and up to until.
+func ItemFilter(since, until time.Time, categories []string) bson.M {
+	filter := bson.M{
+		"status":       internal.StatusProcessed,
+		"processed_at": bson.M{"$gt": since, "$lte": until},
+	}
+	if len(categories) > 0 {
+		filter["categories"] = bson.M{"$in": categories}
+	}
+	return filter
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
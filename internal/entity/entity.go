@@ -0,0 +1,150 @@
+// Package entity validates and normalizes the named entities and keywords extracted from articles,
+// so they can be indexed and matched reliably: CVE IDs are checked against their format, versions
+// are parsed as semantic versions and names get a lookup key that ignores case and punctuation.
+package entity
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+)
+
+// Entity types
+const (
+	TypeProduct      = "product"
+	TypeVersion      = "version"
+	TypeOrganization = "organization"
+	TypePerson       = "person"
+	TypeCVE          = "cve"
+)
+
+// Types are the entity types that can be extracted
+var Types = []string{TypeProduct, TypeVersion, TypeOrganization, TypePerson, TypeCVE}
+
+// MaxKeywords is the maximum number of keywords kept per article
+const MaxKeywords = 10
+
+// cvePattern matches CVE IDs anywhere in text
+var cvePattern = regexp.MustCompile(`(?i)\bCVE-(\d{4})-(\d{4,7})\b`)
+
+// cveIDPattern matches a complete CVE ID
+var cveIDPattern = regexp.MustCompile(`(?i)^CVE-\d{4}-\d{4,7}$`)
+
+// Raw is an entity as returned by the LLM
+type Raw struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Product string `json:"product,omitempty"`
+}
+
+// Entity is a validated and normalized entity
+type Entity struct {
+	Type string `bson:"type" json:"type"`
+	Name string `bson:"name" json:"name"`
+	// Key is the lookup key of the entity, e.g. "cve-2024-3094" or "terraform"
+	Key string `bson:"key" json:"key"`
+	// Product is the product a version entity belongs to
+	Product string `bson:"product,omitempty" json:"product,omitempty"`
+	// Version holds the parsed components of a version entity for range queries
+	Version *semver.Version `bson:"version,omitempty" json:"version,omitempty"`
+}
+
+// Key returns the lookup key of a name: lowercase letters and digits, with runs of other
+// characters collapsed into a single dash.
+func Key(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// Normalize validates raw entities, normalizing their names and dropping invalid ones and
+// duplicates. It returns the valid entities in order and the raw entities that were rejected.
+func Normalize(raw []Raw) (entities []Entity, rejected []Raw) {
+	for _, r := range raw {
+		e, ok := normalize(r)
+		if !ok {
+			rejected = append(rejected, r)
+			continue
+		}
+		if !slices.ContainsFunc(entities, func(x Entity) bool { return x.Type == e.Type && x.Key == e.Key }) {
+			entities = append(entities, e)
+		}
+	}
+	return entities, rejected
+}
+
+func normalize(r Raw) (Entity, bool) {
+	e := Entity{Type: strings.ToLower(strings.TrimSpace(r.Type)), Name: strings.TrimSpace(r.Name)}
+	if e.Name == "" || !slices.Contains(Types, e.Type) {
+		return Entity{}, false
+	}
+
+	switch e.Type {
+	case TypeCVE:
+		if !cveIDPattern.MatchString(e.Name) {
+			return Entity{}, false
+		}
+		e.Name = strings.ToUpper(e.Name)
+	case TypeVersion:
+		v, err := semver.Parse(e.Name)
+		if err != nil {
+			return Entity{}, false
+		}
+		e.Name = v.String()
+		e.Version = &v
+		e.Product = strings.TrimSpace(r.Product)
+		// Versions are only meaningful per product, so the product is part of the key
+		e.Key = e.Name
+		if e.Product != "" {
+			e.Key = Key(e.Product) + "@" + e.Name
+		}
+		return e, true
+	}
+
+	e.Key = Key(e.Name)
+	return e, e.Key != ""
+}
+
+// FindCVEs returns the CVE entities mentioned in text, in order of first appearance. They complement
+// the LLM output, which may miss IDs in long articles.
+func FindCVEs(text string) []Entity {
+	var entities []Entity
+	for _, m := range cvePattern.FindAllString(text, -1) {
+		e, _ := normalize(Raw{Type: TypeCVE, Name: m})
+		if !slices.ContainsFunc(entities, func(x Entity) bool { return x.Key == e.Key }) {
+			entities = append(entities, e)
+		}
+	}
+	return entities
+}
+
+// Merge appends the entities of extra that are not in entities yet.
+func Merge(entities []Entity, extra ...Entity) []Entity {
+	for _, e := range extra {
+		if !slices.ContainsFunc(entities, func(x Entity) bool { return x.Type == e.Type && x.Key == e.Key }) {
+			entities = append(entities, e)
+		}
+	}
+	return entities
+}
+
+// NormalizeKeywords lowercases and trims keywords, dropping empty ones and duplicates, and keeps
+// at most MaxKeywords.
+func NormalizeKeywords(keywords []string) []string {
+	var result []string
+	for _, k := range keywords {
+		k = strings.Join(strings.Fields(strings.ToLower(k)), " ")
+		if k == "" || slices.Contains(result, k) {
+			continue
+		}
+		result = append(result, k)
+		if len(result) == MaxKeywords {
+			break
+		}
+	}
+	return result
+}
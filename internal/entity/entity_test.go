@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	entities, rejected := Normalize([]Raw{
+		{Type: "Product", Name: " Terraform "},
+		{Type: "version", Name: "v1.8", Product: "Terraform"},
+		{Type: "cve", Name: "cve-2024-3094"},
+		{Type: "organization", Name: "HashiCorp"},
+		{Type: "person", Name: "Mitchell Hashimoto"},
+		{Type: "product", Name: "terraform"},
+		{Type: "cve", Name: "CVE-24-1"},
+		{Type: "version", Name: "latest"},
+		{Type: "location", Name: "Amsterdam"},
+		{Type: "product", Name: ""},
+	})
+
+	wantKeys := []string{"terraform", "terraform@1.8.0", "cve-2024-3094", "hashicorp", "mitchell-hashimoto"}
+	var keys []string
+	for _, e := range entities {
+		keys = append(keys, e.Key)
+	}
+	if !slices.Equal(keys, wantKeys) {
+		t.Errorf("expected keys %v, got %v", wantKeys, keys)
+	}
+	if len(rejected) != 4 {
+		t.Errorf("expected 4 rejected entities, got %v", rejected)
+	}
+
+	version := entities[1]
+	if version.Name != "1.8.0" || version.Version == nil || version.Version.Minor != 8 || version.Product != "Terraform" {
+		t.Errorf("expected parsed version entity, got %+v", version)
+	}
+	if entities[2].Name != "CVE-2024-3094" {
+		t.Errorf("expected CVE ID to be uppercased, got %q", entities[2].Name)
+	}
+}
+
+func TestNormalize_VersionWithoutProduct(t *testing.T) {
+	entities, _ := Normalize([]Raw{{Type: "version", Name: "2.0.0-rc.1"}})
+	if len(entities) != 1 || entities[0].Key != "2.0.0-rc.1" {
+		t.Errorf("expected version keyed by its canonical form, got %+v", entities)
+	}
+}
+
+func TestFindCVEs(t *testing.T) {
+	entities := FindCVEs("Fixes CVE-2024-3094 and cve-2023-44487 (see CVE-2024-3094). Not CVE-2024-12.")
+	if len(entities) != 2 || entities[0].Name != "CVE-2024-3094" || entities[1].Name != "CVE-2023-44487" {
+		t.Errorf("unexpected CVEs: %+v", entities)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge(FindCVEs("CVE-2024-3094"), FindCVEs("cve-2024-3094 CVE-2023-44487")...)
+	if len(merged) != 2 {
+		t.Errorf("expected duplicates to be skipped, got %+v", merged)
+	}
+}
+
+func TestNormalizeKeywords(t *testing.T) {
+	got := NormalizeKeywords([]string{" OpenTelemetry ", "log  bridge", "opentelemetry", "", "a", "b", "c", "d", "e", "f", "g", "h", "i"})
+	want := []string{"opentelemetry", "log bridge", "a", "b", "c", "d", "e", "f", "g", "h"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
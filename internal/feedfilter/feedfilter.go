@@ -0,0 +1,261 @@
+// Package feedfilter decides which items of a feed are ingested, so obvious noise such as nightly
+// pre-releases or sponsored posts is dropped before any LLM time is spent on it.
+package feedfilter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+	"github.com/mmcdole/gofeed"
+)
+
+// Rule actions
+const (
+	ActionInclude = "include"
+	ActionExclude = "exclude"
+)
+
+// RuleInclude is the name of the rule reported for items skipped because no include rule matched them
+const RuleInclude = "include"
+
+// includeRule is the rule reported for items skipped because no include rule matched them
+var includeRule = Rule{Name: RuleInclude, Action: ActionInclude}
+
+var (
+	// ErrEmptyRule is returned for rules without any condition
+	ErrEmptyRule = errors.New("filter rule needs a title, url, author, category or prerelease condition")
+	// ErrInvalidAction is returned for actions other than include and exclude
+	ErrInvalidAction = errors.New("filter action must be include or exclude")
+)
+
+// versionPattern finds version-like strings such as "v1.2.0-rc.1" in titles and links
+var versionPattern = regexp.MustCompile(`\bv?\d+(?:\.\d+){1,2}(?:-[0-9A-Za-z][0-9A-Za-z.-]*)?`)
+
+// prereleasePattern finds pre-releases that are not semantic versions, such as nightly builds or
+// Go-style versions like "go1.23rc1"
+var prereleasePattern = regexp.MustCompile(`(?i)\b(?:nightly|canary|snapshot)\b|\d(?:alpha|beta|rc)\d*\b`)
+
+// Rule is a filter rule of a feed, as configured in the feed list. An item matches when it matches
+// every condition that is set. Title, URL and author are regular expressions, the category matches
+// the categories the feed assigns to the item ignoring case, and prerelease matches items whose
+// title or link carries a pre-release version or a nightly build.
+type Rule struct {
+	// Name labels the rule in logs; a description of the conditions is used when empty
+	Name string `json:"name,omitempty"`
+	// Action is ActionExclude, the default, or ActionInclude
+	Action     string `json:"action,omitempty"`
+	Title      string `json:"title,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Author     string `json:"author,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Prerelease bool   `json:"prerelease,omitempty"`
+}
+
+// String returns the name of the rule, or its action and conditions when it has no name.
+func (r Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	var conditions []string
+	for _, c := range []struct{ key, value string }{{"title", r.Title}, {"url", r.URL}, {"author", r.Author}, {"category", r.Category}} {
+		if c.value != "" {
+			conditions = append(conditions, c.key+"="+c.value)
+		}
+	}
+	if r.Prerelease {
+		conditions = append(conditions, "prerelease")
+	}
+	return r.action() + " " + strings.Join(conditions, ",")
+}
+
+// Kind returns the action of the rule and the types of its conditions, such as
+// "exclude:title,prerelease". Unlike the name and patterns of user rules, kinds form a small set,
+// so they can label metrics.
+func (r Rule) Kind() string {
+	var types []string
+	for _, c := range []struct {
+		key string
+		set bool
+	}{{"title", r.Title != ""}, {"url", r.URL != ""}, {"author", r.Author != ""}, {"category", r.Category != ""}, {"prerelease", r.Prerelease}} {
+		if c.set {
+			types = append(types, c.key)
+		}
+	}
+	if len(types) == 0 {
+		return r.action()
+	}
+	return r.action() + ":" + strings.Join(types, ",")
+}
+
+func (r Rule) action() string {
+	if r.Action == "" {
+		return ActionExclude
+	}
+	return r.Action
+}
+
+// compiledRule is a rule with its regular expressions compiled
+type compiledRule struct {
+	Rule
+	title, url, author *regexp.Regexp
+}
+
+func (r compiledRule) match(item *gofeed.Item) bool {
+	if r.title != nil && !r.title.MatchString(item.Title) {
+		return false
+	}
+	if r.url != nil && !r.url.MatchString(item.Link) {
+		return false
+	}
+	if r.author != nil && !slices.ContainsFunc(authors(item), r.author.MatchString) {
+		return false
+	}
+	if r.Category != "" && !slices.ContainsFunc(item.Categories, func(c string) bool { return strings.EqualFold(strings.TrimSpace(c), r.Category) }) {
+		return false
+	}
+	if r.Prerelease && !IsPrerelease(item) {
+		return false
+	}
+	return true
+}
+
+// Filter is the compiled set of filter rules of a feed
+type Filter struct {
+	include []compiledRule
+	exclude []compiledRule
+}
+
+// Compile validates and compiles the filter rules of a feed.
+func Compile(rules []Rule) (*Filter, error) {
+	f := &Filter{}
+	for i, r := range rules {
+		c, err := compile(r)
+		if err != nil {
+			return nil, fmt.Errorf("filter rule %d (%s): %w", i+1, r, err)
+		}
+		if r.action() == ActionInclude {
+			f.include = append(f.include, c)
+		} else {
+			f.exclude = append(f.exclude, c)
+		}
+	}
+	return f, nil
+}
+
+func compile(r Rule) (compiledRule, error) {
+	if r.Title == "" && r.URL == "" && r.Author == "" && r.Category == "" && !r.Prerelease {
+		return compiledRule{}, ErrEmptyRule
+	}
+	if r.action() != ActionInclude && r.action() != ActionExclude {
+		return compiledRule{}, fmt.Errorf("%w, got %q", ErrInvalidAction, r.Action)
+	}
+	c := compiledRule{Rule: r}
+	for _, p := range []struct {
+		source string
+		target **regexp.Regexp
+	}{{r.Title, &c.title}, {r.URL, &c.url}, {r.Author, &c.author}} {
+		if p.source == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.source)
+		if err != nil {
+			return compiledRule{}, err
+		}
+		*p.target = re
+	}
+	return c, nil
+}
+
+// Skip reports whether item is filtered out, with the rule that filtered it: the first matching
+// exclude rule, or a rule named RuleInclude when the feed has include rules and none of them match.
+func (f *Filter) Skip(item *gofeed.Item) (bool, Rule) {
+	for _, r := range f.exclude {
+		if r.match(item) {
+			return true, r.Rule
+		}
+	}
+	if len(f.include) == 0 {
+		return false, Rule{}
+	}
+	for _, r := range f.include {
+		if r.match(item) {
+			return false, Rule{}
+		}
+	}
+	return true, includeRule
+}
+
+// IsPrerelease reports whether the title or link of item carries a pre-release version, such as
+// "v2.0.0-rc.1" or "go1.23rc1", or names a nightly, canary or snapshot build.
+func IsPrerelease(item *gofeed.Item) bool {
+	for _, s := range []string{item.Title, item.Link} {
+		if prereleasePattern.MatchString(s) {
+			return true
+		}
+		for _, match := range versionPattern.FindAllString(s, -1) {
+			if v, err := semver.Parse(match); err == nil && v.IsPrerelease() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// authors returns the names and email addresses of the authors of item.
+func authors(item *gofeed.Item) []string {
+	var names []string
+	people := slices.Clone(item.Authors)
+	if item.Author != nil {
+		people = append(people, item.Author)
+	}
+	for _, p := range people {
+		if p == nil {
+			continue
+		}
+		for _, s := range []string{p.Name, p.Email} {
+			if s != "" {
+				names = append(names, s)
+			}
+		}
+	}
+	return names
+}
+
+// Any combines the filters of the subscriptions sharing a feed. It skips the items every filter
+// skips, so the rules of one subscriber never hide an item from another.
+type Any []*Filter
+
+// CompileAny validates and compiles the filter rules of every subscription of a feed.
+func CompileAny(ruleSets [][]Rule) (Any, error) {
+	a := make(Any, 0, len(ruleSets))
+	for _, rules := range ruleSets {
+		f, err := Compile(rules)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, f)
+	}
+	return a, nil
+}
+
+// Skip reports whether every filter skips item, with the rule that skipped it in the first filter.
+func (a Any) Skip(item *gofeed.Item) (bool, Rule) {
+	if len(a) == 0 {
+		return false, Rule{}
+	}
+	var first Rule
+	for i, f := range a {
+		skip, rule := f.Skip(item)
+		if !skip {
+			return false, Rule{}
+		}
+		if i == 0 {
+			first = rule
+		}
+	}
+	return true, first
+}
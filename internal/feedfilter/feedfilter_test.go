@@ -0,0 +1,147 @@
+package feedfilter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestIsPrerelease(t *testing.T) {
+	cases := map[string]bool{
+		"v2.0.0-rc.1":              true,
+		"Release 1.8.0-beta.2":     true,
+		"go1.23rc1":                true,
+		"Nightly build 2026-10-18": true,
+		"v2.0.0":                   false,
+		"Terraform 1.10 released":  false,
+		"Go 1.23 is released":      false,
+		"Sentry 24.10.0":           false,
+	}
+	for title, want := range cases {
+		if got := IsPrerelease(&gofeed.Item{Title: title}); got != want {
+			t.Errorf("IsPrerelease(%q) = %t, want %t", title, got, want)
+		}
+	}
+	if !IsPrerelease(&gofeed.Item{Title: "Release", Link: "https://github.com/org/repo/releases/tag/v1.2.0-alpha.1"}) {
+		t.Error("expected a pre-release version in the link to be detected")
+	}
+}
+
+func TestFilter_Skip(t *testing.T) {
+	filter, err := Compile([]Rule{
+		{Name: "prereleases", Prerelease: true},
+		{Title: `(?i)^sponsored`},
+		{Author: `(?i)marketing`},
+		{Category: "Advertisement"},
+	})
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+
+	cases := []struct {
+		item gofeed.Item
+		skip bool
+		rule string
+	}{
+		{gofeed.Item{Title: "v1.2.0-rc.1"}, true, "prereleases"},
+		{gofeed.Item{Title: "Sponsored: try our database"}, true, "exclude title=(?i)^sponsored"},
+		{gofeed.Item{Title: "Launch week", Authors: []*gofeed.Person{{Name: "Marketing Team"}}}, true, "exclude author=(?i)marketing"},
+		{gofeed.Item{Title: "Launch week", Author: &gofeed.Person{Email: "marketing@example.com"}}, true, "exclude author=(?i)marketing"},
+		{gofeed.Item{Title: "Partner post", Categories: []string{" advertisement "}}, true, "exclude category=Advertisement"},
+		{gofeed.Item{Title: "v1.2.0", Categories: []string{"Releases"}}, false, ""},
+	}
+	for _, c := range cases {
+		skip, rule := filter.Skip(&c.item)
+		if skip != c.skip || (skip && rule.String() != c.rule) {
+			t.Errorf("Skip(%q) = %t, %q, want %t, %q", c.item.Title, skip, rule, c.skip, c.rule)
+		}
+	}
+}
+
+func TestFilter_Include(t *testing.T) {
+	filter, err := Compile([]Rule{
+		{Action: ActionInclude, URL: `/releases/tag/`},
+		{Action: ActionInclude, Title: `(?i)security`},
+		{Title: `(?i)draft`},
+	})
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+
+	cases := []struct {
+		item gofeed.Item
+		skip bool
+		rule string
+	}{
+		{gofeed.Item{Title: "v1.2.0", Link: "https://github.com/org/repo/releases/tag/v1.2.0"}, false, ""},
+		{gofeed.Item{Title: "Security advisory", Link: "https://example.com/advisory"}, false, ""},
+		{gofeed.Item{Title: "Weekly update", Link: "https://example.com/blog"}, true, RuleInclude},
+		{gofeed.Item{Title: "Draft v1.3.0", Link: "https://github.com/org/repo/releases/tag/v1.3.0"}, true, "exclude title=(?i)draft"},
+	}
+	for _, c := range cases {
+		skip, rule := filter.Skip(&c.item)
+		if skip != c.skip || (skip && rule.String() != c.rule) {
+			t.Errorf("Skip(%q) = %t, %q, want %t, %q", c.item.Title, skip, rule, c.skip, c.rule)
+		}
+	}
+
+	empty, err := Compile(nil)
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	if skip, _ := empty.Skip(&gofeed.Item{Title: "anything"}); skip {
+		t.Error("expected a feed without rules to ingest every item")
+	}
+}
+
+func TestRule_Kind(t *testing.T) {
+	cases := map[string]Rule{
+		"exclude:title":               {Name: "sponsored", Title: `(?i)sponsored`},
+		"include:url,author":          {Action: ActionInclude, URL: `/releases/`, Author: `bot`},
+		"exclude:category,prerelease": {Category: "Beta", Prerelease: true},
+		"include":                     includeRule,
+	}
+	for want, r := range cases {
+		if got := r.Kind(); got != want {
+			t.Errorf("Kind() of %s = %q, want %q", r, got, want)
+		}
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	if _, err := Compile([]Rule{{Name: "empty"}}); !errors.Is(err, ErrEmptyRule) {
+		t.Errorf("expected ErrEmptyRule, got %v", err)
+	}
+	if _, err := Compile([]Rule{{Action: "drop", Prerelease: true}}); !errors.Is(err, ErrInvalidAction) {
+		t.Errorf("expected ErrInvalidAction, got %v", err)
+	}
+	if _, err := Compile([]Rule{{Title: "("}}); err == nil {
+		t.Error("expected an error for an invalid title regex")
+	}
+}
+
+func TestAny_Skip(t *testing.T) {
+	a, err := CompileAny([][]Rule{
+		{{Name: "prereleases", Prerelease: true}},
+		{{Name: "sponsored", Title: `(?i)sponsored`}, {Name: "release candidates", Title: `-rc\.`}},
+	})
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+
+	if skip, rule := a.Skip(&gofeed.Item{Title: "v2.0.0-rc.1"}); !skip || rule.String() != "prereleases" {
+		t.Errorf("expected an item every filter skips to be skipped by prereleases, got %t %q", skip, rule)
+	}
+	for _, title := range []string{"v2.0.0-beta.1", "Sponsored: v2.0.0", "v2.0.0"} {
+		if skip, _ := a.Skip(&gofeed.Item{Title: title}); skip {
+			t.Errorf("expected %q to be kept for the subscription that does not skip it", title)
+		}
+	}
+	if skip, _ := (Any{}).Skip(&gofeed.Item{Title: "v2.0.0-rc.1"}); skip {
+		t.Error("expected no filters to keep every item")
+	}
+	if _, err := CompileAny([][]Rule{{{Name: "empty"}}}); !errors.Is(err, ErrEmptyRule) {
+		t.Errorf("expected ErrEmptyRule, got %v", err)
+	}
+}
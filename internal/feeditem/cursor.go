@@ -0,0 +1,74 @@
+package feeditem
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position after the last item of a page. Items are ordered by creation time and ID,
+// newest first, so a cursor stays valid while new items are added. Items sorted by relevance are
+// ordered by relevance score first, with unscored items last.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        primitive.ObjectID
+	// Score is the relevance score of the item, used when sorting by relevance; nil when unscored
+	Score *float64
+}
+
+// CursorAfter returns the cursor positioned after doc.
+func CursorAfter(doc internal.FeedItemDocument) Cursor {
+	c := Cursor{CreatedAt: doc.CreatedAt, ID: doc.ID}
+	if doc.Relevance != nil {
+		score := doc.Relevance.Score
+		c.Score = &score
+	}
+	return c
+}
+
+// Encode returns the opaque string form of c used in URLs.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatInt(c.CreatedAt.UnixMilli(), 10) + "." + c.ID.Hex()
+	if c.Score != nil {
+		raw += "." + strconv.FormatFloat(*c.Score, 'g', -1, 64)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor created by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), ".", 3)
+	if len(parts) < 2 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	millis, hex := parts[0], parts[1]
+	var score *float64
+	if len(parts) == 3 {
+		f, err := strconv.ParseFloat(parts[2], 64)
+		if err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+		score = &f
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: time.UnixMilli(ms).UTC(), ID: id, Score: score}, nil
+}
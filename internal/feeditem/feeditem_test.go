@@ -0,0 +1,158 @@
+package feeditem
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2024, 6, 26, 9, 30, 15, 123000000, time.UTC), ID: primitive.NewObjectID()}
+
+	decoded, err := DecodeCursor(c.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ID != c.ID {
+		t.Errorf("DecodeCursor() = %+v, want %+v", decoded, c)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"", "!!!", "bm90LWEtY3Vyc29y", "MTIzLm5vdC1hbi1pZA"} {
+		if _, err := DecodeCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	q := Query(Filter{Category: "Security", Feed: "Go changelog", Status: "processed", From: from}, nil)
+
+	if q["categories"] != "Security" || q["feed"] != "Go changelog" || q["status"] != "processed" {
+		t.Errorf("unexpected filter: %v", q)
+	}
+	created, ok := q["created_at"].(bson.M)
+	if !ok || created["$gte"] != from {
+		t.Errorf("created_at = %v, want $gte %v", q["created_at"], from)
+	}
+	if _, ok := created["$lt"]; ok {
+		t.Error("created_at should not have an upper bound")
+	}
+	if _, ok := q["$or"]; ok {
+		t.Error("first page should not have a cursor condition")
+	}
+}
+
+func TestQuery_Empty(t *testing.T) {
+	if q := Query(Filter{}, nil); len(q) != 0 {
+		t.Errorf("empty filter should match everything, got %v", q)
+	}
+}
+
+func TestQuery_FeedURLs(t *testing.T) {
+	urls := []string{"https://go.dev/blog/feed.atom"}
+	if q := Query(Filter{FeedURLs: urls}, nil); !reflect.DeepEqual(q["feed_url"], bson.M{"$in": urls}) {
+		t.Errorf("feed_url = %v, want the subscribed feeds", q["feed_url"])
+	}
+	if q := Query(Filter{FeedURLs: []string{}}, nil); !reflect.DeepEqual(q["feed_url"], bson.M{"$in": []string{}}) {
+		t.Errorf("feed_url = %v, want no items without subscriptions", q["feed_url"])
+	}
+	if f := SearchFilter(SearchQuery{Text: "otel", FeedURLs: urls}); !reflect.DeepEqual(f["feed_url"], bson.M{"$in": urls}) {
+		t.Errorf("search feed_url = %v, want the subscribed feeds", f["feed_url"])
+	}
+}
+
+func TestQuery_Cursor(t *testing.T) {
+	after := Cursor{CreatedAt: time.Date(2024, 6, 26, 0, 0, 0, 0, time.UTC), ID: primitive.NewObjectID()}
+	q := Query(Filter{}, &after)
+
+	or, ok := q["$or"].(bson.A)
+	if !ok || len(or) != 2 {
+		t.Fatalf("cursor condition = %v, want two alternatives", q["$or"])
+	}
+	tie, ok := or[1].(bson.M)
+	if !ok || tie["created_at"] != after.CreatedAt {
+		t.Errorf("ties on creation time should be broken by ID, got %v", or[1])
+	}
+}
+
+func TestCursor_RoundTripScore(t *testing.T) {
+	for _, score := range []float64{0.875, 0} {
+		c := Cursor{CreatedAt: time.Date(2024, 6, 26, 9, 30, 15, 0, time.UTC), ID: primitive.NewObjectID(), Score: &score}
+
+		decoded, err := DecodeCursor(c.Encode())
+		if err != nil {
+			t.Fatalf("DecodeCursor() error = %v", err)
+		}
+		if decoded.Score == nil || *decoded.Score != score || decoded.ID != c.ID {
+			t.Errorf("DecodeCursor() = %+v, want %+v", decoded, c)
+		}
+	}
+}
+
+func TestQuery_Relevance(t *testing.T) {
+	q := Query(Filter{Sort: SortRelevance}, nil)
+	if _, ok := q["relevance.score"]; ok {
+		t.Errorf("relevance order should list unscored items too, got %v", q)
+	}
+
+	score := 0.5
+	after := Cursor{CreatedAt: time.Date(2024, 6, 26, 0, 0, 0, 0, time.UTC), ID: primitive.NewObjectID(), Score: &score}
+	q = Query(Filter{Sort: SortRelevance}, &after)
+	or, ok := q["$or"].(bson.A)
+	if !ok || len(or) != 4 {
+		t.Fatalf("cursor condition = %v, want four alternatives", q["$or"])
+	}
+	lower, ok := or[0].(bson.M)
+	if !ok || lower["relevance.score"].(bson.M)["$lt"] != score {
+		t.Errorf("first alternative should select lower scores, got %v", or[0])
+	}
+	if v, ok := or[3].(bson.M)["relevance.score"]; !ok || v != nil {
+		t.Errorf("last alternative should select unscored items, got %v", or[3])
+	}
+
+	after.Score = nil
+	q = Query(Filter{Sort: SortRelevance}, &after)
+	if or := q["$or"].(bson.A); len(or) != 2 || or[0].(bson.M)["created_at"].(bson.M)["$lt"] != after.CreatedAt {
+		t.Errorf("cursor after an unscored item should only select older unscored items, got %v", q["$or"])
+	}
+	if order := SortOrder(SortRelevance); order[0].Key != "relevance.score" || len(order) != 3 {
+		t.Errorf("unexpected relevance sort %v", order)
+	}
+}
+
+func TestTextLanguage(t *testing.T) {
+	tests := map[string]string{"en": "english", "nl": "dutch", "de": "german", "ja": "none", "": "none"}
+	for code, want := range tests {
+		if got := TextLanguage(code); got != want {
+			t.Errorf("TextLanguage(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	f := SearchFilter(SearchQuery{Text: `"log bridge" otel`, Category: "Security", Language: "nl", From: from})
+
+	text, ok := f["$text"].(bson.M)
+	if !ok || text["$search"] != `"log bridge" otel` || text["$language"] != "dutch" {
+		t.Errorf("$text = %v", f["$text"])
+	}
+	if f["categories"] != "Security" {
+		t.Errorf("categories = %v, want Security", f["categories"])
+	}
+	if created, ok := f["created_at"].(bson.M); !ok || created["$gte"] != from {
+		t.Errorf("created_at = %v", f["created_at"])
+	}
+
+	f = SearchFilter(SearchQuery{Text: "otel"})
+	if _, ok := f["$text"].(bson.M)["$language"]; ok {
+		t.Error("search without language should use the index default")
+	}
+}
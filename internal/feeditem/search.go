@@ -0,0 +1,103 @@
+package feeditem
+
+import (
+	"context"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// textLanguageField is the document field holding the text search language. MongoDB reads the
+// "language" field by default, which holds ISO codes that text search does not support.
+const textLanguageField = "text_language"
+
+// textLanguages maps ISO 639-1 codes to the MongoDB text search languages used for stemming
+var textLanguages = map[string]string{
+	"da": "danish",
+	"de": "german",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"it": "italian",
+	"nl": "dutch",
+	"pt": "portuguese",
+	"ru": "russian",
+	"sv": "swedish",
+}
+
+// TextLanguage returns the MongoDB text search language for an ISO 639-1 code, or "none" to index
+// words without stemming and stop words when the language is unknown or unsupported.
+func TextLanguage(code string) string {
+	if lang, ok := textLanguages[code]; ok {
+		return lang
+	}
+	return "none"
+}
+
+// SearchQuery is a full-text search with optional filters
+type SearchQuery struct {
+	// Text holds the search terms. Phrases are quoted and terms prefixed with "-" are excluded,
+	// following the MongoDB text search syntax.
+	Text     string
+	Category string
+	// FeedURLs restricts the items like Filter.FeedURLs
+	FeedURLs []string
+	// Language is the ISO 639-1 code used to stem the search terms; empty uses the index default
+	Language string
+	// From and To bound the creation time, From inclusive and To exclusive
+	From  time.Time
+	To    time.Time
+	Limit int
+}
+
+// SearchResult is a feed item matching a search with its relevance score
+type SearchResult struct {
+	internal.FeedItemDocument `bson:",inline"`
+	Score                     float64 `bson:"score"`
+}
+
+// ensureTextIndex creates the text index over title, summary and article text. Matches in the
+// title weigh most, matches in the article text least.
+func (s *Store) ensureTextIndex(ctx context.Context) error {
+	_, err := s.items.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "title", Value: "text"}, {Key: "summary", Value: "text"}, {Key: "text", Value: "text"}},
+		Options: options.Index().
+			SetName("text_search").
+			SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "summary", Value: 5}, {Key: "text", Value: 1}}).
+			SetDefaultLanguage("english").
+			SetLanguageOverride(textLanguageField),
+	})
+	return err
+}
+
+// Search returns the feed items matching q, most relevant first.
+func (s *Store) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score, "text": 0}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "created_at", Value: -1}}).
+		SetLimit(int64(q.Limit))
+	cursor, err := s.items.Find(ctx, SearchFilter(q), opts)
+	if err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SearchFilter builds the MongoDB filter for q.
+func SearchFilter(q SearchQuery) bson.M {
+	text := bson.M{"$search": q.Text}
+	if q.Language != "" {
+		text["$language"] = TextLanguage(q.Language)
+	}
+	filter := Query(Filter{Category: q.Category, FeedURLs: q.FeedURLs, From: q.From, To: q.To}, nil)
+	filter["$text"] = text
+	return filter
+}
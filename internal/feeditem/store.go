@@ -0,0 +1,225 @@
+// Package feeditem queries processed feed items for the read-only API, by filter or by full-text search.
+package feeditem
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when a feed item does not exist
+var ErrNotFound = errors.New("feed item not found")
+
+// Sort orders of listed feed items
+const (
+	// SortNewest lists the newest items first
+	SortNewest = "newest"
+	// SortRelevance lists the most relevant items first, newest first among equal scores. Items
+	// without a relevance score come last, newest first.
+	SortRelevance = "relevance"
+)
+
+// Sorts are the valid sort orders
+var Sorts = []string{SortNewest, SortRelevance}
+
+// Filter selects feed items; zero fields do not filter
+type Filter struct {
+	Category string
+	Feed     string
+	// FeedURLs restricts the items to those of the feeds a user subscribes to; nil does not filter
+	// and an empty slice matches no items
+	FeedURLs []string
+	Status   string
+	// From and To bound the creation time, From inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// Sort is the order of the items, SortNewest when empty
+	Sort string
+}
+
+// Page is a page of feed items, newest first
+type Page struct {
+	Items []internal.FeedItemDocument
+	// Next is the cursor of the next page, nil on the last page
+	Next *Cursor
+}
+
+// Feed summarizes the items ingested from a feed
+type Feed struct {
+	Title      string    `bson:"_id" json:"title"`
+	URL        string    `bson:"url" json:"url"`
+	Type       string    `bson:"type,omitempty" json:"type,omitempty"`
+	Items      int       `bson:"items" json:"items"`
+	LastItemAt time.Time `bson:"last_item_at" json:"lastItemAt"`
+}
+
+// Store reads feed items from MongoDB
+type Store struct {
+	items *mongo.Collection
+}
+
+// NewStore creates a store backed by the feed items collection.
+func NewStore(items *mongo.Collection) *Store {
+	return &Store{items: items}
+}
+
+// EnsureIndexes creates the text index used by Search and the indexes backing the filters of List,
+// each ending in the sort order so pages are read from the index.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	if err := s.ensureTextIndex(ctx); err != nil {
+		return err
+	}
+	_, err := s.items.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "categories", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "feed", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "feed_url", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "relevance.score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "categories", Value: 1}, {Key: "relevance.score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+// List returns up to limit items matching f, starting after the cursor when it is not nil.
+func (s *Store) List(ctx context.Context, f Filter, after *Cursor, limit int) (Page, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"text": 0}).
+		SetSort(SortOrder(f.Sort)).
+		SetLimit(int64(limit) + 1)
+	cursor, err := s.items.Find(ctx, Query(f, after), opts)
+	if err != nil {
+		return Page{}, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return Page{}, err
+	}
+
+	// One extra item is read to tell whether there is a next page
+	page := Page{Items: docs}
+	if len(docs) > limit {
+		page.Items = docs[:limit]
+		next := CursorAfter(docs[limit-1])
+		page.Next = &next
+	}
+	return page, nil
+}
+
+// Get returns the feed item with the given ID.
+func (s *Store) Get(ctx context.Context, id primitive.ObjectID) (internal.FeedItemDocument, error) {
+	var doc internal.FeedItemDocument
+	err := s.items.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(bson.M{"text": 0})).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return doc, ErrNotFound
+	}
+	return doc, err
+}
+
+// Find returns the items with the given IDs that match f, in no particular order.
+func (s *Store) Find(ctx context.Context, ids []primitive.ObjectID, f Filter) ([]internal.FeedItemDocument, error) {
+	q := Query(f, nil)
+	q["_id"] = bson.M{"$in": ids}
+	cursor, err := s.items.Find(ctx, q, options.Find().SetProjection(bson.M{"text": 0}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Feeds returns the feeds items were ingested from, ordered by title. feedURLs restricts them like
+// Filter.FeedURLs.
+func (s *Store) Feeds(ctx context.Context, feedURLs []string) ([]Feed, error) {
+	match := bson.M{"feed": bson.M{"$nin": bson.A{nil, ""}}}
+	if feedURLs != nil {
+		match["feed_url"] = bson.M{"$in": feedURLs}
+	}
+	cursor, err := s.items.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$feed",
+			"url":          bson.M{"$first": "$feed_url"},
+			"type":         bson.M{"$first": "$feed_type"},
+			"items":        bson.M{"$sum": 1},
+			"last_item_at": bson.M{"$max": "$created_at"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var feeds []Feed
+	if err := cursor.All(ctx, &feeds); err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
+
+// Query builds the MongoDB filter for f, starting after the cursor when it is not nil.
+func Query(f Filter, after *Cursor) bson.M {
+	q := bson.M{}
+	if f.Category != "" {
+		q["categories"] = f.Category
+	}
+	if f.Feed != "" {
+		q["feed"] = f.Feed
+	}
+	if f.FeedURLs != nil {
+		q["feed_url"] = bson.M{"$in": f.FeedURLs}
+	}
+	if f.Status != "" {
+		q["status"] = f.Status
+	}
+	created := bson.M{}
+	if !f.From.IsZero() {
+		created["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		created["$lt"] = f.To
+	}
+	if len(created) > 0 {
+		q["created_at"] = created
+	}
+	if f.Sort == SortRelevance && after != nil {
+		// Missing scores sort as null, below every score
+		if after.Score == nil {
+			q["$or"] = bson.A{
+				bson.M{"relevance.score": nil, "created_at": bson.M{"$lt": after.CreatedAt}},
+				bson.M{"relevance.score": nil, "created_at": after.CreatedAt, "_id": bson.M{"$lt": after.ID}},
+			}
+			return q
+		}
+		q["$or"] = bson.A{
+			bson.M{"relevance.score": bson.M{"$lt": *after.Score}},
+			bson.M{"relevance.score": *after.Score, "created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"relevance.score": *after.Score, "created_at": after.CreatedAt, "_id": bson.M{"$lt": after.ID}},
+			bson.M{"relevance.score": nil},
+		}
+		return q
+	}
+	if after != nil {
+		q["$or"] = bson.A{
+			bson.M{"created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"created_at": after.CreatedAt, "_id": bson.M{"$lt": after.ID}},
+		}
+	}
+	return q
+}
+
+// SortOrder returns the MongoDB sort of the sort order s, matching the cursor conditions of Query.
+func SortOrder(s string) bson.D {
+	if s == SortRelevance {
+		return bson.D{{Key: "relevance.score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	}
+	return bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+}
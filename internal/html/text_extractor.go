@@ -1,11 +1,11 @@
 package textextractor
 
 import (
-	"bytes"
 	"context"
 	"log/slog"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
@@ -28,6 +28,7 @@ func init() {
 
 // ExtractArticleText creates an extractor function that parses HTML and extracts visible article text,
 // stripping boilerplate like scripts, styles, navigation, headers, and footers.
+// Block-level elements are separated by blank lines so the text can later be split on paragraph boundaries.
 // The limit parameter controls the maximum number of characters to extract.
 // It returns a function that accepts a context and HTML string, and returns the extracted text and a success boolean.
 func ExtractArticleText(limit int) func(ctx context.Context, htmlStr string) (string, bool) {
@@ -36,7 +37,14 @@ func ExtractArticleText(limit int) func(ctx context.Context, htmlStr string) (st
 		if err != nil {
 			return "", false
 		}
-		var buf bytes.Buffer
+		var paragraphs []string
+		var current strings.Builder
+		flush := func() {
+			if p := strings.TrimSpace(current.String()); p != "" {
+				paragraphs = append(paragraphs, p)
+			}
+			current.Reset()
+		}
 		var dfs func(n *html.Node, skip bool)
 		isSkip := func(tag string) bool {
 			switch tag {
@@ -46,25 +54,43 @@ func ExtractArticleText(limit int) func(ctx context.Context, htmlStr string) (st
 				return false
 			}
 		}
+		isBlock := func(tag string) bool {
+			switch tag {
+			case "p", "div", "section", "article", "main", "h1", "h2", "h3", "h4", "h5", "h6",
+				"li", "ul", "ol", "pre", "blockquote", "table", "tr", "br", "hr", "dd", "dt", "figure":
+				return true
+			default:
+				return false
+			}
+		}
 		dfs = func(n *html.Node, skip bool) {
+			block := false
 			if n.Type == html.ElementNode {
 				if isSkip(n.Data) {
 					skip = true
 				}
+				block = isBlock(n.Data)
+			}
+			if block {
+				flush()
 			}
 			if n.Type == html.TextNode && !skip {
-				t := strings.TrimSpace(n.Data)
+				t := strings.Join(strings.Fields(n.Data), " ")
 				if t != "" {
-					buf.WriteString(t)
-					buf.WriteString(" ")
+					current.WriteString(t)
+					current.WriteString(" ")
 				}
 			}
 			for c := n.FirstChild; c != nil; c = c.NextSibling {
 				dfs(c, skip)
 			}
+			if block {
+				flush()
+			}
 		}
 		dfs(doc, false)
-		text := strings.TrimSpace(buf.String())
+		flush()
+		text := strings.Join(paragraphs, "\n\n")
 
 		// Record metric for all extractions
 		textLengthHistogram.Record(ctx, int64(len(text)))
@@ -74,12 +100,26 @@ func ExtractArticleText(limit int) func(ctx context.Context, htmlStr string) (st
 		}
 		if len(text) > limit {
 			slog.Warn("Text truncated", "originalLength", len(text), "limit", limit)
-			text = text[:limit]
+			text = Truncate(text, limit)
 		}
 		return text, true
 	}
 }
 
+// Truncate shortens text to at most limit bytes without cutting a UTF-8 encoded rune in half.
+func Truncate(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
+
 // StripHTMLToPlainText is a resilient fallback that removes HTML tags to plain text.
 func StripHTMLToPlainText(htmlStr string) string {
 	// Simple tag stripper as a fallback (non-boilerplate aware)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestExtractArticleText_SimpleHTML(t *testing.T) {
@@ -43,3 +44,50 @@ func TestStripHTMLToPlainText(t *testing.T) {
 		t.Fatalf("expected stripped text to contain words, got: %q", plain)
 	}
 }
+
+func TestExtractArticleText_ParagraphBoundaries(t *testing.T) {
+	html := `<html><body><p>Paragraph one.</p><div>Paragraph two.</div><ul><li>Item</li></ul></body></html>`
+	extractor := ExtractArticleText(10000)
+	text, ok := extractor(context.Background(), html)
+	if !ok {
+		t.Fatalf("expected success extracting text, got ok=false")
+	}
+	expected := "Paragraph one.\n\nParagraph two.\n\nItem"
+	if text != expected {
+		t.Fatalf("expected paragraphs separated by blank lines, got %q", text)
+	}
+}
+
+func TestExtractArticleText_TruncationKeepsRunesIntact(t *testing.T) {
+	html := `<html><body><p>日本語のテキスト</p></body></html>`
+	extractor := ExtractArticleText(7)
+	text, ok := extractor(context.Background(), html)
+	if !ok {
+		t.Fatalf("expected success extracting text even with truncation, got ok=false")
+	}
+	if !utf8.ValidString(text) {
+		t.Fatalf("expected valid UTF-8 after truncation, got %q", text)
+	}
+	if text != "日本" {
+		t.Fatalf("expected truncation on a rune boundary, got %q", text)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text     string
+		limit    int
+		expected string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 3, "hel"},
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"hello", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.text, tt.limit); got != tt.expected {
+			t.Errorf("Truncate(%q, %d) = %q, expected %q", tt.text, tt.limit, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Package itemstate keeps the read, starred and archived state of feed items per user.
+//
+// Only items a user marked individually get a state document. Marking a category, a feed or
+// everything as read stores a read marker instead, which covers every item of its scope created
+// up to the marker, so bulk marks cost one write however many items they cover and the state
+// collection only grows with the items users star, archive, annotate or mark one by one.
+package itemstate
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Item lists filtered by state
+const (
+	StateUnread   = "unread"
+	StateStarred  = "starred"
+	StateArchived = "archived"
+)
+
+// States are the valid state filters
+var States = []string{StateUnread, StateStarred, StateArchived}
+
+// Read marker scopes
+const (
+	ScopeAll      = "all"
+	ScopeCategory = "category"
+	ScopeFeed     = "feed"
+)
+
+// ErrInvalidUpdate is returned for updates that do not change anything
+var ErrInvalidUpdate = errors.New("update must set read, starred, archived or notes")
+
+// State is the state of a feed item for a user. The feed, categories and creation time of the item
+// are copied onto the state so bulk marks, unread counts and subscription filters do not need to
+// join the items.
+type State struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	User          string             `bson:"user"`
+	Item          primitive.ObjectID `bson:"item"`
+	Feed          string             `bson:"feed,omitempty"`
+	FeedURL       string             `bson:"feed_url,omitempty"`
+	Categories    []string           `bson:"categories,omitempty"`
+	ItemCreatedAt time.Time          `bson:"item_created_at"`
+	// Read is nil when the item was not marked individually and the read markers decide
+	Read       *bool      `bson:"read,omitempty"`
+	Starred    bool       `bson:"starred,omitempty"`
+	Archived   bool       `bson:"archived,omitempty"`
+	Notes      string     `bson:"notes,omitempty"`
+	ReadAt     *time.Time `bson:"read_at,omitempty"`
+	StarredAt  *time.Time `bson:"starred_at,omitempty"`
+	ArchivedAt *time.Time `bson:"archived_at,omitempty"`
+	UpdatedAt  time.Time  `bson:"updated_at"`
+}
+
+// Update changes the state of an item; nil fields are left as they are and empty notes remove them
+type Update struct {
+	Read     *bool
+	Starred  *bool
+	Archived *bool
+	Notes    *string
+}
+
+// document builds the MongoDB update applying u to the state of doc at now.
+func (u Update) document(doc internal.FeedItemDocument, now time.Time) (bson.M, error) {
+	if u.Read == nil && u.Starred == nil && u.Archived == nil && u.Notes == nil {
+		return nil, ErrInvalidUpdate
+	}
+	set := bson.M{"feed": doc.Feed, "feed_url": doc.FeedURL, "categories": doc.Categories, "item_created_at": doc.CreatedAt, "updated_at": now}
+	unset := bson.M{}
+	if u.Read != nil {
+		set["read"] = *u.Read
+		if *u.Read {
+			set["read_at"] = now
+		} else {
+			unset["read_at"] = ""
+		}
+	}
+	for _, flag := range []struct {
+		value      *bool
+		key, since string
+	}{{u.Starred, "starred", "starred_at"}, {u.Archived, "archived", "archived_at"}} {
+		switch {
+		case flag.value == nil:
+		case *flag.value:
+			set[flag.key] = true
+			set[flag.since] = now
+		default:
+			unset[flag.key] = ""
+			unset[flag.since] = ""
+		}
+	}
+	if u.Notes != nil {
+		if *u.Notes != "" {
+			set["notes"] = *u.Notes
+		} else {
+			unset["notes"] = ""
+		}
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
+
+// Marker marks every item of its scope created up to Before as read for a user
+type Marker struct {
+	User string `bson:"user"`
+	// Scope is ScopeAll, ScopeCategory or ScopeFeed, and Value the category or feed
+	Scope  string    `bson:"scope"`
+	Value  string    `bson:"value"`
+	Before time.Time `bson:"before"`
+}
+
+// Markers are the read markers of a user
+type Markers struct {
+	All        time.Time
+	Categories map[string]time.Time
+	Feeds      map[string]time.Time
+}
+
+// newMarkers collects the read markers of a user.
+func newMarkers(markers []Marker) Markers {
+	m := Markers{Categories: map[string]time.Time{}, Feeds: map[string]time.Time{}}
+	for _, marker := range markers {
+		switch marker.Scope {
+		case ScopeAll:
+			m.All = marker.Before
+		case ScopeCategory:
+			m.Categories[marker.Value] = marker.Before
+		case ScopeFeed:
+			m.Feeds[marker.Value] = marker.Before
+		}
+	}
+	return m
+}
+
+// Covers reports whether a marker covers an item of feed with categories, created at createdAt.
+func (m Markers) Covers(feed string, categories []string, createdAt time.Time) bool {
+	covered := func(before time.Time) bool { return !before.IsZero() && !createdAt.After(before) }
+	if covered(m.All) || covered(m.Feeds[feed]) {
+		return true
+	}
+	return slices.ContainsFunc(categories, func(c string) bool { return covered(m.Categories[c]) })
+}
+
+// IsRead reports whether the item doc with state st is read: its individual mark when it has one,
+// otherwise whether a marker covers it.
+func (m Markers) IsRead(st State, doc internal.FeedItemDocument) bool {
+	if st.Read != nil {
+		return *st.Read
+	}
+	return m.Covers(doc.Feed, doc.Categories, doc.CreatedAt)
+}
+
+// covered returns the MongoDB conditions of which a document matches at least one when a marker
+// covers it, with createdField holding the creation time of the item. It is empty without markers.
+func (m Markers) covered(createdField string) bson.A {
+	var conditions bson.A
+	if !m.All.IsZero() {
+		conditions = append(conditions, bson.M{createdField: bson.M{"$lte": m.All}})
+	}
+	for _, c := range sortedKeys(m.Categories) {
+		conditions = append(conditions, bson.M{"categories": c, createdField: bson.M{"$lte": m.Categories[c]}})
+	}
+	for _, f := range sortedKeys(m.Feeds) {
+		conditions = append(conditions, bson.M{"feed": f, createdField: bson.M{"$lte": m.Feeds[f]}})
+	}
+	return conditions
+}
+
+// sortedKeys returns the keys of m in order, which keeps the built queries stable.
+func sortedKeys(m map[string]time.Time) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
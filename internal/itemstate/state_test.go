@@ -0,0 +1,103 @@
+package itemstate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	day1 = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day2 = day1.AddDate(0, 0, 1)
+	day3 = day1.AddDate(0, 0, 2)
+)
+
+func testMarkers() Markers {
+	return newMarkers([]Marker{
+		{Scope: ScopeAll, Before: day1},
+		{Scope: ScopeCategory, Value: "Security", Before: day2},
+		{Scope: ScopeFeed, Value: "Go Blog", Before: day3},
+	})
+}
+
+func TestMarkers_Covers(t *testing.T) {
+	m := testMarkers()
+	cases := []struct {
+		name       string
+		feed       string
+		categories []string
+		createdAt  time.Time
+		want       bool
+	}{
+		{"all", "Kubernetes Blog", nil, day1, true},
+		{"after all", "Kubernetes Blog", []string{"DevOps"}, day1.Add(time.Second), false},
+		{"category", "Kubernetes Blog", []string{"DevOps", "Security"}, day2, true},
+		{"after category", "Kubernetes Blog", []string{"Security"}, day3, false},
+		{"feed", "Go Blog", nil, day3, true},
+		{"other feed", "Rust Blog", nil, day3, false},
+	}
+	for _, c := range cases {
+		if got := m.Covers(c.feed, c.categories, c.createdAt); got != c.want {
+			t.Errorf("%s: Covers() = %t, want %t", c.name, got, c.want)
+		}
+	}
+	if (Markers{}).Covers("Go Blog", []string{"Security"}, day1) {
+		t.Error("expected no markers to cover nothing")
+	}
+}
+
+func TestMarkers_IsRead(t *testing.T) {
+	m := testMarkers()
+	covered := internal.FeedItemDocument{Feed: "Go Blog", CreatedAt: day2}
+	uncovered := internal.FeedItemDocument{Feed: "Rust Blog", CreatedAt: day2}
+	read, unread := true, false
+
+	if !m.IsRead(State{}, covered) || m.IsRead(State{}, uncovered) {
+		t.Error("expected markers to decide items without a read mark")
+	}
+	if m.IsRead(State{Read: &unread}, covered) || !m.IsRead(State{Read: &read}, uncovered) {
+		t.Error("expected the read mark of an item to override the markers")
+	}
+}
+
+func TestMarkers_Covered(t *testing.T) {
+	want := bson.A{
+		bson.M{"item_created_at": bson.M{"$lte": day1}},
+		bson.M{"categories": "Security", "item_created_at": bson.M{"$lte": day2}},
+		bson.M{"feed": "Go Blog", "item_created_at": bson.M{"$lte": day3}},
+	}
+	if got := testMarkers().covered("item_created_at"); !reflect.DeepEqual(got, want) {
+		t.Errorf("covered() = %v, want %v", got, want)
+	}
+	if got := (Markers{}).covered("created_at"); len(got) != 0 {
+		t.Errorf("expected no conditions without markers, got %v", got)
+	}
+}
+
+func TestUpdate_Document(t *testing.T) {
+	doc := internal.FeedItemDocument{Feed: "Go Blog", FeedURL: "https://go.dev/blog/feed.atom", Categories: []string{"Go"}, CreatedAt: day1}
+	read, unstar, notes := true, false, ""
+
+	update, err := Update{Read: &read, Starred: &unstar, Notes: &notes}.document(doc, day2)
+	if err != nil {
+		t.Fatalf("document() error = %v", err)
+	}
+	want := bson.M{
+		"$set": bson.M{
+			"feed": "Go Blog", "feed_url": "https://go.dev/blog/feed.atom", "categories": []string{"Go"}, "item_created_at": day1, "updated_at": day2,
+			"read": true, "read_at": day2,
+		},
+		"$unset": bson.M{"starred": "", "starred_at": "", "notes": ""},
+	}
+	if !reflect.DeepEqual(update, want) {
+		t.Errorf("document() = %v, want %v", update, want)
+	}
+
+	if _, err := (Update{}).document(doc, day2); !errors.Is(err, ErrInvalidUpdate) {
+		t.Errorf("expected ErrInvalidUpdate for an empty update, got %v", err)
+	}
+}
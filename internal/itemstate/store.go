@@ -0,0 +1,440 @@
+package itemstate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/feeditem"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidScope is returned for read markers without a valid scope
+var ErrInvalidScope = errors.New("read marker scope must be all, category or feed")
+
+// Store persists the item states and read markers of users in MongoDB
+type Store struct {
+	states  *mongo.Collection
+	markers *mongo.Collection
+	items   *mongo.Collection
+}
+
+// NewStore creates a store backed by the item state, read marker and feed item collections.
+func NewStore(states, markers, items *mongo.Collection) *Store {
+	return &Store{states: states, markers: markers, items: items}
+}
+
+// EnsureIndexes creates the unique indexes of states and markers, the indexes backing the starred
+// and archived lists and the unread counts, each ending in the list order, and the index on items
+// for refreshing the states of an item.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.states.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "item", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "item", Value: 1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "starred", Value: 1}, {Key: "item_created_at", Value: -1}, {Key: "item", Value: -1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "archived", Value: 1}, {Key: "item_created_at", Value: -1}, {Key: "item", Value: -1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "read", Value: 1}, {Key: "item_created_at", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.markers.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "scope", Value: 1}, {Key: "value", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// States returns the states of the items with the given IDs for user, by item ID. Items without a
+// state are left out.
+func (s *Store) States(ctx context.Context, user string, ids []primitive.ObjectID) (map[primitive.ObjectID]State, error) {
+	states := map[primitive.ObjectID]State{}
+	if len(ids) == 0 {
+		return states, nil
+	}
+	cursor, err := s.states.Find(ctx, bson.M{"user": user, "item": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []State
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	for _, st := range docs {
+		states[st.Item] = st
+	}
+	return states, nil
+}
+
+// Update applies u to the state of the item doc for user and returns the new state.
+func (s *Store) Update(ctx context.Context, user string, doc internal.FeedItemDocument, u Update) (State, error) {
+	update, err := u.document(doc, time.Now().UTC())
+	if err != nil {
+		return State{}, err
+	}
+	var st State
+	err = s.states.FindOneAndUpdate(ctx, bson.M{"user": user, "item": doc.ID}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&st)
+	return st, err
+}
+
+// RefreshItem copies the feed, feed URL and categories of the stored item id to its states of all
+// users, so the lists, counts and read markers read from states follow an item processed again.
+func (s *Store) RefreshItem(ctx context.Context, id primitive.ObjectID) error {
+	var doc internal.FeedItemDocument
+	err := s.items.FindOne(ctx, bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"feed": 1, "feed_url": 1, "categories": 1})).Decode(&doc)
+	if err != nil {
+		return err
+	}
+	_, err = s.states.UpdateMany(ctx, bson.M{"item": id}, bson.M{"$set": bson.M{
+		"feed": doc.Feed, "feed_url": doc.FeedURL, "categories": doc.Categories,
+	}})
+	return err
+}
+
+// Markers returns the read markers of user.
+func (s *Store) Markers(ctx context.Context, user string) (Markers, error) {
+	cursor, err := s.markers.Find(ctx, bson.M{"user": user})
+	if err != nil {
+		return Markers{}, err
+	}
+	var markers []Marker
+	if err := cursor.All(ctx, &markers); err != nil {
+		return Markers{}, err
+	}
+	return newMarkers(markers), nil
+}
+
+// MarkRead marks every item of the scope created up to before as read for user. value is the
+// category or feed and empty for ScopeAll. Individual read marks of the covered items are cleared,
+// so items marked unread become read again, and states left without any flag are deleted.
+func (s *Store) MarkRead(ctx context.Context, user, scope, value string, before time.Time) error {
+	inScope := bson.M{"user": user, "item_created_at": bson.M{"$lte": before}}
+	switch {
+	case scope == ScopeAll && value == "":
+	case scope == ScopeCategory && value != "":
+		inScope["categories"] = value
+	case scope == ScopeFeed && value != "":
+		inScope["feed"] = value
+	default:
+		return fmt.Errorf("%w, got %q %q", ErrInvalidScope, scope, value)
+	}
+
+	_, err := s.markers.UpdateOne(ctx, bson.M{"user": user, "scope": scope, "value": value},
+		bson.M{"$max": bson.M{"before": before}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if _, err := s.states.UpdateMany(ctx, inScope, bson.M{"$unset": bson.M{"read": "", "read_at": ""}}); err != nil {
+		return err
+	}
+	inScope["starred"] = bson.M{"$exists": false}
+	inScope["archived"] = bson.M{"$exists": false}
+	inScope["notes"] = bson.M{"$exists": false}
+	_, err = s.states.DeleteMany(ctx, inScope)
+	return err
+}
+
+// RemoveUser deletes the item states and read markers of user.
+func (s *Store) RemoveUser(ctx context.Context, user string) error {
+	if _, err := s.states.DeleteMany(ctx, bson.M{"user": user}); err != nil {
+		return err
+	}
+	_, err := s.markers.DeleteMany(ctx, bson.M{"user": user})
+	return err
+}
+
+// UnreadCounts returns the number of unread processed items of user by category, counting the items
+// of feedURLs only unless it is nil. Categories without unread items are left out.
+func (s *Store) UnreadCounts(ctx context.Context, user string, feedURLs []string) (map[string]int64, error) {
+	m, err := s.Markers(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Items no marker covers, less those marked read individually, plus covered items marked unread
+	itemFilter := bson.M{"status": internal.StatusProcessed}
+	readFilter := bson.M{"user": user, "read": true}
+	unreadFilter := bson.M{"user": user, "read": false}
+	if feedURLs != nil {
+		for _, filter := range []bson.M{itemFilter, readFilter, unreadFilter} {
+			filter["feed_url"] = bson.M{"$in": feedURLs}
+		}
+	}
+	if covered := m.covered("created_at"); len(covered) > 0 {
+		itemFilter["$nor"] = covered
+		readFilter["$nor"] = m.covered("item_created_at")
+	}
+	counts, err := countCategories(ctx, s.items, itemFilter)
+	if err != nil {
+		return nil, err
+	}
+	read, err := countCategories(ctx, s.states, readFilter, s.processedItem()...)
+	if err != nil {
+		return nil, err
+	}
+	for c, n := range read {
+		counts[c] -= n
+	}
+	if covered := m.covered("item_created_at"); len(covered) > 0 {
+		unreadFilter["$or"] = covered
+		unread, err := countCategories(ctx, s.states, unreadFilter, s.processedItem()...)
+		if err != nil {
+			return nil, err
+		}
+		for c, n := range unread {
+			counts[c] += n
+		}
+	}
+
+	for c, n := range counts {
+		if n <= 0 {
+			delete(counts, c)
+		}
+	}
+	return counts, nil
+}
+
+// processedItem returns the aggregation stages keeping the states of processed items only, so
+// individual marks count over the same items as the unread counts.
+func (s *Store) processedItem() []bson.D {
+	return []bson.D{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         s.items.Name(),
+			"localField":   "item",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$match": bson.M{"status": internal.StatusProcessed}}, bson.M{"$project": bson.M{"_id": 1}}},
+			"as":           "processed",
+		}}},
+		{{Key: "$match", Value: bson.M{"processed": bson.M{"$ne": bson.A{}}}}},
+	}
+}
+
+// countCategories counts the documents of coll matching filter and passing stages by category.
+func countCategories(ctx context.Context, coll *mongo.Collection, filter bson.M, stages ...bson.D) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+	pipeline = append(pipeline, stages...)
+	cursor, err := coll.Aggregate(ctx, append(pipeline,
+		bson.D{{Key: "$unwind", Value: "$categories"}},
+		bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$categories"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+	))
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Name  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Name] = r.Count
+	}
+	return counts, nil
+}
+
+// List returns up to limit items of user in the given state matching f, starting after the cursor
+// when it is not nil. Unread items are listed in the sort order of f and processed items only when
+// f does not filter the status. Starred and archived items are listed newest first, read from the
+// states of user, and ignore the status filter and sort order.
+func (s *Store) List(ctx context.Context, user, state string, f feeditem.Filter, after *feeditem.Cursor, limit int) (feeditem.Page, error) {
+	switch state {
+	case StateUnread:
+		return s.listUnread(ctx, user, f, after, limit)
+	case StateStarred, StateArchived:
+		return s.listFlagged(ctx, user, state, f, after, limit)
+	default:
+		return feeditem.Page{}, fmt.Errorf("state must be one of %v", States)
+	}
+}
+
+// listUnread lists the items no marker covers and that are not marked read individually, with the
+// covered items marked unread individually.
+func (s *Store) listUnread(ctx context.Context, user string, f feeditem.Filter, after *feeditem.Cursor, limit int) (feeditem.Page, error) {
+	if f.Status == "" {
+		f.Status = internal.StatusProcessed
+	}
+	m, err := s.Markers(ctx, user)
+	if err != nil {
+		return feeditem.Page{}, err
+	}
+
+	conditions := bson.A{feeditem.Query(f, after)}
+	if covered := m.covered("created_at"); len(covered) > 0 {
+		unread, err := s.coveredUnread(ctx, user, m)
+		if err != nil {
+			return feeditem.Page{}, err
+		}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"$nor": covered},
+			bson.M{"_id": bson.M{"$in": unread}},
+		}})
+	}
+	read, err := s.readItems(ctx, user, m, f, after)
+	if err != nil {
+		return feeditem.Page{}, err
+	}
+	if len(read) > 0 {
+		conditions = append(conditions, bson.M{"_id": bson.M{"$nin": read}})
+	}
+
+	opts := options.Find().
+		SetSort(feeditem.SortOrder(f.Sort)).
+		SetLimit(int64(limit) + 1).
+		SetProjection(bson.M{"text": 0})
+	cursor, err := s.items.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return feeditem.Page{}, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return feeditem.Page{}, err
+	}
+	return page(docs, limit), nil
+}
+
+// readItems returns the IDs of the items marked read individually by user that no marker covers
+// and that may be listed with f after the cursor. Marking a scope read clears the individual read
+// marks it covers, so these are the items read one by one since, found through the index on the
+// read states of user.
+func (s *Store) readItems(ctx context.Context, user string, m Markers, f feeditem.Filter, after *feeditem.Cursor) ([]primitive.ObjectID, error) {
+	q := bson.M{"user": user, "read": true}
+	if covered := m.covered("item_created_at"); len(covered) > 0 {
+		q["$nor"] = covered
+	}
+	created := bson.M{}
+	if !f.From.IsZero() {
+		created["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		created["$lt"] = f.To
+	}
+	// Newest first, the items after the cursor are created no later than it
+	if after != nil && f.Sort != feeditem.SortRelevance {
+		created["$lte"] = after.CreatedAt
+	}
+	if len(created) > 0 {
+		q["item_created_at"] = created
+	}
+
+	cursor, err := s.states.Find(ctx, q, options.Find().SetProjection(bson.M{"item": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var states []State
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(states))
+	for _, st := range states {
+		ids = append(ids, st.Item)
+	}
+	return ids, nil
+}
+
+// coveredUnread returns the IDs of the items marked unread individually that a marker covers.
+func (s *Store) coveredUnread(ctx context.Context, user string, m Markers) ([]primitive.ObjectID, error) {
+	cursor, err := s.states.Find(ctx, bson.M{"user": user, "read": false, "$or": m.covered("item_created_at")},
+		options.Find().SetProjection(bson.M{"item": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var states []State
+	if err := cursor.All(ctx, &states); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(states))
+	for _, st := range states {
+		ids = append(ids, st.Item)
+	}
+	return ids, nil
+}
+
+// listFlagged lists the starred or archived items of user from their states.
+func (s *Store) listFlagged(ctx context.Context, user, flag string, f feeditem.Filter, after *feeditem.Cursor, limit int) (feeditem.Page, error) {
+	q := bson.M{"user": user, flag: true}
+	if f.Category != "" {
+		q["categories"] = f.Category
+	}
+	if f.Feed != "" {
+		q["feed"] = f.Feed
+	}
+	if f.FeedURLs != nil {
+		q["feed_url"] = bson.M{"$in": f.FeedURLs}
+	}
+	created := bson.M{}
+	if !f.From.IsZero() {
+		created["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		created["$lt"] = f.To
+	}
+	if len(created) > 0 {
+		q["item_created_at"] = created
+	}
+	if after != nil {
+		q["$or"] = bson.A{
+			bson.M{"item_created_at": bson.M{"$lt": after.CreatedAt}},
+			bson.M{"item_created_at": after.CreatedAt, "item": bson.M{"$lt": after.ID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "item_created_at", Value: -1}, {Key: "item", Value: -1}}).
+		SetLimit(int64(limit) + 1)
+	cursor, err := s.states.Find(ctx, q, opts)
+	if err != nil {
+		return feeditem.Page{}, err
+	}
+	var states []State
+	if err := cursor.All(ctx, &states); err != nil {
+		return feeditem.Page{}, err
+	}
+
+	var p feeditem.Page
+	if len(states) > limit {
+		states = states[:limit]
+		p.Next = &feeditem.Cursor{CreatedAt: states[limit-1].ItemCreatedAt, ID: states[limit-1].Item}
+	}
+	ids := make([]primitive.ObjectID, 0, len(states))
+	for _, st := range states {
+		ids = append(ids, st.Item)
+	}
+	cursor, err = s.items.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"text": 0}))
+	if err != nil {
+		return feeditem.Page{}, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return feeditem.Page{}, err
+	}
+	byID := make(map[primitive.ObjectID]internal.FeedItemDocument, len(docs))
+	for _, doc := range docs {
+		byID[doc.ID] = doc
+	}
+	for _, id := range ids {
+		if doc, ok := byID[id]; ok {
+			p.Items = append(p.Items, doc)
+		}
+	}
+	return p, nil
+}
+
+// page cuts docs, read with one extra item to tell whether there is a next page, to a page of limit.
+func page(docs []internal.FeedItemDocument, limit int) feeditem.Page {
+	p := feeditem.Page{Items: docs}
+	if len(docs) > limit {
+		p.Items = docs[:limit]
+		next := feeditem.CursorAfter(docs[limit-1])
+		p.Next = &next
+	}
+	return p
+}
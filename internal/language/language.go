@@ -0,0 +1,152 @@
+// Package language detects the language of article text. Languages written in their own script are
+// recognized by script, languages written in the Latin script by the frequency of their stopwords.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Unknown is returned when the language cannot be detected with confidence
+const Unknown = ""
+
+const (
+	// minWords is the minimum number of words needed to detect a Latin-script language
+	minWords = 20
+	// minStopwordShare is the minimum share of words that must be stopwords of the detected language
+	minStopwordShare = 0.15
+	// minScriptShare is the minimum share of letters written in a script to detect its language
+	minScriptShare = 0.3
+)
+
+// names are the English names of the languages that can be detected or used as a summary language,
+// by ISO 639-1 code
+var names = map[string]string{
+	"ar": "Arabic",
+	"da": "Danish",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"zh": "Chinese",
+}
+
+// stopwords are frequent words of the Latin-script languages that can be detected. Words shared
+// between languages count for each of them.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "are", "this", "be", "on", "as", "have", "not", "by", "from"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "zijn", "op", "voor", "met", "te", "ook", "maar", "wordt", "bij", "naar", "er", "deze"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "von", "sich", "auch", "auf", "für", "wird", "dem", "des", "im"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "un", "du", "que", "pour", "dans", "pas", "sur", "qui", "avec", "sont", "au", "ce", "il"},
+	"es": {"el", "la", "los", "las", "y", "que", "de", "es", "en", "un", "una", "por", "con", "para", "del", "se", "no", "su", "al", "lo"},
+	"it": {"il", "di", "che", "è", "e", "la", "per", "un", "una", "non", "sono", "del", "della", "con", "gli", "le", "si", "nel", "alla", "anche"},
+	"pt": {"o", "a", "os", "as", "de", "que", "é", "e", "do", "da", "em", "um", "uma", "para", "não", "com", "no", "na", "mais", "se"},
+}
+
+// stopwordSets maps each stopword to the languages it belongs to
+var stopwordSets = func() map[string][]string {
+	sets := map[string][]string{}
+	for lang, words := range stopwords {
+		for _, w := range words {
+			sets[w] = append(sets[w], lang)
+		}
+	}
+	return sets
+}()
+
+// Name returns the English name of the language with the given ISO 639-1 code, or an empty string
+// if the code is not known.
+func Name(code string) string {
+	return names[strings.ToLower(code)]
+}
+
+// Detect returns the ISO 639-1 code of the language text is written in, or Unknown when the text is
+// too short or too ambiguous.
+func Detect(text string) string {
+	if lang := detectScript(text); lang != Unknown {
+		return lang
+	}
+	return detectStopwords(text)
+}
+
+// detectScript recognizes languages by the script most letters are written in. Japanese text mixes
+// kana with Han characters, so any kana marks the text as Japanese rather than Chinese.
+func detectScript(text string) string {
+	var letters, kana, han, hangul, cyrillic, arabic int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		}
+	}
+	if letters == 0 {
+		return Unknown
+	}
+
+	share := func(n int) float64 { return float64(n) / float64(letters) }
+	switch {
+	case kana > 0 && share(kana+han) >= minScriptShare:
+		return "ja"
+	case share(han) >= minScriptShare:
+		return "zh"
+	case share(hangul) >= minScriptShare:
+		return "ko"
+	case share(cyrillic) >= minScriptShare:
+		return "ru"
+	case share(arabic) >= minScriptShare:
+		return "ar"
+	}
+	return Unknown
+}
+
+// detectStopwords recognizes Latin-script languages by counting their stopwords. The best scoring
+// language must beat the runner-up, so text mixing languages equally is not detected.
+func detectStopwords(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) < minWords {
+		return Unknown
+	}
+
+	scores := map[string]int{}
+	for _, w := range words {
+		for _, lang := range stopwordSets[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore, runnerUp := Unknown, 0, 0
+	for lang, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, runnerUp = lang, score, bestScore
+		case score > runnerUp:
+			runnerUp = score
+		}
+	}
+	if bestScore == runnerUp || float64(bestScore)/float64(len(words)) < minStopwordShare {
+		return Unknown
+	}
+	return best
+}
@@ -0,0 +1,46 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"en": "The new release of the compiler is faster than ever. It was built with a focus on performance, and the team says that this is only the start of a series of improvements for the toolchain.",
+		"nl": "De nieuwe versie van de compiler is sneller dan ooit. Het team zegt dat dit pas het begin is van een reeks verbeteringen voor de toolchain, en er wordt ook gewerkt aan betere foutmeldingen bij het bouwen.",
+		"de": "Die neue Version des Compilers ist schneller als je zuvor. Das Team sagt, dass dies erst der Anfang einer Reihe von Verbesserungen ist, und es wird auch an besseren Fehlermeldungen für den Build gearbeitet.",
+		"fr": "La nouvelle version du compilateur est plus rapide que jamais. L'équipe dit que ce n'est que le début d'une série d'améliorations pour la chaîne de compilation, et qu'il y a des messages d'erreur plus clairs dans les builds.",
+		"es": "La nueva versión del compilador es más rápida que nunca. El equipo dice que esto es solo el comienzo de una serie de mejoras para la cadena de herramientas, y que se trabaja en mensajes de error más claros para los usuarios.",
+		"ja": "新しいバージョンのコンパイラはこれまでになく高速です。チームによると、これはツールチェーンの一連の改善の始まりにすぎません。",
+		"zh": "新版本的编译器比以往任何时候都快。团队表示，这只是工具链一系列改进的开始。",
+		"ko": "새 버전의 컴파일러는 그 어느 때보다 빠릅니다. 팀은 이것이 개선의 시작일 뿐이라고 말합니다.",
+	}
+	for want, text := range tests {
+		if got := Detect(text); got != want {
+			t.Errorf("Detect(%s text) = %q, want %q", want, got, want)
+		}
+	}
+}
+
+func TestDetect_Unknown(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"Go 1.22 released",
+		"12345 67890 !!! ??? ... --- +++ 12345 67890 !!! ??? ... --- +++ 12345 67890",
+		"Kubernetes Terraform Prometheus Grafana Docker Kafka Redis Postgres MongoDB Elasticsearch Envoy Istio Linkerd Consul Vault Nomad Packer Vagrant Ansible Puppet Chef",
+	} {
+		if got := Detect(text); got != Unknown {
+			t.Errorf("Detect(%q) = %q, want Unknown", text, got)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("nl"); got != "Dutch" {
+		t.Errorf("Name(nl) = %q, want Dutch", got)
+	}
+	if got := Name("EN"); got != "English" {
+		t.Errorf("Name(EN) = %q, want English", got)
+	}
+	if got := Name("xx"); got != "" {
+		t.Errorf("Name(xx) = %q, want empty", got)
+	}
+}
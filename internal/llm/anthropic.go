@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicVersion is the Messages API version sent with every request
+	anthropicVersion = "2023-06-01"
+	// defaultMaxTokens is used when a provider does not configure a response token limit
+	defaultMaxTokens = 4096
+)
+
+// Anthropic is an LLM backed by the native Anthropic Messages API.
+type Anthropic struct {
+	httpClient *http.Client
+	host       string
+	model      string
+	apiKey     string
+	maxTokens  int
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// NewAnthropic creates a Messages API client. host is the API base URL, e.g. https://api.anthropic.com.
+func NewAnthropic(httpClient *http.Client, host, model, apiKey string, maxTokens int) *Anthropic {
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxTokens
+	}
+	return &Anthropic{
+		httpClient: httpClient,
+		host:       strings.TrimSuffix(host, "/"),
+		model:      model,
+		apiKey:     apiKey,
+		maxTokens:  maxTokens,
+	}
+}
+
+// Model implements LLM.
+func (a *Anthropic) Model() string {
+	return a.model
+}
+
+// Complete implements LLM. System messages are sent as the top-level system prompt.
+func (a *Anthropic) Complete(ctx context.Context, messages []Message) (Response, error) {
+	req := anthropicRequest{Model: a.model, MaxTokens: a.maxTokens}
+	var system []string
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			system = append(system, m.Content)
+		case RoleAssistant:
+			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: m.Content})
+		default:
+			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: m.Content})
+		}
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	var resp anthropicResponse
+	err := postJSON(ctx, a.httpClient, a.host+"/v1/messages", map[string]string{
+		"x-api-key":         a.apiKey,
+		"anthropic-version": anthropicVersion,
+	}, req, &resp)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return Response{Content: text.String(), Model: a.model, Usage: Usage{
+		PromptTokens:     resp.Usage.InputTokens,
+		CompletionTokens: resp.Usage.OutputTokens,
+	}}, nil
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnthropic_Complete(t *testing.T) {
+	var got anthropicRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "secret" {
+			t.Errorf("expected API key header, got %q", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("expected anthropic-version header, got %q", r.Header.Get("anthropic-version"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"model":"claude","content":[{"type":"text","text":"{\"summary\":"},{"type":"text","text":"\"x\"}"}],"usage":{"input_tokens":12,"output_tokens":3}}`))
+	}))
+	defer srv.Close()
+
+	client := NewAnthropic(srv.Client(), srv.URL+"/", "claude", "secret", 0)
+	resp, err := client.Complete(context.Background(), []Message{
+		{Role: RoleSystem, Content: "be terse"},
+		UserMessage("hi"),
+		AssistantMessage("hello"),
+		UserMessage("again"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != `{"summary":"x"}` {
+		t.Errorf("expected text blocks to be concatenated, got %q", resp.Content)
+	}
+	if resp.Model != "claude" {
+		t.Errorf("expected model claude, got %q", resp.Model)
+	}
+	if resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 3 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+
+	if got.System != "be terse" {
+		t.Errorf("expected system prompt to be sent separately, got %q", got.System)
+	}
+	if got.MaxTokens != defaultMaxTokens {
+		t.Errorf("expected default max tokens, got %d", got.MaxTokens)
+	}
+	if len(got.Messages) != 3 || got.Messages[0].Role != "user" || got.Messages[1].Role != "assistant" {
+		t.Errorf("unexpected messages: %+v", got.Messages)
+	}
+}
+
+func TestAnthropic_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(529)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error"}}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewAnthropic(srv.Client(), srv.URL, "claude", "secret", 0).Complete(context.Background(), []Message{UserMessage("hi")})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 529 {
+		t.Fatalf("expected StatusError with status 529, got %v", err)
+	}
+	if !isFailoverError(err) {
+		t.Error("expected overloaded error to trigger failover")
+	}
+}
@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	b := newBreaker(2, time.Minute)
+
+	b.failure()
+	if !b.allow() {
+		t.Fatal("expected breaker to allow calls below the threshold")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("expected breaker to reject calls once the threshold is reached")
+	}
+}
+
+func TestBreaker_HalfOpenAfterCooldown(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if b.allow() {
+		t.Fatal("expected open breaker to reject calls")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected breaker to allow a trial call after the cooldown")
+	}
+	if b.allow() {
+		t.Fatal("expected only a single trial call while half-open")
+	}
+
+	b.success()
+	if !b.allow() {
+		t.Fatal("expected breaker to close after a successful trial call")
+	}
+}
+
+func TestBreaker_ReopensOnFailedTrial(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected trial call after cooldown")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("expected breaker to reopen after a failed trial call")
+	}
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.failure()
+	}
+	if !b.allow() {
+		t.Fatal("expected a zero threshold to disable the breaker")
+	}
+}
+
+func TestBreaker_ReleasedTrial(t *testing.T) {
+	now := time.Now()
+	b := newBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected trial call after cooldown")
+	}
+	b.release()
+	if !b.allow() {
+		t.Fatal("expected a new trial call once the previous one was released")
+	}
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrNoProviders is returned when every provider in the chain failed or was skipped
+var ErrNoProviders = errors.New("no LLM provider available")
+
+var failoverCounter metric.Int64Counter
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	failoverCounter, _ = meter.Int64Counter(
+		"feeds.llm.failovers",
+		metric.WithDescription("Number of LLM calls that failed over to the next provider, by provider and reason"),
+		metric.WithUnit("{call}"),
+	)
+}
+
+// Chain sends requests to an ordered list of providers, failing over to the next provider
+// on connection errors, timeouts and server errors. Providers with an open circuit are skipped.
+type Chain struct {
+	providers []*Provider
+}
+
+// NewChain creates a failover chain. Providers are tried in the given order.
+func NewChain(providers ...*Provider) *Chain {
+	return &Chain{providers: providers}
+}
+
+// Model implements LLM, returning the model of the primary provider.
+func (c *Chain) Model() string {
+	if len(c.providers) == 0 {
+		return ""
+	}
+	return c.providers[0].Model()
+}
+
+// ContextSize returns the smallest context size configured across the chain, so that a prompt
+// sized for the chain fits whichever provider ends up answering. It returns fallback when no
+// provider configures a context size.
+func (c *Chain) ContextSize(fallback int) int {
+	size := 0
+	for _, p := range c.providers {
+		if p.contextSize > 0 && (size == 0 || p.contextSize < size) {
+			size = p.contextSize
+		}
+	}
+	if size == 0 {
+		return fallback
+	}
+	return size
+}
+
+// Complete implements LLM, sending the messages to the first available provider.
+func (c *Chain) Complete(ctx context.Context, messages []Message) (Response, error) {
+	var errs error
+	for _, p := range c.providers {
+		if !p.breaker.allow() {
+			errs = errors.Join(errs, fmt.Errorf("%s: circuit open", p.name))
+			recordFailover(ctx, p, "circuit_open")
+			continue
+		}
+
+		resp, err := p.Complete(ctx, messages)
+		if err == nil {
+			p.breaker.success()
+			return resp, nil
+		}
+
+		// The caller gave up; do not blame the provider or try the next one
+		if ctx.Err() != nil {
+			p.breaker.release()
+			return Response{}, ctx.Err()
+		}
+
+		if !isFailoverError(err) {
+			p.breaker.success()
+			return Response{}, fmt.Errorf("%s: %w", p.name, err)
+		}
+
+		p.breaker.failure()
+		errs = errors.Join(errs, fmt.Errorf("%s: %w", p.name, err))
+		recordFailover(ctx, p, "error")
+		slog.Warn("LLM provider failed, trying next provider", "provider", p.name, "model", p.Model(), "err", err, "circuitOpen", p.breaker.open())
+	}
+
+	return Response{}, errors.Join(ErrNoProviders, errs)
+}
+
+func recordFailover(ctx context.Context, p *Provider, reason string) {
+	failoverCounter.Add(ctx, 1, metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("llm.provider", p.name),
+		attribute.String("llm.model", p.Model()),
+		attribute.String("reason", reason),
+	)))
+}
@@ -0,0 +1,232 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCompletionServer starts an OpenAI-compatible stand-in that answers chat completions with content.
+func newCompletionServer(t *testing.T, content string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"1","object":"chat.completion","created":0,"model":"m","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":%q}}],"usage":{"prompt_tokens":7,"completion_tokens":2,"total_tokens":9}}`, content)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newStatusServer starts a stand-in that always answers with the given HTTP status.
+func newStatusServer(t *testing.T, status int, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"error":{"message":"failure","type":"server_error"}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestChain_FailsOverOnServerError(t *testing.T) {
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := newStatusServer(t, http.StatusInternalServerError, &primaryCalls)
+	secondary := newCompletionServer(t, "hello", &secondaryCalls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "primary", Host: primary.URL, Model: "a"}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 3, time.Minute),
+	)
+
+	resp, err := chain.Complete(context.Background(), []Message{UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if resp.Content != "hello" || resp.Provider != "secondary" || resp.Model != "b" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.PromptTokens != 7 || resp.Usage.CompletionTokens != 2 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+	if primaryCalls.Load() != 1 || secondaryCalls.Load() != 1 {
+		t.Fatalf("expected one call per provider, got primary=%d secondary=%d", primaryCalls.Load(), secondaryCalls.Load())
+	}
+}
+
+func TestChain_FailsOverOnConnectionError(t *testing.T) {
+	var calls atomic.Int32
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	secondary := newCompletionServer(t, "hello", &calls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "down", Host: down.URL, Model: "a"}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 3, time.Minute),
+	)
+
+	resp, err := chain.Complete(context.Background(), []Message{UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if resp.Provider != "secondary" {
+		t.Fatalf("expected secondary provider to answer, got %q", resp.Provider)
+	}
+}
+
+func TestChain_FailsOverOnTimeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	secondary := newCompletionServer(t, "hello", &calls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "slow", Host: slow.URL, Model: "a", Timeout: Duration(50 * time.Millisecond)}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 3, time.Minute),
+	)
+
+	resp, err := chain.Complete(context.Background(), []Message{UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if resp.Provider != "secondary" {
+		t.Fatalf("expected secondary provider to answer, got %q", resp.Provider)
+	}
+}
+
+func TestChain_DoesNotFailOverOnClientError(t *testing.T) {
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := newStatusServer(t, http.StatusUnauthorized, &primaryCalls)
+	secondary := newCompletionServer(t, "hello", &secondaryCalls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "primary", Host: primary.URL, Model: "a"}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 3, time.Minute),
+	)
+
+	if _, err := chain.Complete(context.Background(), []Message{UserMessage("hi")}); err == nil {
+		t.Fatal("expected client error to be returned")
+	}
+	if secondaryCalls.Load() != 0 {
+		t.Fatalf("expected no call to secondary provider, got %d", secondaryCalls.Load())
+	}
+}
+
+func TestChain_DoesNotFailOverOnInvalidResponse(t *testing.T) {
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":`)
+	}))
+	t.Cleanup(primary.Close)
+	secondary := newCompletionServer(t, "hello", &secondaryCalls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "primary", Host: primary.URL, Model: "a"}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 3, time.Minute),
+	)
+
+	if _, err := chain.Complete(context.Background(), []Message{UserMessage("hi")}); err == nil {
+		t.Fatal("expected invalid response error to be returned")
+	}
+	if secondaryCalls.Load() != 0 {
+		t.Fatalf("expected no call to secondary provider, got %d", secondaryCalls.Load())
+	}
+}
+
+func TestChain_SkipsProviderWithOpenCircuit(t *testing.T) {
+	var primaryCalls, secondaryCalls atomic.Int32
+	primary := newStatusServer(t, http.StatusBadGateway, &primaryCalls)
+	secondary := newCompletionServer(t, "hello", &secondaryCalls)
+
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "primary", Host: primary.URL, Model: "a"}, 2, time.Minute),
+		NewProvider(ProviderConfig{Name: "secondary", Host: secondary.URL, Model: "b"}, 2, time.Minute),
+	)
+
+	for i := 0; i < 4; i++ {
+		if _, err := chain.Complete(context.Background(), []Message{UserMessage("hi")}); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if primaryCalls.Load() != 2 {
+		t.Fatalf("expected primary to be skipped once its circuit opened, got %d calls", primaryCalls.Load())
+	}
+	if secondaryCalls.Load() != 4 {
+		t.Fatalf("expected secondary to answer every call, got %d", secondaryCalls.Load())
+	}
+}
+
+func TestChain_AllProvidersFail(t *testing.T) {
+	var calls atomic.Int32
+	primary := newStatusServer(t, http.StatusServiceUnavailable, &calls)
+
+	chain := NewChain(NewProvider(ProviderConfig{Name: "primary", Host: primary.URL, Model: "a"}, 3, time.Minute))
+
+	_, err := chain.Complete(context.Background(), []Message{UserMessage("hi")})
+	if !errors.Is(err, ErrNoProviders) {
+		t.Fatalf("expected ErrNoProviders, got %v", err)
+	}
+}
+
+func TestChain_ContextSize(t *testing.T) {
+	chain := NewChain(
+		NewProvider(ProviderConfig{Name: "a", Host: "http://a", Model: "a", ContextSize: 32768}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "b", Host: "http://b", Model: "b", ContextSize: 8192}, 3, time.Minute),
+		NewProvider(ProviderConfig{Name: "c", Host: "http://c", Model: "c"}, 3, time.Minute),
+	)
+	if size := chain.ContextSize(4096); size != 8192 {
+		t.Fatalf("expected smallest configured context size, got %d", size)
+	}
+	if size := NewChain().ContextSize(4096); size != 4096 {
+		t.Fatalf("expected fallback context size, got %d", size)
+	}
+}
+
+func TestChain_CancelledTrialReleasesCircuit(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			<-release
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","created":0,"model":"m","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hello"}}]}`)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	provider := NewProvider(ProviderConfig{Name: "primary", Host: srv.URL, Model: "a"}, 1, time.Minute)
+	now := time.Now()
+	provider.breaker.now = func() time.Time { return now }
+	provider.breaker.failure()
+	now = now.Add(2 * time.Minute)
+	chain := NewChain(provider)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := chain.Complete(ctx, []Message{UserMessage("hi")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the cancelled trial to return the context error, got %v", err)
+	}
+
+	resp, err := chain.Complete(context.Background(), []Message{UserMessage("hi")})
+	if err != nil {
+		t.Fatalf("expected the provider to be tried again after the cancelled trial, got %v", err)
+	}
+	if resp.Content != "hello" || calls.Load() != 2 {
+		t.Fatalf("unexpected response %+v after %d calls", resp, calls.Load())
+	}
+}
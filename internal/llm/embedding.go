@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// Embedder turns texts into embedding vectors
+type Embedder interface {
+	// Embed returns one vector per text, in the order of texts.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model returns the name of the embedding model.
+	Model() string
+}
+
+// OpenAIEmbedder is an Embedder backed by an OpenAI-compatible /embeddings endpoint, such as
+// OpenAI itself or Ollama (under /v1/).
+type OpenAIEmbedder struct {
+	client openai.Client
+	model  string
+}
+
+// NewOpenAIEmbedder creates an OpenAI-compatible embeddings client for host and model. Each request
+// times out after timeout, or defaultTimeout when it is not positive.
+func NewOpenAIEmbedder(host, model, apiKey string, timeout time.Duration) *OpenAIEmbedder {
+	if apiKey == "" {
+		// Required by the client but ignored by providers such as Ollama
+		apiKey = "unused"
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &OpenAIEmbedder{
+		client: openai.NewClient(
+			option.WithAPIKey(apiKey),
+			option.WithBaseURL(host),
+			option.WithRequestTimeout(timeout),
+			// Embedding runs in activities, which Temporal already retries
+			option.WithMaxRetries(0),
+		),
+		model: model,
+	}
+}
+
+// Model implements Embedder.
+func (o *OpenAIEmbedder) Model() string {
+	return o.model
+}
+
+// Embed implements Embedder.
+func (o *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := o.client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model:          openai.EmbeddingModel(o.model),
+		Input:          openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		EncodingFormat: openai.EmbeddingNewParamsEncodingFormatFloat,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || int(d.Index) >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		v := make([]float32, len(d.Embedding))
+		for i, f := range d.Embedding {
+			v[i] = float32(f)
+		}
+		vectors[d.Index] = v
+	}
+	return vectors, nil
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOpenAIEmbedder_Embed(t *testing.T) {
+	var got struct {
+		Model string   `json:"model"`
+		Input []string `json:"input"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Out of order on purpose: vectors must be placed by index
+		w.Write([]byte(`{"object":"list","model":"nomic-embed-text","data":[
+			{"object":"embedding","index":1,"embedding":[0,1]},
+			{"object":"embedding","index":0,"embedding":[1,0.5]}
+		],"usage":{"prompt_tokens":4,"total_tokens":4}}`))
+	}))
+	defer srv.Close()
+
+	embedder := NewOpenAIEmbedder(srv.URL, "nomic-embed-text", "", time.Minute)
+	vectors, err := embedder.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Model != "nomic-embed-text" || len(got.Input) != 2 || got.Input[0] != "first" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[0][1] != 0.5 || vectors[1][1] != 1 {
+		t.Errorf("unexpected vectors: %v", vectors)
+	}
+}
+
+func TestOpenAIEmbedder_CountMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","model":"m","data":[{"object":"embedding","index":0,"embedding":[1]}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIEmbedder(srv.URL, "m", "", time.Minute).Embed(context.Background(), []string{"a", "b"})
+	if err == nil {
+		t.Fatal("expected error when the number of embeddings does not match the inputs")
+	}
+}
+
+func TestOpenAIEmbedder_DoesNotRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := newStatusServer(t, http.StatusServiceUnavailable, &calls)
+
+	if _, err := NewOpenAIEmbedder(srv.URL, "m", "", time.Minute).Embed(context.Background(), []string{"a"}); err == nil {
+		t.Fatal("expected the server error to be returned")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("expected a single request, got %d", calls.Load())
+	}
+}
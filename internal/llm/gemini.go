@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gemini is an LLM backed by the native Gemini generateContent API.
+type Gemini struct {
+	httpClient *http.Client
+	host       string
+	model      string
+	apiKey     string
+	maxTokens  int
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int64 `json:"promptTokenCount"`
+		CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// NewGemini creates a generateContent client. host is the API base URL,
+// e.g. https://generativelanguage.googleapis.com.
+func NewGemini(httpClient *http.Client, host, model, apiKey string, maxTokens int) *Gemini {
+	return &Gemini{
+		httpClient: httpClient,
+		host:       strings.TrimSuffix(host, "/"),
+		model:      model,
+		apiKey:     apiKey,
+		maxTokens:  maxTokens,
+	}
+}
+
+// Model implements LLM.
+func (g *Gemini) Model() string {
+	return g.model
+}
+
+// Complete implements LLM. System messages are sent as the system instruction and assistant
+// messages use Gemini's "model" role.
+func (g *Gemini) Complete(ctx context.Context, messages []Message) (Response, error) {
+	req := geminiRequest{GenerationConfig: geminiGenerationConfig{MaxOutputTokens: g.maxTokens}}
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			if req.SystemInstruction == nil {
+				req.SystemInstruction = &geminiContent{}
+			}
+			req.SystemInstruction.Parts = append(req.SystemInstruction.Parts, geminiPart{Text: m.Content})
+		case RoleAssistant:
+			req.Contents = append(req.Contents, geminiContent{Role: "model", Parts: []geminiPart{{Text: m.Content}}})
+		default:
+			req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: []geminiPart{{Text: m.Content}}})
+		}
+	}
+
+	var resp geminiResponse
+	endpoint := g.host + "/v1beta/models/" + url.PathEscape(g.model) + ":generateContent"
+	err := postJSON(ctx, g.httpClient, endpoint, map[string]string{
+		"x-goog-api-key": g.apiKey,
+	}, req, &resp)
+	if err != nil {
+		return Response{}, err
+	}
+
+	var text strings.Builder
+	if len(resp.Candidates) > 0 {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			text.WriteString(part.Text)
+		}
+	}
+	return Response{Content: text.String(), Model: g.model, Usage: Usage{
+		PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+		CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+	}}, nil
+}
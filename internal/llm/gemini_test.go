@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGemini_Complete(t *testing.T) {
+	var got geminiRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1beta/models/gemini-flash:generateContent" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "secret" {
+			t.Errorf("expected API key header, got %q", r.Header.Get("x-goog-api-key"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"hello "},{"text":"world"}]}}],"usageMetadata":{"promptTokenCount":20,"candidatesTokenCount":5}}`))
+	}))
+	defer srv.Close()
+
+	client := NewGemini(srv.Client(), srv.URL, "gemini-flash", "secret", 1024)
+	resp, err := client.Complete(context.Background(), []Message{
+		{Role: RoleSystem, Content: "be terse"},
+		UserMessage("hi"),
+		AssistantMessage("hello"),
+		UserMessage("again"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Content != "hello world" {
+		t.Errorf("expected parts to be concatenated, got %q", resp.Content)
+	}
+	if resp.Usage.PromptTokens != 20 || resp.Usage.CompletionTokens != 5 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+
+	if got.SystemInstruction == nil || got.SystemInstruction.Parts[0].Text != "be terse" {
+		t.Errorf("expected system instruction to be sent separately, got %+v", got.SystemInstruction)
+	}
+	if got.GenerationConfig.MaxOutputTokens != 1024 {
+		t.Errorf("expected max output tokens 1024, got %d", got.GenerationConfig.MaxOutputTokens)
+	}
+	if len(got.Contents) != 3 || got.Contents[0].Role != "user" || got.Contents[1].Role != "model" {
+		t.Errorf("unexpected contents: %+v", got.Contents)
+	}
+}
+
+func TestGemini_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"code":400,"message":"bad request"}}`))
+	}))
+	defer srv.Close()
+
+	_, err := NewGemini(srv.Client(), srv.URL, "gemini-flash", "secret", 0).Complete(context.Background(), []Message{UserMessage("hi")})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected StatusError with status 400, got %v", err)
+	}
+	if isFailoverError(err) {
+		t.Error("expected bad request not to trigger failover")
+	}
+}
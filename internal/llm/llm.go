@@ -0,0 +1,139 @@
+// Package llm provides clients for LLM providers (OpenAI-compatible endpoints, Anthropic and Gemini),
+// combined into an ordered failover chain with a circuit breaker per provider.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Provider types supported in ProviderConfig.Type
+const (
+	TypeOpenAI    = "openai"
+	TypeAnthropic = "anthropic"
+	TypeGemini    = "gemini"
+)
+
+// LLM is a chat completion client
+type LLM interface {
+	// Complete sends the messages to the model and returns its response.
+	Complete(ctx context.Context, messages []Message) (Response, error)
+	// Model returns the name of the model used for completions.
+	Model() string
+}
+
+// Role is the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single chat message sent to or received from an LLM
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// SystemMessage creates a message carrying the system instructions.
+func SystemMessage(content string) Message {
+	return Message{Role: RoleSystem, Content: content}
+}
+
+// UserMessage creates a message authored by the user.
+func UserMessage(content string) Message {
+	return Message{Role: RoleUser, Content: content}
+}
+
+// AssistantMessage creates a message authored by the assistant.
+func AssistantMessage(content string) Message {
+	return Message{Role: RoleAssistant, Content: content}
+}
+
+// Usage is the number of tokens consumed by a chat completion
+type Usage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// Response is the result of a chat completion together with the provider and model that produced it
+type Response struct {
+	Content  string
+	Provider string
+	Model    string
+	Usage    Usage
+}
+
+// Duration is a time.Duration that unmarshals from a JSON string such as "90s"
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ProviderConfig describes a single provider in the failover chain.
+// Type selects the API flavour and defaults to an OpenAI-compatible endpoint.
+type ProviderConfig struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Host        string   `json:"host"`
+	Model       string   `json:"model"`
+	APIKey      string   `json:"apiKey"`
+	Timeout     Duration `json:"timeout"`
+	Concurrency int      `json:"concurrency"`
+	ContextSize int      `json:"contextSize"`
+	MaxTokens   int      `json:"maxTokens"`
+}
+
+// LoadProviders reads an ordered list of provider configurations from a JSON file.
+// Environment variables referenced as ${VAR} in API keys are expanded so secrets can stay out of the file.
+func LoadProviders(path string) ([]ProviderConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []ProviderConfig
+	if err := json.Unmarshal(b, &providers); err != nil {
+		return nil, err
+	}
+
+	for i := range providers {
+		providers[i].APIKey = os.ExpandEnv(providers[i].APIKey)
+		if providers[i].Name == "" || providers[i].Host == "" || providers[i].Model == "" {
+			return nil, fmt.Errorf("provider %d: name, host and model are required", i)
+		}
+		switch providers[i].Type {
+		case "", TypeOpenAI, TypeAnthropic, TypeGemini:
+		default:
+			return nil, fmt.Errorf("provider %d: unknown type %q", i, providers[i].Type)
+		}
+	}
+
+	return providers, nil
+}
+
+var (
+	_ LLM = (*OpenAI)(nil)
+	_ LLM = (*Anthropic)(nil)
+	_ LLM = (*Gemini)(nil)
+	_ LLM = (*Provider)(nil)
+	_ LLM = (*Chain)(nil)
+
+	_ Embedder = (*OpenAIEmbedder)(nil)
+)
@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+)
+
+// OpenAI is an LLM backed by an OpenAI-compatible chat completions endpoint, such as
+// OpenAI itself, Ollama (under /v1/) or OpenCode Zen.
+type OpenAI struct {
+	client openai.Client
+	model  string
+}
+
+// NewOpenAI creates an OpenAI-compatible client for host and model.
+func NewOpenAI(host, model, apiKey string) *OpenAI {
+	if apiKey == "" {
+		// Required by the client but ignored by providers such as Ollama
+		apiKey = "unused"
+	}
+
+	return &OpenAI{
+		client: openai.NewClient(
+			option.WithAPIKey(apiKey),
+			option.WithBaseURL(host),
+			// Failures fail over to the next provider instead of being retried here
+			option.WithMaxRetries(0),
+		),
+		model: model,
+	}
+}
+
+// Model implements LLM.
+func (o *OpenAI) Model() string {
+	return o.model
+}
+
+// Complete implements LLM.
+func (o *OpenAI) Complete(ctx context.Context, messages []Message) (Response, error) {
+	params := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages))
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			params = append(params, openai.SystemMessage(m.Content))
+		case RoleAssistant:
+			params = append(params, openai.AssistantMessage(m.Content))
+		default:
+			params = append(params, openai.UserMessage(m.Content))
+		}
+	}
+
+	resp, err := o.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model:    openai.ChatModel(o.model),
+		Messages: params,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	result := Response{Model: o.model, Usage: Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}}
+	if len(resp.Choices) > 0 {
+		result.Content = resp.Choices[0].Message.Content
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// defaultTimeout is used for providers that do not configure a timeout
+const defaultTimeout = 2 * time.Minute
+
+// Provider wraps an LLM with a name, timeout, concurrency limit and circuit breaker
+// so it can take part in a failover Chain.
+type Provider struct {
+	name        string
+	contextSize int
+	timeout     time.Duration
+	llm         LLM
+	sem         chan struct{}
+	breaker     *breaker
+}
+
+// NewProvider creates a provider from its configuration, selecting the client implementation by type.
+// The breaker opens after breakerThreshold consecutive failures and stays open for breakerCooldown.
+func NewProvider(cfg ProviderConfig, breakerThreshold int, breakerCooldown time.Duration) *Provider {
+	var client LLM
+	switch cfg.Type {
+	case TypeAnthropic:
+		client = NewAnthropic(http.DefaultClient, cfg.Host, cfg.Model, cfg.APIKey, cfg.MaxTokens)
+	case TypeGemini:
+		client = NewGemini(http.DefaultClient, cfg.Host, cfg.Model, cfg.APIKey, cfg.MaxTokens)
+	default:
+		client = NewOpenAI(cfg.Host, cfg.Model, cfg.APIKey)
+	}
+
+	timeout := time.Duration(cfg.Timeout)
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	var sem chan struct{}
+	if cfg.Concurrency > 0 {
+		sem = make(chan struct{}, cfg.Concurrency)
+	}
+
+	return &Provider{
+		name:        cfg.Name,
+		contextSize: cfg.ContextSize,
+		timeout:     timeout,
+		llm:         client,
+		sem:         sem,
+		breaker:     newBreaker(breakerThreshold, breakerCooldown),
+	}
+}
+
+// Name returns the configured provider name.
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Model implements LLM.
+func (p *Provider) Model() string {
+	return p.llm.Model()
+}
+
+// Complete implements LLM, waiting for a free concurrency slot first.
+func (p *Provider) Complete(ctx context.Context, messages []Message) (Response, error) {
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+			defer func() { <-p.sem }()
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	resp, err := p.llm.Complete(ctx, messages)
+	if err != nil {
+		return Response{}, err
+	}
+	resp.Provider = p.name
+	return resp, nil
+}
+
+// isFailoverError reports whether err should cause the next provider in the chain to be tried:
+// connection errors, timeouts, rate limiting and server errors. Client errors such as 400 or 401
+// point at a problem with the request or credentials, and other errors such as invalid responses
+// or configuration are returned as-is.
+func isFailoverError(err error) bool {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return isFailoverStatus(apiErr.StatusCode)
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return isFailoverStatus(statusErr.StatusCode)
+	}
+	// The per-provider timeout expired
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// The HTTP client wraps transport failures in a *url.Error, which is also returned for requests
+	// that could not be sent at all, such as an unsupported scheme
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if urlErr.Timeout() || errors.Is(urlErr.Err, io.EOF) || errors.Is(urlErr.Err, io.ErrUnexpectedEOF) {
+			return true
+		}
+		err = urlErr.Err
+	}
+	// Refused connections, DNS failures and resets
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isFailoverStatus(code int) bool {
+	return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+}
@@ -0,0 +1,60 @@
+package netguard
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"::1":                  false,
+		"10.0.0.5":             false,
+		"172.18.0.3":           false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"fe80::1":              false,
+		"fd00::1":              false,
+		"100.64.0.1":           false,
+		"0.0.0.0":              false,
+		"::ffff:127.0.0.1":     false,
+	} {
+		if got := Public(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Public(%s) = %t, want %t", addr, got, want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for raw, forbidden := range map[string]bool{
+		"https://go.dev/blog/feed.atom":            false,
+		"http://mongodb:27017/":                    false,
+		"http://localhost:8080/feed":               true,
+		"http://api.localhost./feed":               true,
+		"http://127.0.0.1/feed":                    true,
+		"http://[::1]:8080/feed":                   true,
+		"http://169.254.169.254/latest/meta-data/": true,
+		"http://10.1.2.3/feed":                     true,
+		"https://[2606:2800:220:1::248]/feed.atom": false,
+	} {
+		if err := CheckURL(raw); errors.Is(err, ErrForbiddenAddress) != forbidden {
+			t.Errorf("CheckURL(%s) = %v, want forbidden %t", raw, err, forbidden)
+		}
+	}
+}
+
+func TestNewClient_RefusesLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	_, err := NewClient(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("expected ErrForbiddenAddress, got %v", err)
+	}
+}
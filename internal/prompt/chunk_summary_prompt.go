@@ -0,0 +1,23 @@
+package prompt
+
+// The chunk summary templates summarize one part of an article that is too long to fit the model's
+// context window. The partial summaries are later combined and passed to the process content
+// templates to produce the final summary and categories.
+const (
+	// ChunkSummarySystemTemplate is the system prompt for summarizing a chunk
+	ChunkSummarySystemTemplate = "chunk_summary_system"
+	// ChunkSummaryTemplate is the user prompt carrying the delimited, untrusted chunk
+	ChunkSummaryTemplate = "chunk_summary"
+)
+
+// ChunkSummaryData is the data passed to the chunk summary template
+type ChunkSummaryData struct {
+	// Title is the title of the article
+	Title string
+	// Part is the 1-based index of this chunk
+	Part int
+	// Total is the total number of chunks
+	Total int
+	// Content is the chunk text to summarize
+	Content string
+}
@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildChunkSummaryPrompt_Basic(t *testing.T) {
+	p, err := Defaults().Render(ChunkSummaryTemplate, ChunkSummaryData{Title: "Sample Title", Part: 2, Total: 3, Content: "Chunk content."})
+	if err != nil {
+		t.Fatalf("failed to render prompt: %v", err)
+	}
+
+	if !strings.Contains(p, "Sample Title") || !strings.Contains(p, "Chunk content.") {
+		t.Fatalf("prompt does not include input data: %q", p)
+	}
+	if !strings.Contains(p, "part 2 of 3") {
+		t.Error("prompt should state which part of the article is being summarized")
+	}
+
+	system, err := Defaults().Render(ChunkSummarySystemTemplate, ChunkSummaryData{Title: "Sample Title", Part: 2, Total: 3, Content: "Chunk content."})
+	if err != nil {
+		t.Fatalf("failed to render prompt: %v", err)
+	}
+	if !strings.Contains(system, "plain text") {
+		t.Error("system prompt should request plain text output")
+	}
+	if strings.Contains(system, "Chunk content.") {
+		t.Error("system prompt should not contain the untrusted chunk")
+	}
+}
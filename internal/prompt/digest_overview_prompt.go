@@ -0,0 +1,28 @@
+package prompt
+
+// The digest overview templates ask for a short overview of the items in a daily or weekly digest.
+const (
+	// DigestOverviewSystemTemplate is the system prompt with the overview instructions
+	DigestOverviewSystemTemplate = "digest_overview_system"
+	// DigestOverviewTemplate is the user prompt carrying the delimited, untrusted digest items
+	DigestOverviewTemplate = "digest_overview"
+)
+
+// DigestOverviewData is the data passed to the digest overview templates
+type DigestOverviewData struct {
+	// Frequency is the frequency of the digest, "daily" or "weekly"
+	Frequency string
+	// Period is the period covered by the digest, "day" or "week"
+	Period string
+	// Language is the name of the language to write the overview in; empty lets the LLM choose
+	Language string
+	// Items are the items in the digest
+	Items []DigestOverviewItem
+}
+
+// DigestOverviewItem is an item in the digest overview prompt
+type DigestOverviewItem struct {
+	Category string
+	Title    string
+	Summary  string
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"regexp"
+	"sort"
+)
+
+// delimiterPattern matches tags that look like the article delimiters used in the templates,
+// including closing tags and whitespace or case variations.
+var delimiterPattern = regexp.MustCompile(`(?i)<\s*/?\s*article_[a-z_]*\s*>`)
+
+// Injection signals reported by DetectInjection
+const (
+	SignalIgnoreInstructions = "ignore_instructions"
+	SignalRoleOverride       = "role_override"
+	SignalPromptExfiltration = "prompt_exfiltration"
+	SignalOutputOverride     = "output_override"
+	SignalChatMarkers        = "chat_markers"
+	SignalDelimiterSpoof     = "delimiter_spoof"
+)
+
+// injectionPatterns maps each signal to the instruction-like text that raises it
+var injectionPatterns = map[string]*regexp.Regexp{
+	SignalIgnoreInstructions: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[^.\n]{0,40}\b(previous|prior|above|earlier|preceding|system|all)\b[^.\n]{0,20}\b(instructions?|prompts?|rules|directions|context)\b`),
+	SignalRoleOverride:       regexp.MustCompile(`(?i)\b(you are now|from now on,? you|pretend (to be|you are)|act as (a|an|the)\b[^.\n]{0,40}\b(assistant|ai|model|bot))`),
+	SignalPromptExfiltration: regexp.MustCompile(`(?i)\b(reveal|print|repeat|output|show|leak)\b[^.\n]{0,30}\b(your|the)\s+(system prompt|instructions|initial prompt)`),
+	SignalOutputOverride:     regexp.MustCompile(`(?i)("summary"\s*:|"categories"\s*:|\b(respond|reply|answer|output|return)\s+(only\s+)?(with|exactly)\b[^.\n]{0,30}\b(json|summary|categor))`),
+	SignalChatMarkers:        regexp.MustCompile(`(?im)(<\|im_start\|>|<\|im_end\|>|<\|system\|>|<\|assistant\|>|\[/?INST\]|<</?SYS>>|^\s*(system|assistant)\s*:)`),
+	SignalDelimiterSpoof:     delimiterPattern,
+}
+
+// EscapeDelimiters neutralizes anything in untrusted text that looks like an article delimiter,
+// so content cannot close its own section and pose as instructions.
+func EscapeDelimiters(text string) string {
+	return delimiterPattern.ReplaceAllString(text, "[removed tag]")
+}
+
+// DetectInjection returns the sorted names of the injection signals raised by text, or nil if the
+// text contains nothing instruction-like. Signals are heuristics used to flag articles for review;
+// they do not block processing.
+func DetectInjection(text string) []string {
+	var signals []string
+	for signal, pattern := range injectionPatterns {
+		if pattern.MatchString(text) {
+			signals = append(signals, signal)
+		}
+	}
+	sort.Strings(signals)
+	return signals
+}
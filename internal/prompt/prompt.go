@@ -0,0 +1,185 @@
+// Package prompt renders the LLM prompts used by the feeds aggregator from versioned text/template files.
+// Default templates are embedded in the binary and can be overridden per file from a directory.
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateExt is the file extension of prompt templates; the template name is the file name without it
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// versionPattern matches the version header at the top of a template, e.g. {{- /* version: 3 */ -}}
+var versionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/\s*-?\}\}`)
+
+// Template is a parsed prompt template with its version identifier
+type Template struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// Templates is the set of prompt templates available to the worker
+type Templates struct {
+	templates map[string]*Template
+}
+
+// funcs are the functions available to prompt templates
+var funcs = template.FuncMap{
+	"untrusted": EscapeDelimiters,
+	"inc":       func(i int) int { return i + 1 },
+}
+
+// sampleData holds representative data for each known template, used to validate that templates render.
+var sampleData = map[string]any{
+	ProcessContentSystemTemplate: ProcessContentData{Title: "Title", URL: "https://example.com", Content: "Content", Categories: []Category{{Name: "Security", Description: "Vulnerabilities"}}, SummaryLanguage: "English", ArticleLanguage: "Dutch", KeepOriginal: true},
+	ProcessContentTemplate:       ProcessContentData{Title: "Title", URL: "https://example.com", Content: "Content"},
+	RepairTemplate:               RepairData{Error: "response is not a valid JSON object"},
+	ChunkSummarySystemTemplate:   ChunkSummaryData{Title: "Title", Part: 1, Total: 2, Content: "Content"},
+	ChunkSummaryTemplate:         ChunkSummaryData{Title: "Title", Part: 1, Total: 2, Content: "Content"},
+	ReleaseNotesSystemTemplate:   ReleaseNotesData{Feed: "Feed", Title: "Title", URL: "https://example.com", Content: "Content"},
+	ReleaseNotesTemplate:         ReleaseNotesData{Feed: "Feed", Title: "Title", URL: "https://example.com", Content: "Content"},
+	ReleaseNotesRepairTemplate:   RepairData{Error: "version is not a semantic version"},
+	DigestOverviewSystemTemplate: DigestOverviewData{Frequency: "weekly", Period: "week", Language: "English"},
+	DigestOverviewTemplate:       DigestOverviewData{Frequency: "weekly", Period: "week", Items: []DigestOverviewItem{{Category: "Security", Title: "Title", Summary: "Summary"}}},
+	RelevanceSystemTemplate:      RelevanceData{Profiles: []RelevanceProfile{{Name: "platform", Statement: "Statement"}}},
+	RelevanceTemplate:            RelevanceData{Feed: "Feed", Title: "Title", Categories: []string{"Security"}, Summary: "Summary"},
+}
+
+// Defaults returns the embedded default templates.
+func Defaults() *Templates {
+	t, err := Load("")
+	if err != nil {
+		// The embedded templates are validated by tests, so this is a programming error
+		panic(err)
+	}
+	return t
+}
+
+// Load parses the embedded default templates and overrides them with any *.tmpl files found in dir.
+// An empty dir uses the embedded defaults only.
+func Load(dir string) (*Templates, error) {
+	t := &Templates{templates: map[string]*Template{}}
+
+	entries, err := fs.Glob(defaultTemplates, "templates/*"+templateExt)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		b, err := defaultTemplates.ReadFile(entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.add(filepath.Base(entry), string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir == "" {
+		return t, nil
+	}
+
+	overrides, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range overrides {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.add(filepath.Base(path), string(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Templates) add(filename, text string) error {
+	name := strings.TrimSuffix(filename, templateExt)
+
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("parse prompt template %s: %w", filename, err)
+	}
+
+	t.templates[name] = &Template{Name: name, Version: templateVersion(text), tmpl: tmpl}
+	return nil
+}
+
+// templateVersion returns the version declared in the template header, or a hash of the
+// template text when no version is declared so that every edit still changes the version.
+func templateVersion(text string) string {
+	if m := versionPattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	sum := sha256.Sum256([]byte(text))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// Version returns the version identifier of the named template, or an empty string if it does not exist.
+func (t *Templates) Version(name string) string {
+	if tmpl, ok := t.templates[name]; ok {
+		return tmpl.Version
+	}
+	return ""
+}
+
+// CombinedVersion returns a single version identifier for a set of templates that are used together,
+// such as the system and user templates of one request.
+func (t *Templates) CombinedVersion(names ...string) string {
+	versions := make([]string, 0, len(names))
+	for _, name := range names {
+		versions = append(versions, t.Version(name))
+	}
+	return strings.Join(versions, "+")
+}
+
+// Render executes the named template with data and returns the prompt text.
+func (t *Templates) Render(name string, data any) (string, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return "", fmt.Errorf("prompt template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render prompt template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Validate renders every known template with sample data, so broken overrides are caught at startup.
+func (t *Templates) Validate() error {
+	names := make([]string, 0, len(sampleData))
+	for name := range sampleData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		out, err := t.Render(name, sampleData[name])
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(out) == "" {
+			return fmt.Errorf("prompt template %s renders to an empty prompt", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaults_Validate(t *testing.T) {
+	if err := Defaults().Validate(); err != nil {
+		t.Fatalf("expected embedded templates to render, got %v", err)
+	}
+}
+
+func TestDefaults_Versions(t *testing.T) {
+	templates := Defaults()
+	for _, name := range []string{ProcessContentSystemTemplate, ProcessContentTemplate, RepairTemplate, ChunkSummarySystemTemplate, ChunkSummaryTemplate} {
+		if templates.Version(name) == "" {
+			t.Errorf("expected template %s to have a version", name)
+		}
+	}
+}
+
+func TestDefaults_VersionHeaderNotRendered(t *testing.T) {
+	p, err := Defaults().Render(RepairTemplate, RepairData{Error: "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(p, "version:") || !strings.HasPrefix(p, "Your previous response") {
+		t.Errorf("expected version header to be stripped from the output, got %q", p)
+	}
+}
+
+func TestLoad_Override(t *testing.T) {
+	dir := t.TempDir()
+	override := "{{- /* version: 2-custom */ -}}\nSummarize {{ .Title }} in one sentence: {{ .Content }}"
+	if err := os.WriteFile(filepath.Join(dir, ProcessContentTemplate+".tmpl"), []byte(override), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := templates.Version(ProcessContentTemplate); v != "2-custom" {
+		t.Errorf("expected overridden version 2-custom, got %q", v)
+	}
+	p, err := templates.Render(ProcessContentTemplate, ProcessContentData{Title: "T", Content: "C"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p != "Summarize T in one sentence: C" {
+		t.Errorf("expected overridden template to be rendered, got %q", p)
+	}
+	if templates.Version(RepairTemplate) != Defaults().Version(RepairTemplate) {
+		t.Error("expected templates without an override to keep the embedded default")
+	}
+}
+
+func TestLoad_VersionFallsBackToHash(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, RepairTemplate+".tmpl"), []byte("Fix this: {{ .Error }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v := templates.Version(RepairTemplate); !strings.HasPrefix(v, "sha256:") {
+		t.Errorf("expected hash-based version for template without header, got %q", v)
+	}
+}
+
+func TestLoad_InvalidTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, RepairTemplate+".tmpl"), []byte("{{ .Error "), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(dir); err == nil {
+		t.Fatal("expected parse error for invalid template")
+	}
+}
+
+func TestValidate_UnknownField(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, RepairTemplate+".tmpl"), []byte("Fix this: {{ .Missing }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := Load(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := templates.Validate(); err == nil {
+		t.Fatal("expected validation to fail for a template referencing an unknown field")
+	}
+}
+
+func TestCombinedVersion(t *testing.T) {
+	templates := Defaults()
+	want := templates.Version(ProcessContentSystemTemplate) + "+" + templates.Version(ProcessContentTemplate)
+	if v := templates.CombinedVersion(ProcessContentSystemTemplate, ProcessContentTemplate); v != want {
+		t.Errorf("expected combined version %q, got %q", want, v)
+	}
+}
@@ -0,0 +1,23 @@
+package prompt
+
+// The release notes templates extract structured release information from the items of release feeds.
+const (
+	// ReleaseNotesSystemTemplate is the system prompt with the extraction instructions
+	ReleaseNotesSystemTemplate = "release_notes_system"
+	// ReleaseNotesTemplate is the user prompt carrying the delimited, untrusted release notes
+	ReleaseNotesTemplate = "release_notes"
+	// ReleaseNotesRepairTemplate asks the LLM to correct an invalid release extraction
+	ReleaseNotesRepairTemplate = "release_notes_repair"
+)
+
+// ReleaseNotesData is the data passed to the release notes templates
+type ReleaseNotesData struct {
+	// Feed is the title of the release feed
+	Feed string
+	// Title is the title of the release
+	Title string
+	// URL is the URL of the release
+	URL string
+	// Content is the text of the release notes
+	Content string
+}
@@ -0,0 +1,33 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildReleaseNotesPrompt_Basic(t *testing.T) {
+	data := ReleaseNotesData{Feed: "Terraform changelog", Title: "v1.9.0", URL: "https://github.com/hashicorp/terraform/releases/tag/v1.9.0", Content: "BREAKING CHANGES: ..."}
+	p, err := Defaults().Render(ReleaseNotesTemplate, data)
+	if err != nil {
+		t.Fatalf("failed to render prompt: %v", err)
+	}
+
+	for _, s := range []string{data.Feed, data.Title, data.URL, data.Content, "<article_content>"} {
+		if !strings.Contains(p, s) {
+			t.Errorf("prompt should contain %q: %q", s, p)
+		}
+	}
+}
+
+func TestBuildReleaseNotesPrompt_SystemFields(t *testing.T) {
+	p, err := Defaults().Render(ReleaseNotesSystemTemplate, ReleaseNotesData{})
+	if err != nil {
+		t.Fatalf("failed to render prompt: %v", err)
+	}
+
+	for _, field := range []string{`"product"`, `"version"`, `"releaseDate"`, `"breakingChanges"`, `"securityFixes"`, `"deprecations"`, `"highlights"`} {
+		if !strings.Contains(p, field) {
+			t.Errorf("system prompt should describe field %s", field)
+		}
+	}
+}
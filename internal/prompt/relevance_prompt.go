@@ -0,0 +1,30 @@
+package prompt
+
+// The relevance templates ask how relevant a processed item is to the interest statements of the
+// team profiles.
+const (
+	// RelevanceSystemTemplate is the system prompt with the interest statements and scoring instructions
+	RelevanceSystemTemplate = "relevance_system"
+	// RelevanceTemplate is the user prompt carrying the delimited, untrusted item
+	RelevanceTemplate = "relevance"
+)
+
+// RelevanceData is the data passed to the relevance templates
+type RelevanceData struct {
+	// Profiles are the interest profiles to judge the item against
+	Profiles []RelevanceProfile
+	// Feed is the title of the feed the item was published in
+	Feed string
+	// Title is the title of the item
+	Title string
+	// Categories are the categories of the item
+	Categories []string
+	// Summary is the summary of the item
+	Summary string
+}
+
+// RelevanceProfile is an interest profile in the relevance prompt
+type RelevanceProfile struct {
+	Name      string
+	Statement string
+}
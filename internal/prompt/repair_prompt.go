@@ -0,0 +1,13 @@
+package prompt
+
+// RepairTemplate is the follow-up prompt asking the LLM to correct its previous answer.
+// It is sent after the previous (invalid) assistant response so the model can see what it produced.
+const RepairTemplate = "repair"
+
+// RepairData is the data passed to the repair template
+type RepairData struct {
+	// Error describes why the previous response was rejected
+	Error string
+	// KeepOriginal asks for the summary in the article language as well, see ProcessContentData
+	KeepOriginal bool
+}
@@ -0,0 +1,38 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderRepair renders the default repair template for tests.
+func renderRepair(t *testing.T, validationErr string) string {
+	t.Helper()
+	p, err := Defaults().Render(RepairTemplate, RepairData{Error: validationErr})
+	if err != nil {
+		t.Fatalf("failed to render prompt: %v", err)
+	}
+	return p
+}
+
+func TestBuildRepairPrompt_ContainsValidationError(t *testing.T) {
+	p := renderRepair(t, "categories must be between 1 and 5")
+
+	if !strings.Contains(p, "categories must be between 1 and 5") {
+		t.Fatalf("repair prompt should include the validation error: %q", p)
+	}
+}
+
+func TestBuildRepairPrompt_JSONOutputInstruction(t *testing.T) {
+	p := renderRepair(t, "invalid JSON")
+
+	if !strings.Contains(p, `"summary"`) || !strings.Contains(p, `"categories"`) {
+		t.Error("repair prompt should restate the expected JSON structure")
+	}
+	if !strings.Contains(p, "valid JSON object") {
+		t.Error("repair prompt should instruct valid JSON object output")
+	}
+	if !strings.Contains(p, "between 1 and 5") {
+		t.Error("repair prompt should restate the category count constraint")
+	}
+}
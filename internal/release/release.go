@@ -0,0 +1,89 @@
+// Package release validates the release information the LLM extracts from release notes and
+// queries the stored releases by product and version.
+package release
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+)
+
+// ErrMissingProduct is returned when the release has no product name
+var ErrMissingProduct = errors.New("product must not be empty")
+
+// dateLayout is the layout of release dates returned by the LLM
+const dateLayout = "2006-01-02"
+
+// Raw is the release information as returned by the LLM
+type Raw struct {
+	Product         string   `json:"product"`
+	Version         string   `json:"version"`
+	ReleaseDate     string   `json:"releaseDate"`
+	BreakingChanges []string `json:"breakingChanges"`
+	SecurityFixes   []string `json:"securityFixes"`
+	Deprecations    []string `json:"deprecations"`
+	Highlights      []string `json:"highlights"`
+}
+
+// Normalize validates raw and converts it to a Release. The version may carry the product name or
+// a tag prefix, as in "Terraform v1.9.0" or "go1.22.0". A missing or invalid release date falls
+// back to published, as the date of the feed item is a good approximation. The returned error
+// describes the problem in terms the LLM can act upon during a repair attempt.
+func Normalize(raw Raw, published *time.Time) (internal.Release, error) {
+	product := strings.TrimSpace(raw.Product)
+	if product == "" {
+		return internal.Release{}, ErrMissingProduct
+	}
+
+	version, err := semver.Parse(versionNumber(raw.Version))
+	if err != nil {
+		return internal.Release{}, fmt.Errorf("version %q is not a version number such as 1.9.0: %w", raw.Version, err)
+	}
+
+	r := internal.Release{
+		Product:         product,
+		ProductKey:      entity.Key(product),
+		Version:         version,
+		ReleaseDate:     published,
+		BreakingChanges: cleanList(raw.BreakingChanges),
+		SecurityFixes:   cleanList(raw.SecurityFixes),
+		Deprecations:    cleanList(raw.Deprecations),
+		Highlights:      cleanList(raw.Highlights),
+	}
+	if date, err := time.Parse(dateLayout, strings.TrimSpace(raw.ReleaseDate)); err == nil {
+		r.ReleaseDate = &date
+	}
+	return r, nil
+}
+
+// versionNumber strips anything before the version number, such as a product name or tag prefix.
+func versionNumber(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimLeftFunc(fields[len(fields)-1], func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+}
+
+// cleanList trims the items of list and drops empty items and duplicates.
+func cleanList(list []string) []string {
+	var cleaned []string
+	seen := map[string]bool{}
+	for _, item := range list {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		cleaned = append(cleaned, item)
+	}
+	return cleaned
+}
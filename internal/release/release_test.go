@@ -0,0 +1,111 @@
+package release
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNormalize(t *testing.T) {
+	raw := Raw{
+		Product:         " Terraform ",
+		Version:         "v1.9.0",
+		ReleaseDate:     "2024-06-26",
+		BreakingChanges: []string{"Removed the -lock flag", " ", "Removed the -lock flag"},
+		SecurityFixes:   []string{"Fixed CVE-2024-1234"},
+	}
+
+	r, err := Normalize(raw, nil)
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if r.Product != "Terraform" || r.ProductKey != "terraform" {
+		t.Errorf("product = %q (%q), want Terraform (terraform)", r.Product, r.ProductKey)
+	}
+	if r.Version != (semver.Version{Major: 1, Minor: 9}) {
+		t.Errorf("version = %v, want 1.9.0", r.Version)
+	}
+	if r.ReleaseDate == nil || !r.ReleaseDate.Equal(time.Date(2024, 6, 26, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("release date = %v, want 2024-06-26", r.ReleaseDate)
+	}
+	if !reflect.DeepEqual(r.BreakingChanges, []string{"Removed the -lock flag"}) {
+		t.Errorf("breaking changes = %q", r.BreakingChanges)
+	}
+	if r.Deprecations != nil {
+		t.Errorf("deprecations = %q, want none", r.Deprecations)
+	}
+}
+
+func TestNormalize_VersionPrefixes(t *testing.T) {
+	tests := map[string]string{
+		"1.22.0":           "1.22.0",
+		"go1.22.0":         "1.22.0",
+		"Terraform v1.9.0": "1.9.0",
+		"v2.0.0-rc.1":      "2.0.0-rc.1",
+		"Node.js 22.3":     "22.3.0",
+	}
+	for in, want := range tests {
+		r, err := Normalize(Raw{Product: "Product", Version: in}, nil)
+		if err != nil {
+			t.Errorf("Normalize(%q) error = %v", in, err)
+			continue
+		}
+		if got := r.Version.String(); got != want {
+			t.Errorf("Normalize(%q) version = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	if _, err := Normalize(Raw{Version: "1.0.0"}, nil); !errors.Is(err, ErrMissingProduct) {
+		t.Errorf("missing product: error = %v, want ErrMissingProduct", err)
+	}
+	for _, v := range []string{"", "latest", "1.2.3.4"} {
+		if _, err := Normalize(Raw{Product: "Go", Version: v}, nil); !errors.Is(err, semver.ErrInvalid) {
+			t.Errorf("version %q: error = %v, want semver.ErrInvalid", v, err)
+		}
+	}
+}
+
+func TestNormalize_ReleaseDateFallback(t *testing.T) {
+	published := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	for _, date := range []string{"", "June 26th"} {
+		r, err := Normalize(Raw{Product: "Go", Version: "1.22.5", ReleaseDate: date}, &published)
+		if err != nil {
+			t.Fatalf("Normalize() error = %v", err)
+		}
+		if r.ReleaseDate == nil || !r.ReleaseDate.Equal(published) {
+			t.Errorf("release date %q: got %v, want published date", date, r.ReleaseDate)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	f := Filter(Query{Product: "Terraform", Since: semver.Version{Major: 1, Minor: 8}, BreakingOnly: true})
+
+	if f["release.product_key"] != "terraform" {
+		t.Errorf("product key = %v, want terraform", f["release.product_key"])
+	}
+	if _, ok := f["release.breaking_changes.0"]; !ok {
+		t.Error("breaking only filter should require breaking changes")
+	}
+	if _, ok := f["release.version.prerelease"]; !ok {
+		t.Error("filter should exclude prereleases by default")
+	}
+	or, ok := f["$or"].(bson.A)
+	if !ok || len(or) != 3 {
+		t.Fatalf("version filter = %v, want three alternatives", f["$or"])
+	}
+
+	f = Filter(Query{Product: "Terraform", IncludePrereleases: true})
+	if _, ok := f["release.version.prerelease"]; ok {
+		t.Error("filter should include prereleases when asked")
+	}
+	if _, ok := f["release.breaking_changes.0"]; ok {
+		t.Error("filter should not require breaking changes by default")
+	}
+}
@@ -0,0 +1,89 @@
+package release
+
+import (
+	"context"
+	"slices"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Query selects the releases of a product
+type Query struct {
+	// Product is the name of the product, matched by its normalized key
+	Product string
+	// Since is the lowest version returned, inclusive
+	Since semver.Version
+	// BreakingOnly only returns releases with breaking changes
+	BreakingOnly bool
+	// IncludePrereleases also returns prereleases
+	IncludePrereleases bool
+}
+
+// Store queries the releases stored on feed items
+type Store struct {
+	feedItems *mongo.Collection
+}
+
+// NewStore creates a release store backed by the feed items collection.
+func NewStore(feedItems *mongo.Collection) *Store {
+	return &Store{feedItems: feedItems}
+}
+
+// EnsureIndexes creates the index on product key and version used by Find.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.feedItems.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "release.product_key", Value: 1},
+			{Key: "release.version.major", Value: 1},
+			{Key: "release.version.minor", Value: 1},
+			{Key: "release.version.patch", Value: 1},
+		},
+	})
+	return err
+}
+
+// Find returns the feed items with a release matching q, ordered by version.
+func (s *Store) Find(ctx context.Context, q Query) ([]internal.FeedItemDocument, error) {
+	cursor, err := s.feedItems.Find(ctx, Filter(q))
+	if err != nil {
+		return nil, err
+	}
+	var docs []internal.FeedItemDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	// The filter cannot express prerelease precedence, so versions are compared exactly here
+	docs = slices.DeleteFunc(docs, func(doc internal.FeedItemDocument) bool {
+		return semver.Compare(doc.Release.Version, q.Since) < 0
+	})
+	slices.SortStableFunc(docs, func(a, b internal.FeedItemDocument) int {
+		return semver.Compare(a.Release.Version, b.Release.Version)
+	})
+	return docs, nil
+}
+
+// Filter builds the MongoDB filter for q. Versions are matched on their major, minor and patch
+// components, so prereleases of Since itself are included and must be removed by the caller.
+func Filter(q Query) bson.M {
+	v := q.Since
+	filter := bson.M{
+		"release.product_key": entity.Key(q.Product),
+		"$or": bson.A{
+			bson.M{"release.version.major": bson.M{"$gt": v.Major}},
+			bson.M{"release.version.major": v.Major, "release.version.minor": bson.M{"$gt": v.Minor}},
+			bson.M{"release.version.major": v.Major, "release.version.minor": v.Minor, "release.version.patch": bson.M{"$gte": v.Patch}},
+		},
+	}
+	if q.BreakingOnly {
+		filter["release.breaking_changes.0"] = bson.M{"$exists": true}
+	}
+	if !q.IncludePrereleases {
+		filter["release.version.prerelease"] = bson.M{"$exists": false}
+	}
+	return filter
+}
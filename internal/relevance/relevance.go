@@ -0,0 +1,182 @@
+// Package relevance scores how relevant processed feed items are to the team, from interest
+// profiles with weighted categories, keywords and source feeds, optionally combined with an
+// LLM judgement of the item against the written interest statement of each profile.
+package relevance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+)
+
+// ErrInvalidProfile is returned for profiles that cannot be used
+var ErrInvalidProfile = errors.New("invalid interest profile")
+
+// Profile is the interest profile of a team. Weights range from -1 to 1: positive weights make
+// matching items more relevant, negative weights less, e.g. for a noisy source.
+type Profile struct {
+	Name string `json:"name"`
+	// Statement describes the interests of the team in prose, for the LLM to judge items against
+	Statement  string             `json:"statement,omitempty"`
+	Categories map[string]float64 `json:"categories,omitempty"`
+	Keywords   map[string]float64 `json:"keywords,omitempty"`
+	Feeds      map[string]float64 `json:"feeds,omitempty"`
+}
+
+// Validate reports whether p has a name and weights between -1 and 1.
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProfile)
+	}
+	for field, weights := range map[string]map[string]float64{"categories": p.Categories, "keywords": p.Keywords, "feeds": p.Feeds} {
+		for key, w := range weights {
+			if w < -1 || w > 1 {
+				return fmt.Errorf("%w: %s: weight of %s %q must be between -1 and 1, got %g", ErrInvalidProfile, p.Name, field, key, w)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadProfiles reads and validates the interest profiles from a JSON file.
+func LoadProfiles(path string) ([]Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []Profile
+	if err := json.Unmarshal(b, &profiles); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %q", ErrInvalidProfile, p.Name)
+		}
+		seen[p.Name] = true
+	}
+	return profiles, nil
+}
+
+// RuleScore scores doc between 0 and 1 from the weights of the categories, keywords and feed it
+// matches. Positive weights add up like independent signals, so two weights of 0.5 give 0.75, and
+// negative weights scale the result down, so a weight of -0.5 halves it.
+func (p Profile) RuleScore(doc internal.FeedItemDocument) float64 {
+	var weights []float64
+	for category, w := range p.Categories {
+		if slices.ContainsFunc(doc.Categories, func(c string) bool { return strings.EqualFold(c, category) }) {
+			weights = append(weights, w)
+		}
+	}
+	words := " " + strings.Join(splitWords(doc.Title+" "+doc.Summary), " ") + " "
+	for keyword, w := range p.Keywords {
+		phrase := strings.Join(splitWords(keyword), " ")
+		if phrase == "" {
+			continue
+		}
+		if slices.Contains(doc.Keywords, strings.ToLower(keyword)) || strings.Contains(words, " "+phrase+" ") {
+			weights = append(weights, w)
+		}
+	}
+	for feed, w := range p.Feeds {
+		if strings.EqualFold(doc.Feed, feed) {
+			weights = append(weights, w)
+		}
+	}
+	return combine(weights)
+}
+
+// combine combines weights into a score between 0 and 1.
+func combine(weights []float64) float64 {
+	miss, scale := 1.0, 1.0
+	for _, w := range weights {
+		if w >= 0 {
+			miss *= 1 - w
+		} else {
+			scale *= 1 + w
+		}
+	}
+	return (1 - miss) * scale
+}
+
+// splitWords returns the lowercase words of s.
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '-' && r != '.'
+	})
+}
+
+// Judgement is the LLM-judged relevance of an item to the statement of a profile
+type Judgement struct {
+	// Score ranges from 0 to 1
+	Score  float64
+	Reason string
+}
+
+// Score scores doc for every profile and returns the relevance of its best profile. The rule score
+// of a profile with a judgement is blended with the judged score, which gets llmWeight.
+func Score(profiles []Profile, doc internal.FeedItemDocument, judgements map[string]Judgement, llmWeight float64) internal.Relevance {
+	var r internal.Relevance
+	for _, p := range profiles {
+		ps := internal.ProfileRelevance{Profile: p.Name, RuleScore: round(p.RuleScore(doc))}
+		ps.Score = ps.RuleScore
+		if j, ok := judgements[p.Name]; ok {
+			llmScore := round(j.Score)
+			ps.LLMScore = &llmScore
+			ps.Reason = j.Reason
+			ps.Score = round((1-llmWeight)*ps.RuleScore + llmWeight*llmScore)
+		}
+		r.Profiles = append(r.Profiles, ps)
+		if r.Profile == "" || ps.Score > r.Score {
+			r.Score = ps.Score
+			r.Profile = p.Name
+		}
+	}
+	return r
+}
+
+// round rounds a score to three decimals, which keeps stored scores readable.
+func round(score float64) float64 {
+	return float64(int64(score*1000+0.5)) / 1000
+}
+
+// rawJudgement is a judgement as returned by the LLM, scored from 0 to 10
+type rawJudgement struct {
+	Profile string  `json:"profile"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason"`
+}
+
+// ParseJudgements decodes the LLM response judging an item against the statements of profiles.
+// Judgements of unknown profiles are ignored and scores are clamped to the range 0 to 10.
+func ParseJudgements(content string, profiles []Profile) (map[string]Judgement, error) {
+	var resp struct {
+		Profiles []rawJudgement `json:"profiles"`
+	}
+	if err := json.Unmarshal([]byte(content), &resp); err != nil {
+		return nil, fmt.Errorf("response is not a valid JSON object: %w", err)
+	}
+
+	judgements := map[string]Judgement{}
+	for _, raw := range resp.Profiles {
+		if !slices.ContainsFunc(profiles, func(p Profile) bool { return p.Name == raw.Profile }) {
+			continue
+		}
+		judgements[raw.Profile] = Judgement{Score: min(max(raw.Score, 0), 10) / 10, Reason: strings.TrimSpace(raw.Reason)}
+	}
+	if len(judgements) == 0 {
+		return nil, errors.New("response does not judge any of the profiles")
+	}
+	return judgements, nil
+}
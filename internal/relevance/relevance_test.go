@@ -0,0 +1,136 @@
+package relevance
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+)
+
+var testDoc = internal.FeedItemDocument{
+	Title:      "Kubernetes 1.31 deprecates in-tree cloud providers",
+	Summary:    "The release removes the last in-tree providers and graduates sidecar containers.",
+	Feed:       "Kubernetes Blog",
+	Categories: []string{"DevOps", "Cloud"},
+	Keywords:   []string{"sidecar containers"},
+}
+
+func TestProfile_RuleScore(t *testing.T) {
+	cases := []struct {
+		name    string
+		profile Profile
+		want    float64
+	}{
+		{"no match", Profile{Categories: map[string]float64{"Security": 1}}, 0},
+		{"category", Profile{Categories: map[string]float64{"devops": 0.6}}, 0.6},
+		{"independent signals", Profile{Categories: map[string]float64{"DevOps": 0.5}, Keywords: map[string]float64{"kubernetes": 0.5}}, 0.75},
+		{"stored keyword", Profile{Keywords: map[string]float64{"Sidecar Containers": 0.4}}, 0.4},
+		{"phrase in summary", Profile{Keywords: map[string]float64{"in-tree providers": 0.3}}, 0.3},
+		{"partial word", Profile{Keywords: map[string]float64{"kube": 0.9}}, 0},
+		{"negative feed", Profile{Categories: map[string]float64{"Cloud": 0.8}, Feeds: map[string]float64{"kubernetes blog": -0.5}}, 0.4},
+		{"certain", Profile{Categories: map[string]float64{"Cloud": 1, "DevOps": 0.2}}, 1},
+	}
+	for _, c := range cases {
+		if got := c.profile.RuleScore(testDoc); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("%s: RuleScore() = %g, want %g", c.name, got, c.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	profiles := []Profile{
+		{Name: "security", Categories: map[string]float64{"Security": 1}},
+		{Name: "platform", Statement: "We run Kubernetes on AWS", Categories: map[string]float64{"DevOps": 0.6}},
+		{Name: "frontend", Statement: "We build React apps"},
+	}
+	judgements := map[string]Judgement{
+		"platform": {Score: 0.9, Reason: "Affects our clusters"},
+		"frontend": {Score: 0.1},
+	}
+
+	r := Score(profiles, testDoc, judgements, 0.5)
+	if r.Profile != "platform" || r.Score != 0.75 {
+		t.Errorf("expected platform with 0.75, got %s with %g", r.Profile, r.Score)
+	}
+	if len(r.Profiles) != 3 {
+		t.Fatalf("expected the scores of every profile, got %+v", r.Profiles)
+	}
+	platform := r.Profiles[1]
+	if platform.RuleScore != 0.6 || platform.LLMScore == nil || *platform.LLMScore != 0.9 || platform.Reason != "Affects our clusters" {
+		t.Errorf("unexpected platform score %+v", platform)
+	}
+	if r.Profiles[0].LLMScore != nil || r.Profiles[0].Score != 0 {
+		t.Errorf("expected an unjudged security score of 0, got %+v", r.Profiles[0])
+	}
+	if r.Profiles[2].Score != 0.05 {
+		t.Errorf("expected the frontend score to blend rules and judgement, got %g", r.Profiles[2].Score)
+	}
+
+	if r := Score(profiles, testDoc, nil, 0.5); r.Profile != "platform" || r.Score != 0.6 {
+		t.Errorf("expected rule scores without judgements, got %s with %g", r.Profile, r.Score)
+	}
+	if r := Score(nil, testDoc, nil, 0.5); r.Profile != "" || r.Score != 0 {
+		t.Errorf("expected no relevance without profiles, got %+v", r)
+	}
+}
+
+func TestParseJudgements(t *testing.T) {
+	profiles := []Profile{{Name: "platform"}, {Name: "frontend"}}
+	content := `{"profiles": [
+		{"profile": "platform", "score": 8, "reason": " Affects our clusters "},
+		{"profile": "frontend", "score": 14},
+		{"profile": "unknown", "score": 5}
+	]}`
+
+	judgements, err := ParseJudgements(content, profiles)
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if len(judgements) != 2 {
+		t.Errorf("expected judgements of the known profiles, got %v", judgements)
+	}
+	if j := judgements["platform"]; j.Score != 0.8 || j.Reason != "Affects our clusters" {
+		t.Errorf("unexpected platform judgement %+v", j)
+	}
+	if j := judgements["frontend"]; j.Score != 1 {
+		t.Errorf("expected the frontend score to be clamped, got %+v", j)
+	}
+
+	for _, content := range []string{"", "not json", `{"profiles": []}`, `{"profiles": [{"profile": "unknown", "score": 5}]}`} {
+		if _, err := ParseJudgements(content, profiles); err == nil {
+			t.Errorf("ParseJudgements(%q) expected an error", content)
+		}
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	profiles, err := LoadProfiles(write("valid.json", `[{"name": "platform", "statement": "Kubernetes", "categories": {"DevOps": 0.6}, "feeds": {"Noise": -1}}]`))
+	if err != nil {
+		t.Fatalf("failed to load profiles: %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].Categories["DevOps"] != 0.6 || profiles[0].Feeds["Noise"] != -1 {
+		t.Errorf("unexpected profiles %+v", profiles)
+	}
+
+	for name, content := range map[string]string{
+		"unnamed.json":   `[{"categories": {"DevOps": 0.6}}]`,
+		"weight.json":    `[{"name": "platform", "keywords": {"kubernetes": 2}}]`,
+		"duplicate.json": `[{"name": "platform"}, {"name": "platform"}]`,
+	} {
+		if _, err := LoadProfiles(write(name, content)); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("%s: expected ErrInvalidProfile, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package semver parses and compares semantic versions as they appear in release notes and
+// articles, e.g. "v1.8", "1.8.0" or "2.0.0-rc.1".
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid is returned for strings that are not a semantic version
+var ErrInvalid = errors.New("invalid semantic version")
+
+// Version is a parsed semantic version. Missing minor and patch components are zero.
+type Version struct {
+	Major      int    `bson:"major" json:"major"`
+	Minor      int    `bson:"minor" json:"minor"`
+	Patch      int    `bson:"patch" json:"patch"`
+	Prerelease string `bson:"prerelease,omitempty" json:"prerelease,omitempty"`
+}
+
+// Parse parses a version such as "1", "v1.8", "1.8.3" or "1.8.3-beta.1+build.5". Build metadata is
+// ignored, as it does not affect precedence.
+func Parse(s string) (Version, error) {
+	var v Version
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Prerelease = s[i+1:]
+		s = s[:i]
+		if v.Prerelease == "" {
+			return Version{}, fmt.Errorf("%w: empty prerelease", ErrInvalid)
+		}
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return Version{}, fmt.Errorf("%w: %q has more than three components", ErrInvalid, s)
+	}
+	components := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || (len(p) > 1 && p[0] == '0') {
+			return Version{}, fmt.Errorf("%w: component %q", ErrInvalid, p)
+		}
+		*components[i] = n
+	}
+	return v, nil
+}
+
+// String returns the canonical form of v, e.g. "1.8.0" or "2.0.0-rc.1".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// IsPrerelease reports whether v is a prerelease.
+func (v Version) IsPrerelease() bool {
+	return v.Prerelease != ""
+}
+
+// Compare returns -1, 0 or 1 when a is lower than, equal to or higher than b.
+func Compare(a, b Version) int {
+	for _, d := range [][2]int{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if d[0] != d[1] {
+			if d[0] < d[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+// comparePrerelease compares prerelease identifiers; a release has higher precedence than any prerelease.
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case aErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
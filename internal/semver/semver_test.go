@@ -0,0 +1,55 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"1":                   "1.0.0",
+		"v1.8":                "1.8.0",
+		"1.8.3":               "1.8.3",
+		"V2.0.0-rc.1":         "2.0.0-rc.1",
+		"1.8.3-beta.1+build5": "1.8.3-beta.1",
+		" 10.20.30 ":          "10.20.30",
+	}
+	for in, want := range cases {
+		v, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", in, err)
+			continue
+		}
+		if v.String() != want {
+			t.Errorf("Parse(%q) = %s, want %s", in, v, want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{"", "latest", "1.2.3.4", "1.x", "01.2.3", "1.2.3-", "-1.0.0"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) expected ErrInvalid, got %v", in, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered from lowest to highest precedence, following the semver specification example
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.8", "1.10.0", "2.0.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		a, _ := Parse(ordered[i])
+		b, _ := Parse(ordered[i+1])
+		if Compare(a, b) != -1 || Compare(b, a) != 1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+	a, _ := Parse("v1.8")
+	b, _ := Parse("1.8.0")
+	if Compare(a, b) != 0 {
+		t.Error("expected v1.8 and 1.8.0 to be equal")
+	}
+}
@@ -0,0 +1,52 @@
+package simhash
+
+import (
+	"strings"
+	"testing"
+)
+
+const article = `Go 1.23 is released. The release adds range-over-func iterators, a new unique package for
+interning values, and timer changes that make unstopped timers eligible for garbage collection.
+Toolchain telemetry is now opt-in, and go vet reports more misuses of the standard library.
+Upgrading is recommended for all users, and binary distributions are available on the download page.`
+
+func TestCompute_Identical(t *testing.T) {
+	if Compute(article) != Compute(article) {
+		t.Error("expected identical texts to have identical fingerprints")
+	}
+}
+
+func TestCompute_IgnoresCaseAndPunctuation(t *testing.T) {
+	noisy := strings.ToUpper(strings.NewReplacer(",", "", ".", " !").Replace(article))
+	if d := Distance(Compute(article), Compute(noisy)); d != 0 {
+		t.Errorf("expected case and punctuation to be ignored, got distance %d", d)
+	}
+}
+
+func TestCompute_NearDuplicate(t *testing.T) {
+	syndicated := "Golang Weekly: " + article + " Subscribe for more."
+	if d := Distance(Compute(article), Compute(syndicated)); d > 6 {
+		t.Errorf("expected near-duplicates to be close, got distance %d", d)
+	}
+}
+
+func TestCompute_Different(t *testing.T) {
+	other := `Terraform 1.9 introduces input variable validation that can refer to other variables,
+a new templatestring function and improvements to the plan output for large configurations.
+Providers must be upgraded separately, and the release drops support for several old platforms.`
+	if d := Distance(Compute(article), Compute(other)); d < 10 {
+		t.Errorf("expected unrelated texts to be far apart, got distance %d", d)
+	}
+}
+
+func TestCompute_Empty(t *testing.T) {
+	if Compute("  ... ") != 0 {
+		t.Error("expected text without words to have fingerprint 0")
+	}
+}
+
+func TestDistance(t *testing.T) {
+	if d := Distance(0b1011, 0b0001); d != 2 {
+		t.Errorf("expected distance 2, got %d", d)
+	}
+}
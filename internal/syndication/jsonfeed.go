@@ -0,0 +1,77 @@
+package syndication
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished *time.Time       `json:"date_published,omitempty"`
+	DateModified  *time.Time       `json:"date_modified,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+// WriteJSONFeed renders f as a JSON Feed 1.1 document.
+func WriteJSONFeed(w io.Writer, f Feed) error {
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       make([]jsonFeedItem, 0, len(f.Entries)),
+	}
+
+	for _, e := range f.Entries {
+		item := jsonFeedItem{
+			ID:            e.ID,
+			URL:           e.Link,
+			Title:         e.Title,
+			ContentText:   e.Summary,
+			Summary:       e.Summary,
+			DatePublished: jsonFeedTime(e.Published),
+			DateModified:  jsonFeedTime(e.Updated),
+			Tags:          e.Categories,
+		}
+		if e.Source != "" {
+			item.Authors = []jsonFeedAuthor{{Name: e.Source, URL: e.SourceURL}}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(feed)
+}
+
+// jsonFeedTime returns a pointer to t in UTC, or nil for the zero time so it is omitted.
+func jsonFeedTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
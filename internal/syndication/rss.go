@@ -0,0 +1,94 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomXMLNS string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	SelfLink      *rssLink  `xml:"atom:link,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssSource struct {
+	URL   string `xml:"url,attr"`
+	Title string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Description string     `xml:"description,omitempty"`
+	GUID        rssGUID    `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+	Categories  []string   `xml:"category"`
+	Source      *rssSource `xml:"source,omitempty"`
+}
+
+// WriteRSS renders f as an RSS 2.0 document.
+func WriteRSS(w io.Writer, f Feed) error {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.HomeURL,
+		Description:   f.Description,
+		LastBuildDate: rssTime(f.Updated),
+	}
+	if channel.Link == "" {
+		channel.Link = f.FeedURL
+	}
+	if channel.Description == "" {
+		channel.Description = f.Title
+	}
+	if f.FeedURL != "" {
+		channel.SelfLink = &rssLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, e := range f.Entries {
+		item := rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Summary,
+			GUID:        rssGUID{Value: e.ID},
+			PubDate:     rssTime(e.Published),
+			Categories:  e.Categories,
+		}
+		// RSS requires a URL for the source element
+		if e.Source != "" && e.SourceURL != "" {
+			item.Source = &rssSource{URL: e.SourceURL, Title: e.Source}
+		}
+		channel.Items = append(channel.Items, item)
+	}
+
+	return writeXML(w, rssDocument{Version: "2.0", AtomXMLNS: atomNamespace, Channel: channel})
+}
+
+// rssTime formats t as an RFC 822 date, or an empty string for the zero time.
+func rssTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC1123Z)
+}
@@ -0,0 +1,77 @@
+// Package syndication renders feed items as Atom, RSS 2.0 and JSON Feed 1.1 documents, so the
+// aggregated and summarized items can be read in any feed reader.
+package syndication
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Supported output formats
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+	FormatJSON = "json"
+)
+
+// Formats are the supported output formats
+var Formats = []string{FormatAtom, FormatRSS, FormatJSON}
+
+// Feed is an output feed independent of its format
+type Feed struct {
+	// ID uniquely and permanently identifies the feed
+	ID    string
+	Title string
+	// Description describes the selection of items in the feed
+	Description string
+	// HomeURL is the web page the feed belongs to
+	HomeURL string
+	// FeedURL is the URL the feed itself is served from
+	FeedURL string
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry is a single item of an output feed
+type Entry struct {
+	// ID uniquely and permanently identifies the entry
+	ID    string
+	Title string
+	// Link is the URL of the original article
+	Link    string
+	Summary string
+	// Categories become Atom and RSS categories and JSON Feed tags
+	Categories []string
+	// Source is the title of the feed the item was published in
+	Source    string
+	SourceURL string
+	Published time.Time
+	Updated   time.Time
+}
+
+// ContentType returns the media type of format.
+func ContentType(format string) string {
+	switch format {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return ""
+}
+
+// Write renders f in format to w.
+func Write(w io.Writer, format string, f Feed) error {
+	switch format {
+	case FormatAtom:
+		return WriteAtom(w, f)
+	case FormatRSS:
+		return WriteRSS(w, f)
+	case FormatJSON:
+		return WriteJSONFeed(w, f)
+	}
+	return fmt.Errorf("unsupported feed format %q", format)
+}
@@ -0,0 +1,121 @@
+package syndication
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+var testFeed = Feed{
+	ID:          "https://feeds.example.com/syndication/atom/category/Security",
+	Title:       "Feeds Aggregator: Security",
+	Description: "Summarized articles in the Security category",
+	HomeURL:     "https://feeds.example.com/",
+	FeedURL:     "https://feeds.example.com/syndication/atom/category/Security",
+	Updated:     time.Date(2024, 6, 26, 12, 0, 0, 0, time.UTC),
+	Entries: []Entry{
+		{
+			ID:         "https://feeds.example.com/items/6679a3c2e4b0a1b2c3d4e5f6",
+			Title:      "Critical <vulnerability> in OpenSSH & friends",
+			Link:       "https://example.com/openssh",
+			Summary:    "A race condition in OpenSSH allows remote code execution.",
+			Categories: []string{"Security", "DevOps & Infrastructure"},
+			Source:     "Example blog",
+			SourceURL:  "https://example.com/feed.xml",
+			Published:  time.Date(2024, 6, 25, 8, 0, 0, 0, time.UTC),
+			Updated:    time.Date(2024, 6, 25, 9, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+// parse renders testFeed in format and parses it back with a feed reader library.
+func parse(t *testing.T, format string) *gofeed.Feed {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Write(&buf, format, testFeed); err != nil {
+		t.Fatalf("Write(%s) error = %v", format, err)
+	}
+	parsed, err := gofeed.NewParser().ParseString(buf.String())
+	if err != nil {
+		t.Fatalf("failed to parse %s feed: %v\n%s", format, err, buf.String())
+	}
+	return parsed
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	wantTypes := map[string]string{FormatAtom: "atom", FormatRSS: "rss", FormatJSON: "json"}
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			f := parse(t, format)
+
+			if f.FeedType != wantTypes[format] {
+				t.Errorf("feed type = %q, want %q", f.FeedType, wantTypes[format])
+			}
+			if f.Title != testFeed.Title {
+				t.Errorf("title = %q, want %q", f.Title, testFeed.Title)
+			}
+			if len(f.Items) != 1 {
+				t.Fatalf("got %d items, want 1", len(f.Items))
+			}
+
+			item, want := f.Items[0], testFeed.Entries[0]
+			if item.Title != want.Title {
+				t.Errorf("item title = %q, want %q", item.Title, want.Title)
+			}
+			if item.Link != want.Link {
+				t.Errorf("item link = %q, want %q", item.Link, want.Link)
+			}
+			if item.GUID != want.ID {
+				t.Errorf("item ID = %q, want %q", item.GUID, want.ID)
+			}
+			if !strings.Contains(item.Description+item.Content, want.Summary) {
+				t.Errorf("item should carry the summary, got description %q and content %q", item.Description, item.Content)
+			}
+			if !slices.Equal(item.Categories, want.Categories) {
+				t.Errorf("item categories = %q, want %q", item.Categories, want.Categories)
+			}
+			if item.PublishedParsed == nil || !item.PublishedParsed.Equal(want.Published) {
+				t.Errorf("item published = %v, want %v", item.PublishedParsed, want.Published)
+			}
+		})
+	}
+}
+
+func TestWrite_EmptyFeed(t *testing.T) {
+	empty := Feed{ID: "urn:empty", Title: "Empty", Updated: time.Now()}
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		if err := Write(&buf, format, empty); err != nil {
+			t.Fatalf("Write(%s) error = %v", format, err)
+		}
+		if _, err := gofeed.NewParser().ParseString(buf.String()); err != nil {
+			t.Errorf("empty %s feed does not parse: %v\n%s", format, err, buf.String())
+		}
+	}
+}
+
+func TestWriteJSONFeed_Version(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONFeed(&buf, testFeed); err != nil {
+		t.Fatalf("WriteJSONFeed() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"version": "https://jsonfeed.org/version/1.1"`) {
+		t.Errorf("JSON feed should declare version 1.1, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"items": [`) {
+		t.Errorf("JSON feed should always have an items array, got %s", buf.String())
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "opml", testFeed); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if ContentType("opml") != "" {
+		t.Error("unsupported format should have no content type")
+	}
+}
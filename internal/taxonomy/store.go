@@ -0,0 +1,323 @@
+package taxonomy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store persists the taxonomy in MongoDB and rewrites feed items, item states and read markers when
+// categories are merged
+type Store struct {
+	categories  *mongo.Collection
+	feedItems   *mongo.Collection
+	itemStates  *mongo.Collection
+	readMarkers *mongo.Collection
+}
+
+// MergeResult reports what a merge changed
+type MergeResult struct {
+	// Categories is the number of categories merged into the target
+	Categories int
+	// Items is the number of feed items whose categories were rewritten
+	Items int
+	// States is the number of item states whose copies of the categories were rewritten
+	States int
+	// Markers is the number of category read markers moved to the target
+	Markers int
+}
+
+// NewStore creates a taxonomy store backed by the categories collection. The feed item, item state
+// and read marker collections are rewritten when categories are merged.
+func NewStore(categories, feedItems, itemStates, readMarkers *mongo.Collection) *Store {
+	return &Store{categories: categories, feedItems: feedItems, itemStates: itemStates, readMarkers: readMarkers}
+}
+
+// EnsureIndexes creates the unique index on category keys and the index on alias keys.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.categories.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "alias_keys", Value: 1}}},
+	})
+	return err
+}
+
+// Seed inserts categories when the taxonomy is empty. It reports whether anything was inserted.
+func (s *Store) Seed(ctx context.Context, categories []Category) (bool, error) {
+	n, err := s.categories.EstimatedDocumentCount(ctx)
+	if err != nil || n > 0 {
+		return false, err
+	}
+	for _, c := range categories {
+		if err := s.Add(ctx, c); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Add inserts a new category.
+func (s *Store) Add(ctx context.Context, c Category) error {
+	c, err := newCategory(c)
+	if err != nil {
+		return err
+	}
+	if c.Parent != "" {
+		t, err := s.Load(ctx)
+		if err != nil {
+			return err
+		}
+		parent, ok := t.Canonical(c.Parent)
+		if !ok {
+			return fmt.Errorf("parent category %q does not exist", c.Parent)
+		}
+		c.Parent = parent
+	}
+	_, err = s.categories.InsertOne(ctx, c)
+	return err
+}
+
+// List returns all categories sorted by name.
+func (s *Store) List(ctx context.Context) ([]Category, error) {
+	cursor, err := s.categories.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var categories []Category
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// Load returns a snapshot of the taxonomy for normalizing categories.
+func (s *Store) Load(ctx context.Context) (*Taxonomy, error) {
+	categories, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return New(categories), nil
+}
+
+// Counts returns the number of feed items per stored category string, counting the items of
+// feedURLs only unless it is nil.
+func (s *Store) Counts(ctx context.Context, feedURLs []string) (map[string]int64, error) {
+	match := bson.M{}
+	if feedURLs != nil {
+		match["feed_url"] = bson.M{"$in": feedURLs}
+	}
+	cursor, err := s.feedItems.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$categories"}, {Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Name  string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Name] = r.Count
+	}
+	return counts, nil
+}
+
+// Merge folds the from categories into into, creating into when it does not exist yet. The names
+// and aliases of merged categories become aliases of into, their children are moved under into,
+// and every feed item and item state carrying one of them, in any spelling, is rewritten to into, as
+// are the category read markers of users.
+func (s *Store) Merge(ctx context.Context, from []string, into string) (MergeResult, error) {
+	var result MergeResult
+
+	t, err := s.Load(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	target, ok := t.Canonical(into)
+	if !ok {
+		c, err := newCategory(Category{Name: into})
+		if err != nil {
+			return result, err
+		}
+		if err := s.Add(ctx, c); err != nil {
+			return result, err
+		}
+		// The stored name is trimmed, and it is the ID the target is updated by
+		target = c.Name
+	}
+
+	// Collect every spelling that should now resolve to the target
+	aliases := []string{}
+	merged := []string{}
+	for _, name := range from {
+		canonical, known := t.Canonical(name)
+		if known && canonical == target {
+			continue
+		}
+		aliases = append(aliases, name)
+		if !known {
+			continue
+		}
+		merged = append(merged, canonical)
+		aliases = append(aliases, canonical)
+		for _, c := range t.Categories() {
+			if c.Name == canonical {
+				aliases = append(aliases, c.Aliases...)
+			}
+		}
+	}
+	if len(aliases) == 0 {
+		return result, nil
+	}
+
+	aliasKeys := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		aliasKeys = append(aliasKeys, Key(alias))
+	}
+
+	// The merged categories are deleted last: until then their names take precedence over the new
+	// aliases of the target, so a merge that failed part-way is completed by running it again
+	_, err = s.categories.UpdateByID(ctx, target, bson.M{"$addToSet": bson.M{
+		"aliases":    bson.M{"$each": aliases},
+		"alias_keys": bson.M{"$each": aliasKeys},
+	}})
+	if err != nil {
+		return result, err
+	}
+	if len(merged) > 0 {
+		if _, err := s.categories.UpdateMany(ctx, bson.M{"parent": bson.M{"$in": merged}}, bson.M{"$set": bson.M{"parent": target}}); err != nil {
+			return result, err
+		}
+	}
+
+	result.Items, err = rewriteCategories(ctx, s.feedItems, aliases, target)
+	if err != nil {
+		return result, err
+	}
+	result.States, err = rewriteCategories(ctx, s.itemStates, aliases, target)
+	if err != nil {
+		return result, err
+	}
+	result.Markers, err = s.moveMarkers(ctx, aliases, target)
+	if err != nil {
+		return result, err
+	}
+
+	if len(merged) > 0 {
+		if _, err := s.categories.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": merged}}); err != nil {
+			return result, err
+		}
+	}
+	result.Categories = len(merged)
+	return result, nil
+}
+
+// rewriteCategories replaces the from categories, in any spelling, with into on all documents of
+// coll, feed items or item states, and returns the number of documents rewritten.
+func rewriteCategories(ctx context.Context, coll *mongo.Collection, from []string, into string) (int, error) {
+	stored, err := storedSpellings(ctx, coll, "categories", bson.M{}, from)
+	if err != nil || len(stored) == 0 {
+		return 0, err
+	}
+
+	cursor, err := coll.Find(ctx, bson.M{"categories": bson.M{"$in": stored}},
+		options.Find().SetProjection(bson.M{"categories": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	rewritten := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID         primitive.ObjectID `bson:"_id"`
+			Categories []string           `bson:"categories"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return rewritten, err
+		}
+		categories, changed := ReplaceCategories(doc.Categories, from, into)
+		if !changed {
+			continue
+		}
+		if _, err := coll.UpdateByID(ctx, doc.ID, bson.M{"$set": bson.M{"categories": categories}}); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, cursor.Err()
+}
+
+// moveMarkers moves the category read markers of the from categories, in any spelling, to into and
+// returns the number of markers moved. A user who already has a marker on into keeps the earlier of
+// the two, so no item becomes read that was not read before the merge.
+func (s *Store) moveMarkers(ctx context.Context, from []string, into string) (int, error) {
+	// Read markers scoped to a category, see itemstate.ScopeCategory
+	categoryScope := bson.M{"scope": "category"}
+	stored, err := storedSpellings(ctx, s.readMarkers, "value", categoryScope, from)
+	if err != nil {
+		return 0, err
+	}
+	stored = slices.DeleteFunc(stored, func(name string) bool { return name == into })
+	if len(stored) == 0 {
+		return 0, nil
+	}
+
+	inScope := bson.M{"scope": "category", "value": bson.M{"$in": stored}}
+	cursor, err := s.readMarkers.Find(ctx, inScope)
+	if err != nil {
+		return 0, err
+	}
+	var markers []struct {
+		User   string    `bson:"user"`
+		Before time.Time `bson:"before"`
+	}
+	if err := cursor.All(ctx, &markers); err != nil {
+		return 0, err
+	}
+	for _, m := range markers {
+		_, err := s.readMarkers.UpdateOne(ctx, bson.M{"user": m.User, "scope": "category", "value": into},
+			bson.M{"$min": bson.M{"before": m.Before}}, options.Update().SetUpsert(true))
+		if err != nil {
+			return 0, err
+		}
+	}
+	if _, err := s.readMarkers.DeleteMany(ctx, inScope); err != nil {
+		return 0, err
+	}
+	return len(markers), nil
+}
+
+// storedSpellings returns the distinct values of field in the documents of coll matching filter
+// that are spellings of the from categories. Stored categories may be spelled differently from the merged names, so they are matched
+// on keys.
+func storedSpellings(ctx context.Context, coll *mongo.Collection, field string, filter bson.M, from []string) ([]string, error) {
+	distinct, err := coll.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+	var stored []string
+	for _, v := range distinct {
+		name, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(from, func(f string) bool { return Key(f) == Key(name) }) {
+			stored = append(stored, name)
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,151 @@
+// Package taxonomy manages the canonical set of categories assigned to feed items. Categories
+// returned by the LLM are normalized against canonical names and aliases, so spelling variants
+// such as "Dev Ops" and "DevOps" end up as the same category.
+package taxonomy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Category is a canonical category with its aliases and optional parent
+type Category struct {
+	Name        string   `bson:"_id" json:"name"`
+	Key         string   `bson:"key" json:"-"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Aliases     []string `bson:"aliases,omitempty" json:"aliases,omitempty"`
+	AliasKeys   []string `bson:"alias_keys,omitempty" json:"-"`
+	Parent      string   `bson:"parent,omitempty" json:"parent,omitempty"`
+}
+
+// Defaults are the categories seeded into an empty taxonomy; they were the exemplars of the original prompt
+var Defaults = []Category{
+	{Name: "Programming Languages", Description: "Language releases, comparisons, best practices, performance tips, ecosystem libraries. Examples: JavaScript, Rust, Go, Python"},
+	{Name: "Frameworks & Libraries", Description: "Framework introductions, major updates, ecosystem tools, architecture patterns"},
+	{Name: "Developer Tools", Description: "IDEs, CLI tools, build tools, debugging, testing, productivity"},
+	{Name: "AI & Machine Learning", Description: "AI tools, ML concepts, LLMs, prompt engineering, AI frameworks"},
+	{Name: "Software Engineering Practices", Description: "Architecture, code quality, testing, design patterns, refactoring"},
+	{Name: "DevOps & Infrastructure", Description: "CI/CD, containers, cloud, observability, IaC, scaling", Aliases: []string{"DevOps", "Infrastructure"}},
+	{Name: "Security", Description: "Secure coding, vulnerabilities, authentication, cryptography, security tools"},
+	{Name: "Industry & Trends", Description: "Tech trends, ecosystem shifts, market changes, startup stacks"},
+	{Name: "Opinion & Thought Pieces", Description: "Opinions, predictions, lessons learned, DX, career"},
+	{Name: "Tutorials & Guides", Description: "Beginner tutorials, step-by-step guides, walkthroughs, project builds"},
+}
+
+// Key returns the normalized lookup key of a category name: lowercase letters, digits, "+" and "#"
+// only, ignoring the word "and" so that "CI & CD", "CI and CD" and "ci/cd" share a key while "C",
+// "C++" and "C#" do not.
+func Key(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if w == "and" {
+			continue
+		}
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+// Taxonomy is an in-memory snapshot of the categories used to normalize LLM output
+type Taxonomy struct {
+	categories []Category
+	byKey      map[string]string
+}
+
+// New builds a taxonomy from categories, indexing their names and aliases.
+func New(categories []Category) *Taxonomy {
+	t := &Taxonomy{categories: categories, byKey: map[string]string{}}
+	for _, c := range categories {
+		for _, alias := range c.Aliases {
+			t.byKey[Key(alias)] = c.Name
+		}
+	}
+	// Canonical names take precedence over aliases of other categories
+	for _, c := range categories {
+		t.byKey[Key(c.Name)] = c.Name
+	}
+	return t
+}
+
+// Categories returns the categories of the taxonomy.
+func (t *Taxonomy) Categories() []Category {
+	return t.categories
+}
+
+// Canonical returns the canonical name for name, and whether it is known.
+func (t *Taxonomy) Canonical(name string) (string, bool) {
+	canonical, ok := t.byKey[Key(name)]
+	return canonical, ok
+}
+
+// Normalize maps names to their canonical categories, removing duplicates while keeping order.
+// Unknown names are kept as returned by the LLM and also reported separately; in strict mode
+// they are left out of the normalized result.
+func (t *Taxonomy) Normalize(names []string, strict bool) (normalized []string, unknown []string) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		canonical, ok := t.Canonical(name)
+		if !ok {
+			unknown = append(unknown, name)
+			if strict {
+				continue
+			}
+			canonical = name
+		}
+		if !slices.ContainsFunc(normalized, func(n string) bool { return Key(n) == Key(canonical) }) {
+			normalized = append(normalized, canonical)
+		}
+	}
+	return normalized, unknown
+}
+
+// ReplaceCategories replaces every category in categories whose key matches one of from with into,
+// removing duplicates while keeping order. It reports whether anything changed.
+func ReplaceCategories(categories []string, from []string, into string) ([]string, bool) {
+	fromKeys := make(map[string]bool, len(from))
+	for _, f := range from {
+		fromKeys[Key(f)] = true
+	}
+
+	changed := false
+	result := make([]string, 0, len(categories))
+	for _, c := range categories {
+		if fromKeys[Key(c)] {
+			c = into
+			changed = true
+		}
+		if slices.Contains(result, c) {
+			changed = true
+			continue
+		}
+		result = append(result, c)
+	}
+	return result, changed
+}
+
+// newCategory validates and fills in the derived fields of a category.
+func newCategory(c Category) (Category, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Key = Key(c.Name)
+	if c.Key == "" {
+		return Category{}, fmt.Errorf("category name %q has no letters or digits", c.Name)
+	}
+	aliases := c.Aliases
+	c.Aliases, c.AliasKeys = nil, nil
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if k := Key(alias); k != "" && k != c.Key && !slices.Contains(c.AliasKeys, k) {
+			c.Aliases = append(c.Aliases, alias)
+			c.AliasKeys = append(c.AliasKeys, k)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,97 @@
+package taxonomy
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	cases := map[string]string{
+		"DevOps":                  "devops",
+		"Dev Ops":                 "devops",
+		"dev-ops":                 "devops",
+		"CI & CD":                 "cicd",
+		"CI and CD":               "cicd",
+		"AI & Machine Learning":   "aimachinelearning",
+		"  Security  ":            "security",
+		"Frameworks & Libraries!": "frameworkslibraries",
+		"C":                       "c",
+		"C++":                     "c++",
+		"c ++":                    "c++",
+		"C#":                      "c#",
+		"F#":                      "f#",
+	}
+	for name, want := range cases {
+		if got := Key(name); got != want {
+			t.Errorf("Key(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	if len(Defaults) != 10 {
+		t.Fatalf("expected the ten original exemplars, got %d", len(Defaults))
+	}
+	for _, c := range Defaults {
+		if _, err := newCategory(c); err != nil {
+			t.Errorf("invalid default category %q: %v", c.Name, err)
+		}
+		if c.Description == "" {
+			t.Errorf("default category %q should have a description", c.Name)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tax := New(Defaults)
+
+	normalized, unknown := tax.Normalize([]string{"Dev Ops", "infrastructure", "security", "Rust Tips", "SECURITY"}, false)
+	want := []string{"DevOps & Infrastructure", "Security", "Rust Tips"}
+	if !slices.Equal(normalized, want) {
+		t.Errorf("expected %v, got %v", want, normalized)
+	}
+	if !slices.Equal(unknown, []string{"Rust Tips"}) {
+		t.Errorf("expected unknown [Rust Tips], got %v", unknown)
+	}
+}
+
+func TestNormalize_Strict(t *testing.T) {
+	tax := New(Defaults)
+
+	normalized, unknown := tax.Normalize([]string{"Rust Tips", "developer tools"}, true)
+	if !slices.Equal(normalized, []string{"Developer Tools"}) {
+		t.Errorf("expected unknown categories to be dropped, got %v", normalized)
+	}
+	if !slices.Equal(unknown, []string{"Rust Tips"}) {
+		t.Errorf("expected unknown [Rust Tips], got %v", unknown)
+	}
+}
+
+func TestNew_CanonicalNameWinsOverAlias(t *testing.T) {
+	tax := New([]Category{
+		{Name: "Platform", Aliases: []string{"Infrastructure"}},
+		{Name: "Infrastructure"},
+	})
+	if got, _ := tax.Canonical("infrastructure"); got != "Infrastructure" {
+		t.Errorf("expected canonical name to win over alias, got %q", got)
+	}
+}
+
+func TestReplaceCategories(t *testing.T) {
+	got, changed := ReplaceCategories([]string{"Go", "Dev Ops", "Infrastructure", "Security"}, []string{"DevOps", "Infrastructure"}, "DevOps & Infrastructure")
+	want := []string{"Go", "DevOps & Infrastructure", "Security"}
+	if !changed || !slices.Equal(got, want) {
+		t.Errorf("expected %v (changed), got %v (changed=%v)", want, got, changed)
+	}
+
+	got, changed = ReplaceCategories([]string{"Go"}, []string{"DevOps"}, "DevOps & Infrastructure")
+	if changed || !slices.Equal(got, []string{"Go"}) {
+		t.Errorf("expected no change, got %v (changed=%v)", got, changed)
+	}
+}
+
+func TestNewCategory_RejectsEmptyKey(t *testing.T) {
+	if _, err := newCategory(Category{Name: " & "}); err == nil {
+		t.Error("expected error for category without letters or digits")
+	}
+}
@@ -0,0 +1,193 @@
+// Package tokens provides token estimation and context-aware text chunking for LLM prompts.
+package tokens
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken is the rough number of characters per token for English text on common tokenizers.
+const charsPerToken = 4
+
+// latinEnd ends the Latin script blocks; runes from there on, such as CJK, Cyrillic or emoji, are
+// usually one token or more each rather than a fraction of one
+const latinEnd = 0x0250
+
+// cost returns the cost of r in units of a Latin character, charsPerToken of which make a token.
+func cost(r rune) int {
+	if r < latinEnd {
+		return 1
+	}
+	return charsPerToken
+}
+
+// Estimate returns a conservative estimate of the number of tokens in text: Latin characters count
+// as a quarter of a token and every other rune as a token.
+func Estimate(text string) int {
+	units := 0
+	for _, r := range text {
+		units += cost(r)
+	}
+	return (units + charsPerToken - 1) / charsPerToken
+}
+
+// prefixLen returns the length in bytes of the longest prefix of text estimated at no more than
+// maxTokens tokens.
+func prefixLen(text string, maxTokens int) int {
+	units := 0
+	for i, r := range text {
+		units += cost(r)
+		if units > maxTokens*charsPerToken {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// ContextSizes maps model names to their context window size in tokens.
+// It is parsed from environment values in the form "model=size,model=size".
+type ContextSizes map[string]int
+
+// UnmarshalEnvironmentValue implements env.Unmarshaler.
+func (c *ContextSizes) UnmarshalEnvironmentValue(data string) error {
+	sizes := ContextSizes{}
+	for _, pair := range strings.Split(data, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		// Split on the last "=" so model names may contain one
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return fmt.Errorf("invalid context size %q, expected model=size", pair)
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(pair[i+1:]))
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid context size %q, expected a positive integer", pair)
+		}
+		sizes[strings.TrimSpace(pair[:i])] = size
+	}
+	*c = sizes
+	return nil
+}
+
+// For returns the context size configured for model, or fallback if none is configured.
+func (c ContextSizes) For(model string, fallback int) int {
+	if size, ok := c[model]; ok {
+		return size
+	}
+	return fallback
+}
+
+// Split divides text into chunks of at most maxTokens estimated tokens.
+// Text is split on paragraph boundaries (blank lines) where possible; paragraphs that are too
+// large on their own are split on sentence boundaries, and as a last resort on rune boundaries.
+func Split(text string, maxTokens int) []string {
+	if maxTokens <= 0 {
+		maxTokens = 1
+	}
+
+	var chunks []string
+	var current []string
+	currentTokens := 0
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, strings.Join(current, "\n\n"))
+		}
+		current = nil
+		currentTokens = 0
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		for _, piece := range splitOversized(paragraph, maxTokens) {
+			t := Estimate(piece)
+			if currentTokens+t > maxTokens {
+				flush()
+			}
+			current = append(current, piece)
+			currentTokens += t
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitOversized breaks a single paragraph into pieces that each fit within maxTokens.
+func splitOversized(paragraph string, maxTokens int) []string {
+	if Estimate(paragraph) <= maxTokens {
+		return []string{paragraph}
+	}
+
+	var pieces []string
+	var current strings.Builder
+	for _, sentence := range splitSentences(paragraph) {
+		if current.Len() > 0 && Estimate(current.String()+" "+sentence) > maxTokens {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		if Estimate(sentence) > maxTokens {
+			pieces = append(pieces, splitTokens(sentence, maxTokens)...)
+			continue
+		}
+		if current.Len() > 0 {
+			current.WriteString(" ")
+		}
+		current.WriteString(sentence)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// splitSentences splits text after sentence-ending punctuation followed by whitespace.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text)-1; i++ {
+		switch text[i] {
+		case '.', '!', '?':
+			if text[i+1] == ' ' {
+				sentences = append(sentences, text[start:i+1])
+				start = i + 2
+			}
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+// splitTokens splits text on rune boundaries into pieces of at most maxTokens estimated tokens.
+func splitTokens(text string, maxTokens int) []string {
+	var pieces []string
+	for text != "" {
+		n := prefixLen(text, maxTokens)
+		if n == 0 {
+			// Take at least one rune so the split always progresses
+			_, n = utf8.DecodeRuneInString(text)
+		}
+		pieces = append(pieces, text[:n])
+		text = text[n:]
+	}
+	return pieces
+}
+
+// Truncate shortens text so that its estimated token count does not exceed maxTokens.
+func Truncate(text string, maxTokens int) string {
+	if Estimate(text) <= maxTokens {
+		return text
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+	return text[:prefixLen(text, maxTokens)]
+}
@@ -0,0 +1,115 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"café", 1},
+		{"日本語のテ", 5},
+		{"Go 1.25 のリリース", 7},
+	}
+	for _, tt := range tests {
+		if got := Estimate(tt.text); got != tt.expected {
+			t.Errorf("Estimate(%q) = %d, expected %d", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestContextSizes_Unmarshal(t *testing.T) {
+	var sizes ContextSizes
+	if err := sizes.UnmarshalEnvironmentValue("gpt-oss:20b=131072, qwen3.5:27b=32768"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sizes.For("gpt-oss:20b", 0) != 131072 {
+		t.Errorf("expected gpt-oss:20b context size 131072, got %d", sizes.For("gpt-oss:20b", 0))
+	}
+	if sizes.For("qwen3.5:27b", 0) != 32768 {
+		t.Errorf("expected qwen3.5:27b context size 32768, got %d", sizes.For("qwen3.5:27b", 0))
+	}
+	if sizes.For("unknown", 8192) != 8192 {
+		t.Errorf("expected fallback for unknown model")
+	}
+}
+
+func TestContextSizes_UnmarshalInvalid(t *testing.T) {
+	for _, value := range []string{"model", "model=abc", "model=-1", "=100"} {
+		var sizes ContextSizes
+		if err := sizes.UnmarshalEnvironmentValue(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestSplit_ParagraphBoundaries(t *testing.T) {
+	text := strings.Repeat("a", 40) + "\n\n" + strings.Repeat("b", 40) + "\n\n" + strings.Repeat("c", 40)
+	chunks := Split(text, 20)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != strings.Repeat("a", 40)+"\n\n"+strings.Repeat("b", 40) {
+		t.Errorf("expected first chunk to hold the first two paragraphs, got %q", chunks[0])
+	}
+	if chunks[1] != strings.Repeat("c", 40) {
+		t.Errorf("expected second chunk to hold the last paragraph, got %q", chunks[1])
+	}
+}
+
+func TestSplit_OversizedParagraph(t *testing.T) {
+	sentence := strings.Repeat("x", 30) + "."
+	text := strings.Join([]string{sentence, sentence, sentence}, " ")
+	chunks := Split(text, 10)
+	if len(chunks) != 3 {
+		t.Fatalf("expected oversized paragraph to be split per sentence, got %d: %q", len(chunks), chunks)
+	}
+	for _, chunk := range chunks {
+		if Estimate(chunk) > 10 {
+			t.Errorf("chunk exceeds token budget: %q", chunk)
+		}
+	}
+}
+
+func TestSplit_OversizedSentence(t *testing.T) {
+	chunks := Split(strings.Repeat("é", 100), 5)
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 chunks of 20 runes, got %d", len(chunks))
+	}
+	for _, chunk := range chunks {
+		if Estimate(chunk) > 5 {
+			t.Errorf("chunk exceeds token budget: %q", chunk)
+		}
+	}
+}
+
+func TestSplit_CJK(t *testing.T) {
+	chunks := Split(strings.Repeat("日本語のテキスト。", 20), 10)
+	for _, chunk := range chunks {
+		if Estimate(chunk) > 10 {
+			t.Errorf("chunk exceeds token budget: %q", chunk)
+		}
+	}
+	if strings.Join(chunks, "") != strings.Repeat("日本語のテキスト。", 20) {
+		t.Error("expected the chunks to hold the whole text")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := Truncate("abcdefgh", 1); got != "abcd" {
+		t.Errorf("expected truncation to 4 characters, got %q", got)
+	}
+	if got := Truncate("abc", 10); got != "abc" {
+		t.Errorf("expected short text to be unchanged, got %q", got)
+	}
+	if got := Truncate("日本語のテキスト", 3); got != "日本語" {
+		t.Errorf("expected truncation on rune boundary, got %q", got)
+	}
+}
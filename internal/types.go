@@ -3,15 +3,25 @@ package internal
 import (
 	"time"
 
+	"github.com/demeyerthom/feeds-aggregator/internal/entity"
+	"github.com/demeyerthom/feeds-aggregator/internal/feedfilter"
+	"github.com/demeyerthom/feeds-aggregator/internal/semver"
 	"github.com/mmcdole/gofeed"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type FeedList []Feed
 
+// FeedTypeRelease marks feeds that publish release notes, whose items get a structured Release
+const FeedTypeRelease = "release"
+
 type Feed struct {
 	Title  string `json:"title"`
 	XMLURL string `json:"xmlUrl"`
+	// Type is empty for regular feeds or FeedTypeRelease
+	Type string `json:"type,omitempty"`
+	// Filters decide which items are ingested, before a workflow is started for them
+	Filters []feedfilter.Rule `json:"filters,omitempty"`
 }
 
 type Post struct {
@@ -20,16 +30,108 @@ type Post struct {
 }
 
 type FeedItem struct {
-	Link  string `json:"link"`
-	Title string `json:"title"`
+	Link    string `json:"link"`
+	Title   string `json:"title"`
+	Feed    string `json:"feed"`
+	FeedURL string `json:"feedUrl"`
+	// FeedType is the Type of the feed the item was published in
+	FeedType string `json:"feedType,omitempty"`
+	// Published is the publication date of the item, if the feed provides one
+	Published *time.Time `json:"published,omitempty"`
+}
+
+// TokenUsage is the number of LLM tokens consumed to process a feed item and their estimated cost
+type TokenUsage struct {
+	PromptTokens     int64   `bson:"prompt_tokens"`
+	CompletionTokens int64   `bson:"completion_tokens"`
+	EstimatedCost    float64 `bson:"estimated_cost"`
+}
+
+// Processing statuses of a feed item document
+const (
+	// StatusPending is the status of items that are being ingested
+	StatusPending = "pending"
+	// StatusProcessed is the status of items with a summary and categories
+	StatusProcessed = "processed"
+	// StatusFailed is the status of items whose HTML could not be fetched or processed
+	StatusFailed = "failed"
+)
+
+// Release is the structured release information extracted from an item of a release feed
+type Release struct {
+	Product string `bson:"product" json:"product"`
+	// ProductKey is the normalized product name used for queries
+	ProductKey      string         `bson:"product_key" json:"productKey"`
+	Version         semver.Version `bson:"version" json:"version"`
+	ReleaseDate     *time.Time     `bson:"release_date,omitempty" json:"releaseDate,omitempty"`
+	BreakingChanges []string       `bson:"breaking_changes,omitempty" json:"breakingChanges,omitempty"`
+	SecurityFixes   []string       `bson:"security_fixes,omitempty" json:"securityFixes,omitempty"`
+	Deprecations    []string       `bson:"deprecations,omitempty" json:"deprecations,omitempty"`
+	Highlights      []string       `bson:"highlights,omitempty" json:"highlights,omitempty"`
+}
+
+// AlertMatch is an alert rule matched by a feed item
+type AlertMatch struct {
+	RuleID    primitive.ObjectID `bson:"rule_id" json:"ruleId"`
+	Rule      string             `bson:"rule" json:"rule"`
+	MatchedAt time.Time          `bson:"matched_at" json:"matchedAt"`
+}
+
+// Relevance is how relevant a feed item is to the team: the score of the interest profile it is
+// most relevant to, with the scores of every profile
+type Relevance struct {
+	Score    float64            `bson:"score" json:"score"`
+	Profile  string             `bson:"profile,omitempty" json:"profile,omitempty"`
+	Profiles []ProfileRelevance `bson:"profiles,omitempty" json:"profiles,omitempty"`
+}
+
+// ProfileRelevance is the relevance of a feed item to one interest profile, between 0 and 1
+type ProfileRelevance struct {
+	Profile string  `bson:"profile" json:"profile"`
+	Score   float64 `bson:"score" json:"score"`
+	// RuleScore is computed from the weighted categories, keywords and feeds of the profile
+	RuleScore float64 `bson:"rule_score" json:"ruleScore"`
+	// LLMScore is the LLM-judged relevance to the interest statement of the profile, if judged
+	LLMScore *float64 `bson:"llm_score,omitempty" json:"llmScore,omitempty"`
+	Reason   string   `bson:"reason,omitempty" json:"reason,omitempty"`
 }
 
 // FeedItemDocument is the MongoDB document model for storing feed items
 type FeedItemDocument struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	Link       string             `bson:"link"`
-	Title      string             `bson:"title"`
-	Summary    string             `bson:"summary,omitempty"`
-	Categories []string           `bson:"categories"`
-	CreatedAt  time.Time          `bson:"created_at"`
+	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	Link             string             `bson:"link"`
+	Title            string             `bson:"title"`
+	Feed             string             `bson:"feed,omitempty"`
+	FeedURL          string             `bson:"feed_url,omitempty"`
+	FeedType         string             `bson:"feed_type,omitempty"`
+	PublishedAt      *time.Time         `bson:"published_at,omitempty"`
+	Status           string             `bson:"status,omitempty"`
+	Error            string             `bson:"error,omitempty"`
+	Summary          string             `bson:"summary,omitempty"`
+	OriginalSummary  string             `bson:"original_summary,omitempty"`
+	Language         string             `bson:"language,omitempty"`
+	SummaryLanguage  string             `bson:"summary_language,omitempty"`
+	Text             string             `bson:"text,omitempty"`
+	TextLanguage     string             `bson:"text_language,omitempty"`
+	Categories       []string           `bson:"categories"`
+	Entities         []entity.Entity    `bson:"entities,omitempty"`
+	Keywords         []string           `bson:"keywords,omitempty"`
+	Provider         string             `bson:"provider,omitempty"`
+	Model            string             `bson:"model,omitempty"`
+	PromptVersion    string             `bson:"prompt_version,omitempty"`
+	Usage            *TokenUsage        `bson:"usage,omitempty"`
+	CacheHit         bool               `bson:"cache_hit,omitempty"`
+	Suspicious       bool               `bson:"suspicious,omitempty"`
+	InjectionSignals []string           `bson:"injection_signals,omitempty"`
+	SimHash          int64              `bson:"simhash,omitempty"`
+	ClusterID        primitive.ObjectID `bson:"cluster_id,omitempty"`
+	Release          *Release           `bson:"release,omitempty"`
+	Alerts           []AlertMatch       `bson:"alerts,omitempty"`
+	Relevance        *Relevance         `bson:"relevance,omitempty"`
+	CreatedAt        time.Time          `bson:"created_at"`
+	ProcessedAt      *time.Time         `bson:"processed_at,omitempty"`
+
+	// BypassCache forces content processing past the LLM response cache, when reprocessing the item;
+	// it is carried between activities but not stored
+	BypassCache bool `bson:"-"`
 }
@@ -0,0 +1,59 @@
+// Package usage tracks LLM token usage, estimates its cost and enforces a daily spending budget.
+package usage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Price is the cost in USD per million prompt (input) and completion (output) tokens
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Pricing maps model names to their price.
+// It is parsed from environment values in the form "model=input:output,model=input:output".
+type Pricing map[string]Price
+
+// UnmarshalEnvironmentValue implements env.Unmarshaler.
+func (p *Pricing) UnmarshalEnvironmentValue(data string) error {
+	pricing := Pricing{}
+	for _, pair := range strings.Split(data, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		// Split on the last "=" so model names may contain one
+		i := strings.LastIndex(pair, "=")
+		if i <= 0 {
+			return fmt.Errorf("invalid price %q, expected model=input:output", pair)
+		}
+		input, output, ok := strings.Cut(pair[i+1:], ":")
+		if !ok {
+			return fmt.Errorf("invalid price %q, expected model=input:output", pair)
+		}
+		in, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil || in < 0 {
+			return fmt.Errorf("invalid input price in %q", pair)
+		}
+		out, err := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		if err != nil || out < 0 {
+			return fmt.Errorf("invalid output price in %q", pair)
+		}
+		pricing[strings.TrimSpace(pair[:i])] = Price{Input: in, Output: out}
+	}
+	*p = pricing
+	return nil
+}
+
+// Cost returns the estimated cost in USD of the given token counts for model.
+// Models without a configured price are assumed to be free, e.g. self-hosted Ollama models.
+func (p Pricing) Cost(model string, promptTokens, completionTokens int64) float64 {
+	price, ok := p[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1_000_000
+}
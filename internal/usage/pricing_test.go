@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPricing_Unmarshal(t *testing.T) {
+	var pricing Pricing
+	if err := pricing.UnmarshalEnvironmentValue("big-pickle=0.5:1.5, claude-haiku-4-5=1:5"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pricing["big-pickle"] != (Price{Input: 0.5, Output: 1.5}) {
+		t.Errorf("unexpected price for big-pickle: %+v", pricing["big-pickle"])
+	}
+	if pricing["claude-haiku-4-5"] != (Price{Input: 1, Output: 5}) {
+		t.Errorf("unexpected price for claude-haiku-4-5: %+v", pricing["claude-haiku-4-5"])
+	}
+}
+
+func TestPricing_UnmarshalInvalid(t *testing.T) {
+	for _, value := range []string{"model", "model=1", "model=a:1", "model=1:b", "model=-1:1", "=1:1"} {
+		var pricing Pricing
+		if err := pricing.UnmarshalEnvironmentValue(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestPricing_Cost(t *testing.T) {
+	pricing := Pricing{"m": {Input: 2, Output: 10}}
+
+	cost := pricing.Cost("m", 500_000, 100_000)
+	if math.Abs(cost-2.0) > 1e-9 {
+		t.Errorf("expected cost 2.0, got %f", cost)
+	}
+	if pricing.Cost("unknown", 1_000_000, 1_000_000) != 0 {
+		t.Error("expected models without pricing to be free")
+	}
+}
+
+func TestTracker_ResetAt(t *testing.T) {
+	tracker := NewTracker(nil, nil, 10)
+	tracker.now = func() time.Time {
+		return time.Date(2026, 3, 31, 22, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	}
+
+	expected := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	if got := tracker.ResetAt(); !got.Equal(expected) {
+		t.Errorf("expected reset at %s, got %s", expected, got)
+	}
+	if got := tracker.spendKey(); got != "feeds:llm:spend:2026-03-31" {
+		t.Errorf("unexpected spend key %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal/llm"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// spendKeyPrefix is the Redis key prefix for the daily spend counters, suffixed with the UTC date
+const spendKeyPrefix = "feeds:llm:spend:"
+
+var (
+	promptTokensCounter     metric.Int64Counter
+	completionTokensCounter metric.Int64Counter
+	costCounter             metric.Float64Counter
+)
+
+func init() {
+	meter := otel.Meter("feeds-worker")
+
+	promptTokensCounter, _ = meter.Int64Counter(
+		"feeds.llm.prompt_tokens",
+		metric.WithDescription("Number of prompt tokens sent to LLM providers"),
+		metric.WithUnit("{token}"),
+	)
+	completionTokensCounter, _ = meter.Int64Counter(
+		"feeds.llm.completion_tokens",
+		metric.WithDescription("Number of completion tokens returned by LLM providers"),
+		metric.WithUnit("{token}"),
+	)
+	costCounter, _ = meter.Float64Counter(
+		"feeds.llm.estimated_cost",
+		metric.WithDescription("Estimated cost of LLM calls based on configured per-model pricing"),
+		metric.WithUnit("USD"),
+	)
+}
+
+// Tracker records token usage and estimated cost of LLM calls, and keeps a per-day spend total in
+// Redis so the daily budget is shared across workers.
+type Tracker struct {
+	rdb         *redis.Client
+	pricing     Pricing
+	dailyBudget float64
+	now         func() time.Time
+}
+
+// NewTracker creates a usage tracker. A dailyBudget of 0 disables the budget.
+func NewTracker(rdb *redis.Client, pricing Pricing, dailyBudget float64) *Tracker {
+	return &Tracker{rdb: rdb, pricing: pricing, dailyBudget: dailyBudget, now: time.Now}
+}
+
+// Record emits usage metrics for resp and adds its estimated cost to today's spend.
+// It returns the estimated cost of the call.
+func (t *Tracker) Record(ctx context.Context, feed string, resp llm.Response) (float64, error) {
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("feed.title", feed),
+		attribute.String("llm.model", resp.Model),
+		attribute.String("llm.provider", resp.Provider),
+	))
+	promptTokensCounter.Add(ctx, resp.Usage.PromptTokens, attrs)
+	completionTokensCounter.Add(ctx, resp.Usage.CompletionTokens, attrs)
+
+	cost := t.pricing.Cost(resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	if cost == 0 {
+		return 0, nil
+	}
+	costCounter.Add(ctx, cost, attrs)
+
+	key := t.spendKey()
+	pipe := t.rdb.TxPipeline()
+	pipe.IncrByFloat(ctx, key, cost)
+	pipe.Expire(ctx, key, 48*time.Hour)
+	_, err := pipe.Exec(ctx)
+	return cost, err
+}
+
+// Exceeded reports whether today's spend has reached the daily budget, and when the budget resets.
+func (t *Tracker) Exceeded(ctx context.Context) (bool, time.Time, error) {
+	resetAt := t.ResetAt()
+	if t.dailyBudget <= 0 {
+		return false, resetAt, nil
+	}
+
+	val, err := t.rdb.Get(ctx, t.spendKey()).Result()
+	if err == redis.Nil {
+		return false, resetAt, nil
+	}
+	if err != nil {
+		return false, resetAt, err
+	}
+
+	spent, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return false, resetAt, err
+	}
+	return spent >= t.dailyBudget, resetAt, nil
+}
+
+// ResetAt returns the start of the next UTC day, when the daily budget resets.
+func (t *Tracker) ResetAt() time.Time {
+	now := t.now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+func (t *Tracker) spendKey() string {
+	return spendKeyPrefix + t.now().UTC().Format("2006-01-02")
+}
@@ -0,0 +1,139 @@
+// Package vector provides an in-process vector index for nearest-neighbour search over feed item
+// embeddings, and the MongoDB store the index is loaded from.
+package vector
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Result is a search hit with its cosine similarity to the query
+type Result struct {
+	ID    string
+	Score float64
+}
+
+// Index is an exact cosine-similarity index. Vectors are normalized when added, so a search is a
+// single dot product per stored vector. Items may have several vectors (e.g. summary and content);
+// an item scores as its best-matching vector.
+type Index struct {
+	mu      sync.RWMutex
+	dims    int
+	vectors map[string][][]float32
+}
+
+// NewIndex creates an empty index.
+func NewIndex() *Index {
+	return &Index{vectors: map[string][][]float32{}}
+}
+
+// Add stores vectors for id, replacing the vectors stored for it before. Vectors with a different
+// dimension than the first vector added, or with zero length, are ignored. It reports how many
+// vectors were stored.
+func (x *Index) Add(id string, vectors ...[]float32) int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var stored [][]float32
+	for _, v := range vectors {
+		if len(v) == 0 {
+			continue
+		}
+		if x.dims == 0 {
+			x.dims = len(v)
+		}
+		if len(v) != x.dims {
+			continue
+		}
+		n := normalize(v)
+		if n == nil {
+			continue
+		}
+		stored = append(stored, n)
+	}
+	if len(stored) == 0 {
+		delete(x.vectors, id)
+		return 0
+	}
+	x.vectors[id] = stored
+	return len(stored)
+}
+
+// Len returns the number of stored vectors.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	n := 0
+	for _, vectors := range x.vectors {
+		n += len(vectors)
+	}
+	return n
+}
+
+// Search returns the k items most similar to query, best first.
+func (x *Index) Search(query []float32, k int) []Result {
+	q := normalize(query)
+	if q == nil || k <= 0 {
+		return nil
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if len(q) != x.dims {
+		return nil
+	}
+
+	results := make([]Result, 0, len(x.vectors))
+	for id, vectors := range x.vectors {
+		best := math.Inf(-1)
+		for _, v := range vectors {
+			best = max(best, dot(q, v))
+		}
+		results = append(results, Result{ID: id, Score: best})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if either is empty or of different length.
+func Cosine(a, b []float32) float64 {
+	na, nb := normalize(a), normalize(b)
+	if na == nil || nb == nil || len(na) != len(nb) {
+		return 0
+	}
+	return dot(na, nb)
+}
+
+// normalize returns v scaled to unit length, or nil for an empty or zero vector.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return nil
+	}
+	norm := math.Sqrt(sum)
+	n := make([]float32, len(v))
+	for i, f := range v {
+		n[i] = float32(float64(f) / norm)
+	}
+	return n
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,88 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIndex_Search(t *testing.T) {
+	x := NewIndex()
+	x.Add("go", []float32{1, 0, 0})
+	x.Add("rust", []float32{0, 1, 0})
+	x.Add("mixed", []float32{1, 1, 0})
+
+	results := x.Search([]float32{2, 0.1, 0}, 2)
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].ID != "go" || results[1].ID != "mixed" {
+		t.Errorf("unexpected order: %+v", results)
+	}
+	if results[0].Score < results[1].Score {
+		t.Errorf("expected results sorted by score, got %+v", results)
+	}
+}
+
+func TestIndex_ItemScoresAsBestVector(t *testing.T) {
+	x := NewIndex()
+	x.Add("item", []float32{0, 1}, []float32{1, 0})
+	x.Add("other", []float32{1, 1})
+
+	results := x.Search([]float32{1, 0}, 10)
+	if len(results) != 2 {
+		t.Fatalf("expected one result per item, got %+v", results)
+	}
+	if results[0].ID != "item" || math.Abs(results[0].Score-1) > 1e-6 {
+		t.Errorf("expected item to match through its best vector, got %+v", results)
+	}
+}
+
+func TestIndex_IgnoresMismatchedVectors(t *testing.T) {
+	x := NewIndex()
+	if n := x.Add("a", []float32{1, 0}, nil, []float32{0, 0}, []float32{1, 2, 3}); n != 1 {
+		t.Errorf("expected only the first valid vector to be stored, got %d", n)
+	}
+	if x.Len() != 1 {
+		t.Errorf("expected 1 stored vector, got %d", x.Len())
+	}
+	if results := x.Search([]float32{1, 0, 0}, 5); results != nil {
+		t.Errorf("expected no results for a query of another dimension, got %+v", results)
+	}
+}
+
+func TestIndex_AddReplacesVectors(t *testing.T) {
+	x := NewIndex()
+	x.Add("item", []float32{1, 0}, []float32{1, 1})
+	x.Add("item", []float32{0, 1})
+
+	if x.Len() != 1 {
+		t.Errorf("expected the vectors of item to be replaced, got %d stored", x.Len())
+	}
+	results := x.Search([]float32{1, 0}, 10)
+	if len(results) != 1 || math.Abs(results[0].Score) > 1e-6 {
+		t.Errorf("expected item to match through its new vector only, got %+v", results)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if c := Cosine([]float32{1, 0}, []float32{2, 0}); math.Abs(c-1) > 1e-6 {
+		t.Errorf("expected parallel vectors to have similarity 1, got %f", c)
+	}
+	if c := Cosine([]float32{1, 0}, []float32{0, 3}); math.Abs(c) > 1e-6 {
+		t.Errorf("expected orthogonal vectors to have similarity 0, got %f", c)
+	}
+	if c := Cosine([]float32{1}, []float32{1, 0}); c != 0 {
+		t.Errorf("expected 0 for vectors of different length, got %f", c)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	a := Embedding{Summary: []float32{1, 0}, Content: []float32{0, 1}}
+	b := Embedding{Summary: []float32{0, 1}, Content: []float32{0, 2}}
+	if s := Similarity(a, b); math.Abs(s-1) > 1e-6 {
+		t.Errorf("expected the best matching pair to count, got %f", s)
+	}
+	if s := Similarity(a, Embedding{}); s != 0 {
+		t.Errorf("expected 0 without vectors, got %f", s)
+	}
+}
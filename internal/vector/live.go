@@ -0,0 +1,45 @@
+package vector
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// refreshOverlap is how far a refresh reaches back before the latest embedding loaded, so
+// embeddings saved at the same time by workers with slightly different clocks are not missed
+const refreshOverlap = time.Minute
+
+// LiveIndex is the index of the embeddings of one model, loaded once and kept up to date by
+// loading the embeddings saved since the previous refresh.
+type LiveIndex struct {
+	*Index
+	store *Store
+	model string
+
+	mu    sync.Mutex
+	since time.Time
+}
+
+// NewLiveIndex creates an empty index of the embeddings created by model in store. It is filled by
+// Refresh.
+func NewLiveIndex(store *Store, model string) *LiveIndex {
+	return &LiveIndex{Index: NewIndex(), store: store, model: model}
+}
+
+// Refresh loads the embeddings saved since the previous refresh, or all of them the first time.
+// Items embedded again replace their vectors.
+func (l *LiveIndex) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	since := l.since
+	if !since.IsZero() {
+		since = since.Add(-refreshOverlap)
+	}
+	latest, err := l.store.LoadInto(ctx, l.Index, l.model, since)
+	if latest.After(l.since) {
+		l.since = latest
+	}
+	return err
+}
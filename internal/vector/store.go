@@ -0,0 +1,102 @@
+package vector
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Embedding holds the vectors of a feed item, stored separately from the feed item document so
+// item queries do not load the vectors
+type Embedding struct {
+	ItemID    primitive.ObjectID `bson:"_id"`
+	Model     string             `bson:"model"`
+	Summary   []float32          `bson:"summary,omitempty"`
+	Content   []float32          `bson:"content,omitempty"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Store persists feed item embeddings in MongoDB
+type Store struct {
+	collection *mongo.Collection
+}
+
+// NewStore creates an embedding store backed by collection.
+func NewStore(collection *mongo.Collection) *Store {
+	return &Store{collection: collection}
+}
+
+// EnsureIndexes creates the index used to load the embeddings of one model, in the order they were
+// created.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "model", Value: 1}, {Key: "created_at", Value: 1}}})
+	return err
+}
+
+// Save stores the embedding of a feed item, replacing any previous one.
+func (s *Store) Save(ctx context.Context, e Embedding) error {
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": e.ItemID}, e, options.Replace().SetUpsert(true))
+	return err
+}
+
+// LoadIndex builds an in-process index from all embeddings created by model. Vectors from other
+// models are not comparable and are skipped.
+func (s *Store) LoadIndex(ctx context.Context, model string) (*Index, error) {
+	index := NewIndex()
+	_, err := s.LoadInto(ctx, index, model, time.Time{})
+	return index, err
+}
+
+// LoadInto adds the embeddings created by model at or after since to index, replacing the vectors
+// of items embedded again, and returns the latest creation time loaded, or since when none was.
+func (s *Store) LoadInto(ctx context.Context, index *Index, model string, since time.Time) (time.Time, error) {
+	q := bson.M{"model": model}
+	if !since.IsZero() {
+		q["created_at"] = bson.M{"$gte": since}
+	}
+	cursor, err := s.collection.Find(ctx, q)
+	if err != nil {
+		return since, err
+	}
+	defer cursor.Close(ctx)
+
+	latest := since
+	for cursor.Next(ctx) {
+		var e Embedding
+		if err := cursor.Decode(&e); err != nil {
+			return latest, err
+		}
+		index.Add(e.ItemID.Hex(), e.Summary, e.Content)
+		if e.CreatedAt.After(latest) {
+			latest = e.CreatedAt
+		}
+	}
+	return latest, cursor.Err()
+}
+
+// Find returns the embeddings of the given feed items created by model, keyed by item ID.
+func (s *Store) Find(ctx context.Context, model string, ids []primitive.ObjectID) (map[primitive.ObjectID]Embedding, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "model": model})
+	if err != nil {
+		return nil, err
+	}
+	var embeddings []Embedding
+	if err := cursor.All(ctx, &embeddings); err != nil {
+		return nil, err
+	}
+
+	result := make(map[primitive.ObjectID]Embedding, len(embeddings))
+	for _, e := range embeddings {
+		result[e.ItemID] = e
+	}
+	return result, nil
+}
+
+// Similarity returns the best cosine similarity between the summary and content vectors of a and b.
+func Similarity(a, b Embedding) float64 {
+	return max(Cosine(a.Summary, b.Summary), Cosine(a.Content, b.Content))
+}
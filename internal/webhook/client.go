@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxErrorBody is the number of response body bytes kept in the error of a failed delivery
+const maxErrorBody = 512
+
+// StatusError is returned when the webhook responds with a non-2xx status
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("webhook responded with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable reports whether the delivery may succeed when retried: on timeouts, rate limits and
+// server errors. Other client errors mean the request itself is rejected.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// DeliveryID returns the ID of the delivery of an item to a subscription, which stays the same
+// across retries.
+func DeliveryID(subscriptionID, itemID primitive.ObjectID) string {
+	return subscriptionID.Hex() + "-" + itemID.Hex()
+}
+
+// Client posts payloads to webhooks
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client sending requests with httpClient.
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{http: httpClient}
+}
+
+// Send posts event to the URL of sub in the format of the subscription, signing it when the
+// subscription has a secret. It returns the response status code, and a *StatusError for non-2xx
+// responses.
+func (c *Client) Send(ctx context.Context, sub Subscription, event Event, now time.Time) (int, error) {
+	body, err := Body(sub.Format, event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FeedsAggregator-Webhook/1.0")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	if sub.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(sub.Secret, now, body))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2026, 10, 18, 7, 0, 0, 0, time.UTC)
+	body := []byte(`{"id":"1"}`)
+	header := Sign("secret", now, body)
+
+	if err := Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Errorf("expected a valid signature, got %v", err)
+	}
+	for name, err := range map[string]error{
+		"other secret": Verify("other", header, body, now, 5*time.Minute),
+		"other body":   Verify("secret", header, []byte(`{"id":"2"}`), now, 5*time.Minute),
+		"expired":      Verify("secret", header, body, now.Add(10*time.Minute), 5*time.Minute),
+		"malformed":    Verify("secret", "v1=abc", body, now, 5*time.Minute),
+	} {
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: expected ErrInvalidSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestClient_Send(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	event := testEvent()
+	status, err := NewClient(srv.Client()).Send(context.Background(), Subscription{URL: srv.URL, Format: FormatJSON, Secret: "secret"}, event, now)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d, %v", status, err)
+	}
+	if received.Header.Get(DeliveryHeader) != event.ID || received.Header.Get(EventHeader) != EventItemProcessed {
+		t.Errorf("unexpected headers %v", received.Header)
+	}
+	if err := Verify("secret", received.Header.Get(SignatureHeader), receivedBody, now, time.Minute); err != nil {
+		t.Errorf("expected a verifiable signature, got %v", err)
+	}
+}
+
+func TestClient_SendUnsigned(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(SignatureHeader) != "" {
+			t.Error("expected no signature without a secret")
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.Client()).Send(context.Background(), Subscription{URL: srv.URL, Format: FormatSlack}, testEvent(), time.Now()); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+}
+
+func TestClient_SendStatusError(t *testing.T) {
+	for status, retryable := range map[int]bool{
+		http.StatusBadRequest:          false,
+		http.StatusNotFound:            false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", status)
+		}))
+
+		_, err := NewClient(srv.Client()).Send(context.Background(), Subscription{URL: srv.URL, Format: FormatSlack}, testEvent(), time.Now())
+		srv.Close()
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != status || statusErr.Body != "nope" {
+			t.Errorf("expected a status error for %d, got %v", status, err)
+			continue
+		}
+		if statusErr.Retryable() != retryable {
+			t.Errorf("status %d: Retryable() = %t, want %t", status, statusErr.Retryable(), retryable)
+		}
+	}
+}
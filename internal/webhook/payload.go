@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+)
+
+const (
+	// EventItemProcessed is the type of the event sent when a feed item has been processed
+	EventItemProcessed = "feed_item.processed"
+	// EventAlertMatched is the type of the event sent when a feed item matches an alert rule
+	EventAlertMatched = "alert.matched"
+)
+
+// Event is the JSON payload of the json format
+type Event struct {
+	// ID identifies the delivery, so receivers can drop retried deliveries they already handled
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Item      Item      `json:"item"`
+	// Rule is the matched alert rule of alert events
+	Rule *Rule `json:"rule,omitempty"`
+}
+
+// Rule is the alert rule in an alert event
+type Rule struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item is the feed item in an event
+type Item struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Feed        string     `json:"feed,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+	Categories  []string   `json:"categories"`
+	Keywords    []string   `json:"keywords,omitempty"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// slackMessage is a Slack incoming webhook message
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// discordMessage is a Discord webhook message
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Timestamp   *time.Time     `json:"timestamp,omitempty"`
+	Footer      *discordFooter `json:"footer,omitempty"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+const (
+	// discordTitleLimit and discordDescriptionLimit are the embed limits of Discord
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+// NewEvent creates the processed event of doc for the delivery with the given ID.
+func NewEvent(deliveryID string, doc internal.FeedItemDocument, now time.Time) Event {
+	return Event{
+		ID:        deliveryID,
+		Type:      EventItemProcessed,
+		CreatedAt: now,
+		Item: Item{
+			ID:          doc.ID.Hex(),
+			Title:       doc.Title,
+			Link:        doc.Link,
+			Feed:        doc.Feed,
+			Summary:     doc.Summary,
+			Categories:  doc.Categories,
+			Keywords:    doc.Keywords,
+			PublishedAt: doc.PublishedAt,
+			CreatedAt:   doc.CreatedAt,
+		},
+	}
+}
+
+// Body encodes event in format.
+func Body(format Format, event Event) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.Marshal(event)
+	case FormatSlack:
+		return json.Marshal(newSlackMessage(event))
+	case FormatDiscord:
+		return json.Marshal(newDiscordMessage(event))
+	}
+	return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, format)
+}
+
+func newSlackMessage(event Event) slackMessage {
+	item := event.Item
+	title := fmt.Sprintf("<%s|%s>", item.Link, slackEscape(item.Title))
+	msg := slackMessage{
+		// Text is the fallback shown in notifications
+		Text:   alertPrefix(event) + item.Title,
+		Blocks: []slackBlock{{Type: "section", Text: &slackText{Type: "mrkdwn", Text: slackEscape(alertPrefix(event)) + "*" + title + "*"}}},
+	}
+	if item.Summary != "" {
+		msg.Blocks[0].Text.Text += "\n" + slackEscape(item.Summary)
+	}
+	if context := itemContext(item); context != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: slackEscape(context)}}})
+	}
+	return msg
+}
+
+func newDiscordMessage(event Event) discordMessage {
+	item := event.Item
+	embed := discordEmbed{
+		Title:       truncate(alertPrefix(event)+item.Title, discordTitleLimit),
+		URL:         item.Link,
+		Description: truncate(item.Summary, discordDescriptionLimit),
+		Timestamp:   item.PublishedAt,
+	}
+	if context := itemContext(item); context != "" {
+		embed.Footer = &discordFooter{Text: context}
+	}
+	return discordMessage{Embeds: []discordEmbed{embed}}
+}
+
+// alertPrefix returns the prefix of chat messages for alert events, e.g. "Alert Terraform breaking: ".
+func alertPrefix(event Event) string {
+	if event.Rule == nil {
+		return ""
+	}
+	return "Alert " + event.Rule.Name + ": "
+}
+
+// itemContext returns the feed and categories of item as one line, e.g. "Go Blog · Programming Languages, Go".
+func itemContext(item Item) string {
+	var parts []string
+	if item.Feed != "" {
+		parts = append(parts, item.Feed)
+	}
+	if len(item.Categories) > 0 {
+		parts = append(parts, strings.Join(item.Categories, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// slackEscape escapes the control characters of Slack mrkdwn.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens s to at most n runes, ending in an ellipsis when shortened.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testEvent() Event {
+	published := time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)
+	doc := internal.FeedItemDocument{
+		ID:          primitive.NewObjectID(),
+		Title:       "Go 1.30 <beta> & more",
+		Link:        "https://go.dev/blog/go1.30",
+		Feed:        "Go Blog",
+		Summary:     "Go 1.30 adds generic methods.",
+		Categories:  []string{"Programming Languages"},
+		PublishedAt: &published,
+	}
+	return NewEvent("delivery-1", doc, published.Add(time.Hour))
+}
+
+func TestBody_JSON(t *testing.T) {
+	event := testEvent()
+	b, err := Body(FormatJSON, event)
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if decoded.ID != "delivery-1" || decoded.Type != EventItemProcessed || decoded.Item.ID != event.Item.ID || decoded.Item.Link != event.Item.Link {
+		t.Errorf("unexpected event %+v", decoded)
+	}
+}
+
+func TestBody_Slack(t *testing.T) {
+	b, err := Body(FormatSlack, testEvent())
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+
+	var msg slackMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if msg.Text != "Go 1.30 <beta> & more" {
+		t.Errorf("unexpected fallback text %q", msg.Text)
+	}
+	if len(msg.Blocks) != 2 || !strings.HasPrefix(msg.Blocks[0].Text.Text, "*<https://go.dev/blog/go1.30|Go 1.30 &lt;beta&gt; &amp; more>*\nGo 1.30 adds generic methods.") {
+		t.Errorf("unexpected blocks %+v", msg.Blocks)
+	}
+	if msg.Blocks[1].Elements[0].Text != "Go Blog · Programming Languages" {
+		t.Errorf("unexpected context %+v", msg.Blocks[1])
+	}
+}
+
+func TestBody_Discord(t *testing.T) {
+	event := testEvent()
+	event.Item.Title = strings.Repeat("x", 300)
+	b, err := Body(FormatDiscord, event)
+	if err != nil {
+		t.Fatalf("failed to encode body: %v", err)
+	}
+
+	var msg discordMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if len(msg.Embeds) != 1 {
+		t.Fatalf("expected one embed, got %+v", msg)
+	}
+	embed := msg.Embeds[0]
+	if len([]rune(embed.Title)) != discordTitleLimit || !strings.HasSuffix(embed.Title, "…") {
+		t.Errorf("expected the title to be truncated to %d runes, got %d", discordTitleLimit, len([]rune(embed.Title)))
+	}
+	if embed.URL != event.Item.Link || embed.Description != event.Item.Summary || embed.Footer == nil || embed.Timestamp == nil {
+		t.Errorf("unexpected embed %+v", embed)
+	}
+}
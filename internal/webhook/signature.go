@@ -0,0 +1,70 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the timestamp and HMAC-SHA256 signature of a payload, e.g. "t=1760770800,v1=5257a8…"
+	SignatureHeader = "X-Feeds-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Feeds-Event"
+	// DeliveryHeader carries the delivery ID, which stays the same when a delivery is retried
+	DeliveryHeader = "X-Feeds-Delivery"
+)
+
+// ErrInvalidSignature is returned when a signature header does not match the payload
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Sign returns the signature header value of body sent at t. The signature is the hex-encoded
+// HMAC-SHA256 of the Unix timestamp, a dot and the body, keyed with secret, so a captured request
+// cannot be replayed with a different timestamp.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + signature(secret, ts, body)
+}
+
+// Verify checks a signature header created by Sign for body, rejecting signatures older than
+// tolerance relative to now. Receivers can use it to authenticate deliveries.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := signature(secret, ts, body)
+	for _, s := range signatures {
+		if hmac.Equal([]byte(s), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeliveryRetention is how long deliveries are kept in the delivery log
+const DeliveryRetention = 30 * 24 * time.Hour
+
+// ErrSubscriptionNotFound is returned when a subscription does not exist
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// Delivery is an attempt to deliver a feed item to a subscription, as recorded in the delivery log
+type Delivery struct {
+	ID primitive.ObjectID `bson:"_id,omitempty"`
+	// DeliveryID is the same for all attempts to deliver an item to a subscription
+	DeliveryID     string             `bson:"delivery_id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id"`
+	ItemID         primitive.ObjectID `bson:"item_id"`
+	Link           string             `bson:"link"`
+	Attempt        int32              `bson:"attempt"`
+	Success        bool               `bson:"success"`
+	StatusCode     int                `bson:"status_code,omitempty"`
+	Error          string             `bson:"error,omitempty"`
+	Duration       time.Duration      `bson:"duration"`
+	CreatedAt      time.Time          `bson:"created_at"`
+}
+
+// Store persists webhook subscriptions and their delivery log in MongoDB
+type Store struct {
+	subscriptions *mongo.Collection
+	deliveries    *mongo.Collection
+}
+
+// NewStore creates a store backed by the subscription and delivery log collections.
+func NewStore(subscriptions, deliveries *mongo.Collection) *Store {
+	return &Store{subscriptions: subscriptions, deliveries: deliveries}
+}
+
+// EnsureIndexes creates the index used to list the deliveries of a subscription and the TTL index
+// expiring deliveries after DeliveryRetention.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.deliveries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(DeliveryRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// Enabled returns the subscriptions that receive deliveries.
+func (s *Store) Enabled(ctx context.Context) ([]Subscription, error) {
+	return s.find(ctx, bson.M{"disabled": bson.M{"$ne": true}})
+}
+
+// List returns all subscriptions, ordered by creation time.
+func (s *Store) List(ctx context.Context) ([]Subscription, error) {
+	return s.find(ctx, bson.M{})
+}
+
+func (s *Store) find(ctx context.Context, filter bson.M) ([]Subscription, error) {
+	cursor, err := s.subscriptions.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// Get returns the subscription with the given ID.
+func (s *Store) Get(ctx context.Context, id primitive.ObjectID) (Subscription, error) {
+	var sub Subscription
+	err := s.subscriptions.FindOne(ctx, bson.M{"_id": id}).Decode(&sub)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return sub, ErrSubscriptionNotFound
+	}
+	return sub, err
+}
+
+// Add stores a new subscription and returns it with its ID.
+func (s *Store) Add(ctx context.Context, sub Subscription) (Subscription, error) {
+	sub.ID = primitive.NewObjectID()
+	sub.CreatedAt = time.Now()
+	if _, err := s.subscriptions.InsertOne(ctx, sub); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Remove deletes the subscription with the given ID. Its delivery log expires with DeliveryRetention.
+func (s *Store) Remove(ctx context.Context, id primitive.ObjectID) error {
+	res, err := s.subscriptions.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// LogDelivery adds a delivery attempt to the delivery log.
+func (s *Store) LogDelivery(ctx context.Context, d Delivery) error {
+	_, err := s.deliveries.InsertOne(ctx, d)
+	return err
+}
+
+// Deliveries returns the last limit delivery attempts of a subscription, newest first.
+func (s *Store) Deliveries(ctx context.Context, subscriptionID primitive.ObjectID, limit int) ([]Delivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.deliveries.Find(ctx, bson.M{"subscription_id": subscriptionID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
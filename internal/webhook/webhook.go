@@ -0,0 +1,111 @@
+// Package webhook pushes processed feed items to webhook subscriptions as HMAC-signed JSON
+// payloads or as Slack and Discord messages, and keeps a delivery log per subscription.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Format is the message format of a subscription
+type Format string
+
+const (
+	// FormatJSON posts the signed event payload
+	FormatJSON Format = "json"
+	// FormatSlack posts a Slack incoming webhook message
+	FormatSlack Format = "slack"
+	// FormatDiscord posts a Discord webhook message
+	FormatDiscord Format = "discord"
+)
+
+// ErrInvalidFormat is returned for unknown message formats
+var ErrInvalidFormat = errors.New("format must be json, slack or discord")
+
+// ParseFormat parses a message format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatJSON, FormatSlack, FormatDiscord:
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidFormat, s)
+}
+
+// Subscription is a webhook that receives the processed feed items matching its filter
+type Subscription struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Name   string             `bson:"name,omitempty"`
+	URL    string             `bson:"url"`
+	Format Format             `bson:"format"`
+	// Secret signs the payloads; an empty secret sends them unsigned
+	Secret string `bson:"secret,omitempty"`
+	Filter Filter `bson:"filter"`
+	// Disabled subscriptions receive no deliveries
+	Disabled  bool      `bson:"disabled,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Filter selects the feed items sent to a subscription. An item matches when it matches every
+// non-empty field, by having any of the listed values; an empty filter matches every item.
+type Filter struct {
+	Categories []string `bson:"categories,omitempty"`
+	Feeds      []string `bson:"feeds,omitempty"`
+	// Keywords match the extracted keywords of an item, or words in its title or summary, ignoring case
+	Keywords []string `bson:"keywords,omitempty"`
+}
+
+// Matches reports whether doc passes the filter.
+func (f Filter) Matches(doc internal.FeedItemDocument) bool {
+	if len(f.Categories) > 0 && !slices.ContainsFunc(doc.Categories, func(c string) bool { return slices.Contains(f.Categories, c) }) {
+		return false
+	}
+	if len(f.Feeds) > 0 && !slices.Contains(f.Feeds, doc.Feed) {
+		return false
+	}
+	if len(f.Keywords) > 0 && !slices.ContainsFunc(f.Keywords, func(k string) bool { return hasKeyword(doc, k) }) {
+		return false
+	}
+	return true
+}
+
+// hasKeyword reports whether doc has keyword k among its keywords or as whole words in its title or summary.
+func hasKeyword(doc internal.FeedItemDocument, k string) bool {
+	k = strings.Join(strings.Fields(strings.ToLower(k)), " ")
+	if k == "" {
+		return false
+	}
+	if slices.Contains(doc.Keywords, k) {
+		return true
+	}
+	for _, text := range []string{doc.Title, doc.Summary} {
+		if containsWords(strings.ToLower(text), k) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWords reports whether phrase occurs in text with word boundaries on both sides.
+func containsWords(text, phrase string) bool {
+	for i := 0; ; {
+		j := strings.Index(text[i:], phrase)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(phrase)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 0x80
+}
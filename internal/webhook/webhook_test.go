@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	doc := internal.FeedItemDocument{
+		Title:      "Kubernetes 1.31 released",
+		Summary:    "The release deprecates the gitRepo volume.",
+		Feed:       "Kubernetes Blog",
+		Categories: []string{"Cloud", "DevOps"},
+		Keywords:   []string{"kubernetes", "release notes"},
+	}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"category", Filter{Categories: []string{"Security", "DevOps"}}, true},
+		{"other category", Filter{Categories: []string{"Security"}}, false},
+		{"feed", Filter{Feeds: []string{"Kubernetes Blog"}}, true},
+		{"other feed", Filter{Feeds: []string{"Go Blog"}}, false},
+		{"keyword", Filter{Keywords: []string{"Release  Notes"}}, true},
+		{"title word", Filter{Keywords: []string{"released"}}, true},
+		{"summary word", Filter{Keywords: []string{"gitrepo"}}, true},
+		{"partial word", Filter{Keywords: []string{"kube"}}, false},
+		{"all fields", Filter{Categories: []string{"Cloud"}, Feeds: []string{"Kubernetes Blog"}, Keywords: []string{"volume"}}, true},
+		{"one field fails", Filter{Categories: []string{"Cloud"}, Keywords: []string{"rust"}}, false},
+	}
+	for _, c := range cases {
+		if got := c.filter.Matches(doc); got != c.want {
+			t.Errorf("%s: Matches() = %t, want %t", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("Slack"); err != nil || f != FormatSlack {
+		t.Errorf("expected slack, got %q, %v", f, err)
+	}
+	if _, err := ParseFormat("teams"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("expected ErrInvalidFormat, got %v", err)
+	}
+}
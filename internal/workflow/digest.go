@@ -0,0 +1,98 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"github.com/demeyerthom/feeds-aggregator/internal/digest"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// DigestRequest is the input of the Digest workflow
+type DigestRequest struct {
+	Frequency digest.Frequency
+	// Overview asks the LLM for a short overview of the period at the top of each digest
+	Overview bool
+}
+
+// Digest is the workflow function that sends the daily or weekly digest to every subscriber of
+// the requested frequency. For each subscriber it collects the items processed since their last
+// digest, optionally asks the LLM for an overview, sends the email and records the end of the
+// period so the next digest continues from there. Subscribers without new items get no email. A
+// failed overview only leaves the overview out, and a failure for one subscriber does not stop the
+// digests of the others; the workflow fails at the end if any digest could not be sent.
+//
+// @param ctx - Workflow context
+// @param req - The digest frequency and whether to include an overview
+// @return error - Returns an error if any digest could not be sent
+// @author Thomas De Meyer
+func Digest() func(ctx workflow.Context, req DigestRequest) error {
+	return func(ctx workflow.Context, req DigestRequest) error {
+		logger := workflow.GetLogger(ctx)
+		logger.Info("Digest workflow started.", "frequency", req.Frequency, "overview", req.Overview)
+
+		ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			StartToCloseTimeout: 5 * time.Minute,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 3,
+			},
+		})
+		llmCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+			TaskQueue:           internal.LLMTaskQueueName,
+			StartToCloseTimeout: 15 * time.Minute,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 3,
+			},
+		})
+
+		var subscribers []digest.Subscriber
+		err := workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.ListDigestSubscribers), req.Frequency).Get(ctx, &subscribers)
+		if err != nil {
+			logger.Error("listDigestSubscribersActivity activity failed.", "Error", err)
+			return err
+		}
+
+		until := workflow.Now(ctx)
+		failed := 0
+		for _, subscriber := range subscribers {
+			if err := sendDigest(ctx, llmCtx, req, subscriber, until); err != nil {
+				logger.Error("Failed to send digest.", "email", subscriber.Email, "Error", err)
+				failed++
+			}
+		}
+
+		logger.Info("Digest workflow completed.", "frequency", req.Frequency, "subscribers", len(subscribers), "failed", failed)
+		if failed > 0 {
+			return fmt.Errorf("failed to send %d of %d digests", failed, len(subscribers))
+		}
+		return nil
+	}
+}
+
+// sendDigest collects, summarizes and sends the digest of one subscriber for the period ending at until.
+func sendDigest(ctx, llmCtx workflow.Context, req DigestRequest, subscriber digest.Subscriber, until time.Time) error {
+	var d digest.Digest
+	err := workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.CollectDigest), subscriber, until).Get(ctx, &d)
+	if err != nil {
+		return err
+	}
+
+	if !d.Empty() {
+		if req.Overview {
+			err = workflow.ExecuteActivity(llmCtx, internal.GetFunctionName(activity.SummarizeDigest), d).Get(ctx, &d.Overview)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("summarizeDigestActivity activity failed, sending digest without overview.", "email", subscriber.Email, "Error", err)
+			}
+		}
+
+		err = workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.SendDigest), subscriber, d).Get(ctx, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.MarkDigestSent), subscriber, until).Get(ctx, nil)
+}
@@ -6,12 +6,16 @@ import (
 
 	"github.com/demeyerthom/feeds-aggregator/internal"
 	"github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"github.com/demeyerthom/feeds-aggregator/internal/alert"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
 // IngestFeedItem is the workflow function that orchestrates feed item ingestion.
-// It executes three activities in sequence: add feed item, fetch HTML, and process content.
+// It executes its steps in sequence: add feed item, fetch HTML, process content, extract release
+// information (release feeds only), evaluate alerts, score relevance, embed, cluster and notify
+// webhooks. Only the first three steps fail the workflow, after marking the item as failed. Steps
+// added since the first release are gated with workflow.GetVersion, so running workflows replay.
 //
 // @param ctx - Workflow context
 // @param feedItem - The feed item to ingest
@@ -21,13 +25,7 @@ func IngestFeedItem() func(ctx workflow.Context, feedItem internal.FeedItem) err
 	return func(ctx workflow.Context, feedItem internal.FeedItem) error {
 		workflow.GetLogger(ctx).Info("Ingest feed item workflow started.", "link", feedItem.Link, "title", feedItem.Title)
 
-		ao := workflow.ActivityOptions{
-			StartToCloseTimeout: 5 * time.Minute,
-			RetryPolicy: &temporal.RetryPolicy{
-				MaximumAttempts: 3,
-			},
-		}
-		ctx = workflow.WithActivityOptions(ctx, ao)
+		ctx, llmCtx := withActivityOptions(ctx)
 
 		// First activity: add feed item to MongoDB and get the document with ID
 		var feedItemDoc internal.FeedItemDocument
@@ -41,18 +39,199 @@ func IngestFeedItem() func(ctx workflow.Context, feedItem internal.FeedItem) err
 		err = workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.FetchHTML), feedItemDoc).Get(ctx, nil)
 		if err != nil {
 			workflow.GetLogger(ctx).Error("fetchHTMLActivity activity failed.", "Error", err)
+			markFailed(ctx, feedItemDoc, err)
 			return err
 		}
 
-		// Third activity: process content (summary and categories)
-		err = workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.ProcessContent), feedItemDoc).Get(ctx, nil)
+		// Third activity: process content (summary and categories), pausing while the daily LLM budget is exhausted
+		err = executeLLMActivity(ctx, llmCtx, internal.GetFunctionName(activity.ProcessContent), feedItemDoc)
 		if err != nil {
 			workflow.GetLogger(ctx).Error("processContentActivity activity failed.", "Error", err)
+			markFailed(ctx, feedItemDoc, err)
 			return err
 		}
 
+		// Fourth step: items of release feeds also get structured release information
+		if feedItem.FeedType == internal.FeedTypeRelease && versioned(ctx, "extract-release") {
+			err = executeLLMActivity(ctx, llmCtx, internal.GetFunctionName(activity.ExtractRelease), feedItemDoc)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("extractReleaseActivity activity failed, continuing without release information.", "Error", err)
+			}
+		}
+
+		// Fifth step: evaluate the alert rules, which can match on the processed content and release information
+		if versioned(ctx, "alerts") {
+			notifyAlerts(ctx, feedItemDoc)
+		}
+
+		// Sixth step: rank the item for the team interest profiles
+		if versioned(ctx, "score-relevance") {
+			err = workflow.ExecuteActivity(llmCtx, internal.GetFunctionName(activity.ScoreRelevance), feedItemDoc).Get(ctx, nil)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("scoreRelevanceActivity activity failed, continuing without relevance.", "Error", err)
+			}
+		}
+
+		// Seventh step: embed the summary and article text for semantic search
+		if versioned(ctx, "embed") {
+			err = workflow.ExecuteActivity(llmCtx, internal.GetFunctionName(activity.EmbedFeedItem), feedItemDoc).Get(ctx, nil)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("embedFeedItemActivity activity failed, continuing without embeddings.", "Error", err)
+			}
+		}
+
+		// Eighth step: group near-duplicates from other feeds into a story cluster
+		if versioned(ctx, "cluster") {
+			err = workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.ClusterFeedItem), feedItemDoc).Get(ctx, nil)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("clusterFeedItemActivity activity failed, continuing without clustering.", "Error", err)
+			}
+		}
+
+		// Ninth step: push the processed item to the matching webhook subscriptions
+		if versioned(ctx, "webhooks") {
+			notifyWebhooks(ctx, feedItemDoc)
+		}
+
 		workflow.GetLogger(ctx).Info("Ingest feed item workflow completed.")
 
 		return nil
 	}
 }
+
+// withActivityOptions returns ctx with the options of regular activities, and a context with the
+// options of LLM activities.
+func withActivityOptions(ctx workflow.Context) (workflow.Context, workflow.Context) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+
+	// LLM activities run on their own task queue and get a longer timeout, as one activity may make
+	// several calls to a slow local model, such as when summarizing a long article in chunks
+	llmCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:           internal.LLMTaskQueueName,
+		StartToCloseTimeout: 15 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			MaximumAttempts: 3,
+		},
+	})
+	return ctx, llmCtx
+}
+
+// minBudgetPause is the shortest pause while the daily LLM budget is exhausted, so a reset time
+// that has already passed does not make the workflow spin
+const minBudgetPause = time.Minute
+
+// executeLLMActivity executes an LLM activity, pausing the workflow while the daily LLM budget is
+// exhausted and executing it again once the budget resets, for as long as it stays exhausted. A
+// budget error never fails the item.
+func executeLLMActivity(ctx, llmCtx workflow.Context, name string, feedItemDoc internal.FeedItemDocument) error {
+	for {
+		err := workflow.ExecuteActivity(llmCtx, name, feedItemDoc).Get(ctx, nil)
+		resetAt, exceeded := activity.BudgetResetAt(err)
+		if !exceeded {
+			return err
+		}
+		pause := budgetPause(resetAt, workflow.Now(ctx))
+		workflow.GetLogger(ctx).Warn("Daily LLM budget exceeded, pausing LLM activity.", "activity", name, "resetAt", resetAt, "pause", pause)
+		if err := workflow.Sleep(ctx, pause); err != nil {
+			return err
+		}
+	}
+}
+
+// budgetPause returns how long to pause at now for the budget to reset at resetAt, an hour when
+// the reset time is unknown and at least minBudgetPause.
+func budgetPause(resetAt, now time.Time) time.Duration {
+	if resetAt.IsZero() {
+		return time.Hour
+	}
+	return max(resetAt.Sub(now), minBudgetPause)
+}
+
+// notifyWebhooks delivers the feed item to every matching webhook subscription in parallel. Each
+// delivery is retried with exponential backoff for about twenty minutes, so a webhook that is down
+// briefly still receives the item. Failed deliveries are only logged.
+func notifyWebhooks(ctx workflow.Context, feedItemDoc internal.FeedItemDocument) {
+	var subscriptionIDs []string
+	err := workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.NotifyWebhooks), feedItemDoc).Get(ctx, &subscriptionIDs)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("notifyWebhooksActivity activity failed, not delivering webhooks.", "Error", err)
+		return
+	}
+	if len(subscriptionIDs) == 0 {
+		return
+	}
+
+	deliverCtx := workflow.WithActivityOptions(ctx, notificationOptions(activity.ErrTypeWebhookRejected))
+	futures := make([]workflow.Future, 0, len(subscriptionIDs))
+	for _, id := range subscriptionIDs {
+		futures = append(futures, workflow.ExecuteActivity(deliverCtx, internal.GetFunctionName(activity.DeliverWebhook), id, feedItemDoc))
+	}
+	for i, f := range futures {
+		if err := f.Get(ctx, nil); err != nil {
+			workflow.GetLogger(ctx).Warn("deliverWebhookActivity activity failed.", "subscription", subscriptionIDs[i], "Error", err)
+		}
+	}
+}
+
+// notifyAlerts evaluates the alert rules against the feed item and notifies every target of the
+// matched rules in parallel, retried like webhook deliveries. Failed notifications are only logged.
+func notifyAlerts(ctx workflow.Context, feedItemDoc internal.FeedItemDocument) {
+	var notifications []alert.Notification
+	err := workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.EvaluateAlerts), feedItemDoc).Get(ctx, &notifications)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("evaluateAlertsActivity activity failed, not sending alerts.", "Error", err)
+		return
+	}
+	if len(notifications) == 0 {
+		return
+	}
+
+	notifyCtx := workflow.WithActivityOptions(ctx, notificationOptions(activity.ErrTypeAlertRejected))
+	futures := make([]workflow.Future, 0, len(notifications))
+	for _, n := range notifications {
+		futures = append(futures, workflow.ExecuteActivity(notifyCtx, internal.GetFunctionName(activity.NotifyAlert), n, feedItemDoc))
+	}
+	for i, f := range futures {
+		if err := f.Get(ctx, nil); err != nil {
+			workflow.GetLogger(ctx).Warn("notifyAlertActivity activity failed.", "rule", notifications[i].RuleID, "target", notifications[i].Target, "Error", err)
+		}
+	}
+}
+
+// notificationOptions are the activity options of deliveries to external endpoints, retried with
+// exponential backoff for about twenty minutes unless they fail with the non-retryable error type.
+func notificationOptions(nonRetryableErrorType string) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        10 * time.Second,
+			BackoffCoefficient:     2,
+			MaximumInterval:        10 * time.Minute,
+			MaximumAttempts:        8,
+			NonRetryableErrorTypes: []string{nonRetryableErrorType},
+		},
+	}
+}
+
+// versioned reports whether the workflow runs the step changeID, which workflows started before
+// the step was added did not.
+func versioned(ctx workflow.Context, changeID string) bool {
+	return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, 1) >= 1
+}
+
+// markFailed records on the feed item document that ingestion failed. Failing to do so is only
+// logged, as the workflow is already failing with cause.
+func markFailed(ctx workflow.Context, feedItemDoc internal.FeedItemDocument, cause error) {
+	if !versioned(ctx, "mark-failed") {
+		return
+	}
+	err := workflow.ExecuteActivity(ctx, internal.GetFunctionName(activity.MarkFeedItemFailed), feedItemDoc, cause.Error()).Get(ctx, nil)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("markFeedItemFailedActivity activity failed.", "Error", err)
+	}
+}
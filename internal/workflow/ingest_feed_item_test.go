@@ -0,0 +1,75 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// TestIngestFeedItem_ReplaysFirstRelease replays the history of a workflow run by the first
+// release, which only added, fetched and processed the item, so steps added since then must be
+// gated for workflows still running when a worker is upgraded.
+func TestIngestFeedItem_ReplaysFirstRelease(t *testing.T) {
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflowWithOptions(IngestFeedItem(), workflow.RegisterOptions{
+		Name: internal.GetFunctionName(IngestFeedItem),
+	})
+	if err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, "testdata/ingest_feed_item_first_release.json"); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+}
+
+func TestExecuteLLMActivity_PausesUntilBudgetResets(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	// The budget stays exhausted over several resets, as when the budget is too small for a day
+	calls := 0
+	env.RegisterActivityWithOptions(func(context.Context, internal.FeedItemDocument) error {
+		calls++
+		if calls <= 5 {
+			return temporal.NewNonRetryableApplicationError("daily LLM budget exceeded", activity.ErrTypeBudgetExceeded, nil)
+		}
+		return nil
+	}, sdkactivity.RegisterOptions{Name: internal.GetFunctionName(activity.ProcessContent)})
+	for _, fn := range []any{activity.ScoreRelevance, activity.EmbedFeedItem} {
+		env.RegisterActivityWithOptions(func(context.Context, internal.FeedItemDocument) error {
+			return nil
+		}, sdkactivity.RegisterOptions{Name: internal.GetFunctionName(fn)})
+	}
+
+	env.ExecuteWorkflow(ReprocessFeedItem(), internal.FeedItemDocument{ID: primitive.NewObjectID()})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	if calls != 6 {
+		t.Errorf("expected the activity to run again after each pause, got %d calls", calls)
+	}
+}
+
+func TestBudgetPause(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		resetAt time.Time
+		want    time.Duration
+	}{
+		{"until the reset", now.Add(3 * time.Hour), 3 * time.Hour},
+		{"unknown reset", time.Time{}, time.Hour},
+		{"passed reset", now.Add(-time.Minute), minBudgetPause},
+	}
+	for _, c := range cases {
+		if got := budgetPause(c.resetAt, now); got != c.want {
+			t.Errorf("%s: budgetPause = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
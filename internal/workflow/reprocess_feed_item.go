@@ -0,0 +1,52 @@
+package workflow
+
+import (
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ReprocessFeedItem is the workflow function that processes an ingested feed item again, such as
+// after a prompt or model change, bypassing the LLM response cache. It reruns the LLM steps of
+// IngestFeedItem: process content, extract release information (release feeds only), score
+// relevance and embed. Alerts and webhooks are not sent again.
+//
+// @param ctx - Workflow context
+// @param feedItemDoc - The feed item document to reprocess
+// @return error - Returns an error if processing the content fails
+// @author Thomas De Meyer
+func ReprocessFeedItem() func(ctx workflow.Context, feedItemDoc internal.FeedItemDocument) error {
+	return func(ctx workflow.Context, feedItemDoc internal.FeedItemDocument) error {
+		workflow.GetLogger(ctx).Info("Reprocess feed item workflow started.", "id", feedItemDoc.ID.Hex(), "link", feedItemDoc.Link)
+
+		ctx, llmCtx := withActivityOptions(ctx)
+		feedItemDoc.BypassCache = true
+
+		err := executeLLMActivity(ctx, llmCtx, internal.GetFunctionName(activity.ProcessContent), feedItemDoc)
+		if err != nil {
+			workflow.GetLogger(ctx).Error("processContentActivity activity failed.", "Error", err)
+			return err
+		}
+
+		if feedItemDoc.FeedType == internal.FeedTypeRelease {
+			err = executeLLMActivity(ctx, llmCtx, internal.GetFunctionName(activity.ExtractRelease), feedItemDoc)
+			if err != nil {
+				workflow.GetLogger(ctx).Warn("extractReleaseActivity activity failed, keeping the previous release information.", "Error", err)
+			}
+		}
+
+		err = workflow.ExecuteActivity(llmCtx, internal.GetFunctionName(activity.ScoreRelevance), feedItemDoc).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("scoreRelevanceActivity activity failed, keeping the previous relevance.", "Error", err)
+		}
+
+		err = workflow.ExecuteActivity(llmCtx, internal.GetFunctionName(activity.EmbedFeedItem), feedItemDoc).Get(ctx, nil)
+		if err != nil {
+			workflow.GetLogger(ctx).Warn("embedFeedItemActivity activity failed, keeping the previous embeddings.", "Error", err)
+		}
+
+		workflow.GetLogger(ctx).Info("Reprocess feed item workflow completed.")
+
+		return nil
+	}
+}
@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/demeyerthom/feeds-aggregator/internal"
+	"github.com/demeyerthom/feeds-aggregator/internal/activity"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	sdkactivity "go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/testsuite"
+)
+
+func TestReprocessFeedItem_BypassesCache(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+
+	var processed []internal.FeedItemDocument
+	env.RegisterActivityWithOptions(func(_ context.Context, doc internal.FeedItemDocument) error {
+		processed = append(processed, doc)
+		return nil
+	}, sdkactivity.RegisterOptions{Name: internal.GetFunctionName(activity.ProcessContent)})
+	for _, fn := range []any{activity.ScoreRelevance, activity.EmbedFeedItem} {
+		env.RegisterActivityWithOptions(func(context.Context, internal.FeedItemDocument) error {
+			return nil
+		}, sdkactivity.RegisterOptions{Name: internal.GetFunctionName(fn)})
+	}
+
+	env.ExecuteWorkflow(ReprocessFeedItem(), internal.FeedItemDocument{ID: primitive.NewObjectID(), Title: "Go 1.25 is released"})
+	if err := env.GetWorkflowError(); err != nil {
+		t.Fatalf("workflow failed: %v", err)
+	}
+	if len(processed) != 1 || !processed[0].BypassCache {
+		t.Errorf("expected the content to be processed once past the cache, got %+v", processed)
+	}
+}